@@ -0,0 +1,299 @@
+package cookiesignature
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"hash"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	algorithmPurpose = "alg"
+	algorithmTagEnd  = ':'
+)
+
+// ErrAlgorithmNotAllowed is returned when the algorithm of a signature is outside the allowlist of WithAllowedAlgorithms
+var ErrAlgorithmNotAllowed = errors.New("signature algorithm is not allowed")
+
+// Algorithm is the MAC algorithm named by the compact tag of tagged signatures
+type Algorithm string
+
+const (
+	// AlgorithmHS256 is HMAC-SHA256, the algorithm of node-cookie-signature
+	AlgorithmHS256 Algorithm = "hs256"
+	// AlgorithmHS384 is HMAC-SHA384
+	AlgorithmHS384 Algorithm = "hs384"
+	// AlgorithmHS512 is HMAC-SHA512
+	AlgorithmHS512 Algorithm = "hs512"
+	// AlgorithmHB2B256 is HMAC-BLAKE2b-256
+	AlgorithmHB2B256 Algorithm = "hb2b256"
+	// AlgorithmHB2B512 is HMAC-BLAKE2b-512
+	AlgorithmHB2B512 Algorithm = "hb2b512"
+	// AlgorithmB2B256 is BLAKE2b-256 in keyed mode
+	AlgorithmB2B256 Algorithm = "b2b256"
+	// AlgorithmB2B512 is BLAKE2b-512 in keyed mode
+	AlgorithmB2B512 Algorithm = "b2b512"
+	// AlgorithmHS1 is HMAC-SHA1, only verified in the untagged signatures of legacy signers enabled by WithLegacySHA1
+	AlgorithmHS1 Algorithm = "hs1"
+)
+
+// macAlgorithm is the MAC of an algorithm, the HMAC of the hash or the hash in keyed mode.
+// The zero value is HMAC-SHA256
+type macAlgorithm struct {
+	hash  crypto.Hash
+	keyed bool
+}
+
+// macAlgorithms are the algorithms able to verify tagged signatures, with their MAC
+var macAlgorithms = map[Algorithm]macAlgorithm{
+	AlgorithmHS256:   {hash: crypto.SHA256},
+	AlgorithmHS384:   {hash: crypto.SHA384},
+	AlgorithmHS512:   {hash: crypto.SHA512},
+	AlgorithmHB2B256: {hash: crypto.BLAKE2b_256},
+	AlgorithmHB2B512: {hash: crypto.BLAKE2b_512},
+	AlgorithmB2B256:  {hash: crypto.BLAKE2b_256, keyed: true},
+	AlgorithmB2B512:  {hash: crypto.BLAKE2b_512, keyed: true},
+}
+
+// WithHash sets the hash of the HMAC of the signatures, crypto.SHA256 by default, crypto.SHA384, crypto.SHA512,
+// crypto.BLAKE2b_256 or crypto.BLAKE2b_512. Only SHA-256 signatures interoperate with node-cookie-signature.
+// Unsign detects the hash of untagged signatures by their length, so signers can move to another hash of another length
+// without invalidating the values signed before. Hashes of the same length, e.g. SHA-256 and BLAKE2b-256,
+// can only be told apart by the tags of WithAlgorithmTag
+func WithHash(hash crypto.Hash) Option {
+	return func(o *options) {
+		o.hash = hash
+	}
+}
+
+// WithKeyedBLAKE2b signs with BLAKE2b in keyed mode instead of an HMAC, faster since the hash is computed once.
+// The size of the signatures is the one of the hash of WithHash, crypto.BLAKE2b_256 by default or crypto.BLAKE2b_512.
+// Secrets longer than 64 bytes, the longest BLAKE2b key, are hashed with BLAKE2b-512 first
+func WithKeyedBLAKE2b() Option {
+	return func(o *options) {
+		o.keyedBLAKE2b = true
+	}
+}
+
+// algorithm returns the algorithm of the signatures
+func (o options) algorithm() Algorithm {
+	for algorithm, mac := range macAlgorithms {
+		if mac == o.macAlgorithm() {
+			return algorithm
+		}
+	}
+	return AlgorithmHS256
+}
+
+// macAlgorithm returns the MAC of the signatures
+func (o options) macAlgorithm() macAlgorithm {
+	return macAlgorithm{hash: o.macHash(), keyed: o.keyedBLAKE2b}
+}
+
+// macHash returns the hash of the MAC of the signatures
+func (o options) macHash() crypto.Hash {
+	switch {
+	case o.hash != 0:
+		return o.hash
+	case o.keyedBLAKE2b:
+		return crypto.BLAKE2b_256
+	}
+	return crypto.SHA256
+}
+
+// WithLegacySHA1 makes Unsign verify the untagged HMAC-SHA1 signatures of legacy signers, e.g. old node-cookie-signature releases,
+// detected by their length. Values are still signed with the hash of WithHash, so legacy sessions keep working
+// while they are re-signed, e.g. by UpgradeCookies. WithAllowedAlgorithms must include AlgorithmHS1 if it is set
+func WithLegacySHA1() Option {
+	return func(o *options) {
+		o.legacySHA1 = true
+	}
+}
+
+func (o options) validateHash() error {
+	for _, mac := range macAlgorithms {
+		if mac == o.macAlgorithm() {
+			return nil
+		}
+	}
+	if o.keyedBLAKE2b {
+		return errors.New("unsupported hash, keyed mode uses crypto.BLAKE2b_256 or crypto.BLAKE2b_512")
+	}
+	return errors.New("unsupported hash, use crypto.SHA256, crypto.SHA384, crypto.SHA512, crypto.BLAKE2b_256 or crypto.BLAKE2b_512")
+}
+
+// verificationMAC returns the MAC verifying the signatures of the algorithm
+func (o options) verificationMAC(algorithm Algorithm) (macAlgorithm, error) {
+	if algorithm == AlgorithmHS1 {
+		if !o.legacySHA1 {
+			return macAlgorithm{}, errInvalidSignature
+		}
+		return macAlgorithm{hash: crypto.SHA1}, nil
+	}
+	return macAlgorithms[algorithm], nil
+}
+
+func newHash(h crypto.Hash) (func() hash.Hash, bool) {
+	switch h {
+	case crypto.SHA1:
+		return sha1.New, true
+	case crypto.SHA256:
+		return sha256.New, true
+	case crypto.SHA384:
+		return sha512.New384, true
+	case crypto.SHA512:
+		return sha512.New, true
+	case crypto.BLAKE2b_256, crypto.BLAKE2b_512:
+		size := h.Size()
+		return func() hash.Hash {
+			b, _ := blake2b.New(size, nil)
+			return b
+		}, true
+	}
+	return nil, false
+}
+
+// compute computes the MAC of the input with the secret
+func (m macAlgorithm) compute(input string, secret []byte) ([]byte, error) {
+	switch {
+	case m.keyed:
+		return computeKeyedBLAKE2b(m.hash, input, secret)
+	case m.hash == 0:
+		return computeHMAC256(input, secret)
+	}
+	return computeHMAC(m.hash, input, secret)
+}
+
+// computeHMAC computes the HMAC of the input with the hash
+func computeHMAC(hash crypto.Hash, input string, secret []byte) ([]byte, error) {
+	newFunc, ok := newHash(hash)
+	if !ok {
+		return nil, errors.New("unsupported hash")
+	}
+	mac := hmac.New(newFunc, secret)
+	if _, err := mac.Write(stringToBytes(input)); err != nil {
+		return nil, err
+	}
+	return mac.Sum(nil), nil
+}
+
+// computeKeyedBLAKE2b computes the BLAKE2b of the input keyed with the secret
+func computeKeyedBLAKE2b(hash crypto.Hash, input string, secret []byte) ([]byte, error) {
+	if len(secret) > blake2b.Size {
+		sum := blake2b.Sum512(secret)
+		secret = sum[:]
+	}
+	h, err := blake2b.New(hash.Size(), secret)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := h.Write(stringToBytes(input)); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// untaggedAlgorithm detects the algorithm of an untagged signature by its length, without decoding it,
+// the algorithm of the signer first since several algorithms may share a length
+func (o options) untaggedAlgorithm(signature string) Algorithm {
+	signature = strings.TrimSpace(signature)
+	unpadded := len(strings.TrimRight(signature, "="))
+	encodes := func(size int) bool {
+		length := o.codec().EncodedLen(size)
+		return length == len(signature) || length == unpadded
+	}
+	switch {
+	case encodes(o.macHash().Size()):
+		return o.algorithm()
+	case encodes(sha512.Size384):
+		return AlgorithmHS384
+	case encodes(sha512.Size):
+		return AlgorithmHS512
+	case encodes(sha1.Size):
+		return AlgorithmHS1
+	}
+	return AlgorithmHS256
+}
+
+// WithAlgorithmTag prefixes the signatures with the compact tag of the algorithm, e.g. "hello.hs256:<signature>".
+// The tag is covered by the MAC, so fleets running different algorithms can verify each other's cookies
+// and a downgrade of the tag breaks the signature. Unsign accepts tagged signatures whether the option is set or not,
+// so it can be rolled out one instance at a time
+func WithAlgorithmTag() Option {
+	return func(o *options) {
+		o.algorithmTag = true
+	}
+}
+
+// WithAllowedAlgorithms restricts the algorithms that Unsign accepts, whether named by the tag of the signature
+// or detected from an untagged signature, and rejects any other one with ErrAlgorithmNotAllowed.
+// It keeps a legacy algorithm enabled for a migration from weakening every signer. Untagged signatures are detected by their length.
+// Every supported algorithm is accepted if no algorithm is given
+func WithAllowedAlgorithms(algorithms ...Algorithm) Option {
+	return func(o *options) {
+		if len(algorithms) == 0 {
+			o.allowedAlgorithms = nil
+			return
+		}
+		o.allowedAlgorithms = make(map[Algorithm]bool, len(algorithms))
+		for _, algorithm := range algorithms {
+			o.allowedAlgorithms[algorithm] = true
+		}
+	}
+}
+
+func (o options) checkAlgorithm(algorithm Algorithm) error {
+	if o.allowedAlgorithms != nil && !o.allowedAlgorithms[algorithm] {
+		return ErrAlgorithmNotAllowed
+	}
+	return nil
+}
+
+// signatureTag returns the tag prefixing the signatures, empty if untagged
+func (o options) signatureTag() string {
+	if !o.algorithmTag {
+		return ""
+	}
+	return string(o.algorithm()) + string(algorithmTagEnd)
+}
+
+// macInput returns the input whose MAC signs the value
+func (o options) macInput(value string) string {
+	if !o.algorithmTag {
+		return value
+	}
+	return taggedMACInput(o.algorithm(), value)
+}
+
+func taggedMACInput(algorithm Algorithm, value string) string {
+	return encodeValues([]string{algorithmPurpose, string(algorithm), value})
+}
+
+// parseAlgorithmTag splits a tagged input into the value and the untagged input verifying it, with the algorithm of the signature.
+// tagged is false if the signature isn't tagged, the algorithm is then detected from the signature
+func (o options) parseAlgorithmTag(input string) (value string, untagged string, algorithm Algorithm, tagged bool, err error) {
+	index := strings.LastIndexByte(input, '.')
+	if index < 0 {
+		return "", "", o.algorithm(), false, nil
+	}
+	end := strings.IndexByte(input[index+1:], algorithmTagEnd)
+	if end < 0 {
+		algorithm = o.untaggedAlgorithm(input[index+1:])
+		return "", "", algorithm, false, o.checkAlgorithm(algorithm)
+	}
+	algorithm = Algorithm(input[index+1 : index+1+end])
+	if err := o.checkAlgorithm(algorithm); err != nil {
+		return "", "", algorithm, true, err
+	}
+	if _, ok := macAlgorithms[algorithm]; !ok {
+		return "", "", algorithm, true, errInvalidSignature
+	}
+	value = input[:index]
+	return value, taggedMACInput(algorithm, value) + "." + input[index+2+end:], algorithm, true, nil
+}
@@ -0,0 +1,224 @@
+package cookiesignature
+
+import (
+	"crypto"
+	"strings"
+	"testing"
+)
+
+func TestAlgorithmTag(t *testing.T) {
+	tagged, _ := NewCookieSignature([]string{"tobiiscool"}, WithAlgorithmTag())
+	untagged, _ := NewCookieSignature([]string{"tobiiscool"})
+
+	val, err := tagged.Sign("hello")
+	assertEqual(t, "hello.hs256:uZV1qDYJ1h8SMjxE+HtqXUSkGpnAqXxZwyPmmwohytU", val, err)
+
+	for _, cs := range []*CookieSignature{tagged, untagged} {
+		result, err := cs.Unsign("hello.hs256:uZV1qDYJ1h8SMjxE+HtqXUSkGpnAqXxZwyPmmwohytU")
+		assertEqual(t, "hello", result, err)
+		result, err = cs.Unsign("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI")
+		assertEqual(t, "hello", result, err)
+	}
+
+	// the tag is covered by the MAC, it can neither be stripped nor replaced
+	for _, forged := range []string{
+		"hello.uZV1qDYJ1h8SMjxE+HtqXUSkGpnAqXxZwyPmmwohytU",
+		"hello.hs256:DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI",
+		"hello.hs1:uZV1qDYJ1h8SMjxE+HtqXUSkGpnAqXxZwyPmmwohytU",
+		"hello.:uZV1qDYJ1h8SMjxE+HtqXUSkGpnAqXxZwyPmmwohytU",
+	} {
+		if _, err := untagged.Unsign(forged); err != errInvalidSignature {
+			t.Fatalf("expected error: %s for %s, got: %v", errInvalidSignature, forged, err)
+		}
+	}
+
+	encoded, _ := NewCookieSignature([]string{"tobiiscool"}, WithAlgorithmTag(), WithURLEncoding(), WithBufferPool(false))
+	val, err = encoded.Sign("hello")
+	assertEqual(t, "hello.hs256%3AuZV1qDYJ1h8SMjxE%2BHtqXUSkGpnAqXxZwyPmmwohytU", val, err)
+	result, err := encoded.Unsign(val)
+	assertEqual(t, "hello", result, err)
+}
+
+func TestAllowedAlgorithms(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"}, WithAllowedAlgorithms(AlgorithmHS256))
+	result, err := cs.Unsign("hello.hs256:uZV1qDYJ1h8SMjxE+HtqXUSkGpnAqXxZwyPmmwohytU")
+	assertEqual(t, "hello", result, err)
+	result, err = cs.Unsign("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI")
+	assertEqual(t, "hello", result, err)
+	if _, err := cs.Unsign("hello.hs1:uZV1qDYJ1h8SMjxE+HtqXUSkGpnAqXxZwyPmmwohytU"); err != ErrAlgorithmNotAllowed {
+		t.Fatalf("expected error: %s, got: %v", ErrAlgorithmNotAllowed, err)
+	}
+
+	cs, _ = NewCookieSignature([]string{"tobiiscool"}, WithAllowedAlgorithms("hs512"))
+	for _, input := range []string{"hello.hs256:uZV1qDYJ1h8SMjxE+HtqXUSkGpnAqXxZwyPmmwohytU", "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"} {
+		if _, err := cs.Unsign(input); err != ErrAlgorithmNotAllowed {
+			t.Fatalf("expected error: %s for %s, got: %v", ErrAlgorithmNotAllowed, input, err)
+		}
+	}
+
+	cs, _ = NewCookieSignature([]string{"tobiiscool"}, WithAllowedAlgorithms("hs512"), WithAllowedAlgorithms())
+	result, err = cs.Unsign("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI")
+	assertEqual(t, "hello", result, err)
+}
+
+func TestWithHash(t *testing.T) {
+	sha384, _ := NewCookieSignature([]string{"tobiiscool"}, WithHash(crypto.SHA384))
+	sha512, _ := NewCookieSignature([]string{"tobiiscool"}, WithHash(crypto.SHA512))
+	sha256, _ := NewCookieSignature([]string{"tobiiscool"}, WithHash(crypto.SHA256))
+
+	val, err := sha384.Sign("hello")
+	assertEqual(t, "hello.RJljN3thz3FGathvIVhUDu5T2fohzb9YVHfOB+dId9Y+JHYcQlIhSUP6WioF2sFr", val, err)
+	val, err = sha512.Sign("hello")
+	assertEqual(t, "hello.kVofCuivbz8r8NiCfWJV5JsZQBX6WGHgt8ihyKktT7OdEObssUD5JItXNDH9PIaD+rTEQTK9V6+prF9qR0aDSg", val, err)
+	val, err = sha256.Sign("hello")
+	assertEqual(t, "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", val, err)
+
+	// the hash of untagged signatures is detected by their length, so a signer can change its hash
+	for _, cs := range []*CookieSignature{sha256, sha384, sha512} {
+		for _, input := range []string{
+			"hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI",
+			"hello.RJljN3thz3FGathvIVhUDu5T2fohzb9YVHfOB+dId9Y+JHYcQlIhSUP6WioF2sFr",
+			"hello.kVofCuivbz8r8NiCfWJV5JsZQBX6WGHgt8ihyKktT7OdEObssUD5JItXNDH9PIaD-rTEQTK9V6-prF9qR0aDSg==",
+		} {
+			result, err := cs.Unsign(input)
+			assertEqual(t, "hello", result, err)
+		}
+	}
+
+	tagged, _ := NewCookieSignature([]string{"tobiiscool"}, WithHash(crypto.SHA512), WithAlgorithmTag())
+	val, err = tagged.Sign("hello")
+	assertEqual(t, "hello.hs512:Rzpa8REA4G8EajIDXSqP7qNya+ew9A57ksajXvQ/Kc/MLznCHu6ijlLP1WyfY/OZyFu1CLVwvD5tYTIzcjPpSQ", val, err)
+	result, err := sha256.Unsign(val)
+	assertEqual(t, "hello", result, err)
+
+	allowed, _ := NewCookieSignature([]string{"tobiiscool"}, WithHash(crypto.SHA512), WithAllowedAlgorithms(AlgorithmHS512))
+	if _, err := allowed.Unsign("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"); err != ErrAlgorithmNotAllowed {
+		t.Fatalf("expected error: %s, got: %v", ErrAlgorithmNotAllowed, err)
+	}
+
+	// signatures of every format use the hash
+	scoped, err := sha512.SignScoped("hello", CookieScope{Path: "/admin"})
+	if err != nil || len(scoped) != len("hello.")+86 {
+		t.Fatalf("unexpected scoped value: %s, %v", scoped, err)
+	}
+	result, err = sha512.UnsignScoped(scoped, CookieScope{Path: "/admin"})
+	assertEqual(t, "hello", result, err)
+
+	if _, err := NewCookieSignature([]string{"tobiiscool"}, WithHash(crypto.MD5)); err == nil {
+		t.Fatal("expected an error for an unsupported hash")
+	}
+	if value, _ := ParseSignedValue("hello.RJljN3thz3FGathvIVhUDu5T2fohzb9YVHfOB+dId9Y+JHYcQlIhSUP6WioF2sFr"); value.Algorithm() != AlgorithmHS384 {
+		t.Fatalf("expected algorithm: %s, got: %s", AlgorithmHS384, value.Algorithm())
+	}
+}
+
+func TestLegacySHA1(t *testing.T) {
+	legacy, _ := NewCookieSignature([]string{"tobiiscool"}, WithLegacySHA1())
+	strict, _ := NewCookieSignature([]string{"tobiiscool"})
+
+	// values are still signed with SHA-256
+	val, err := legacy.Sign("hello")
+	assertEqual(t, "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", val, err)
+
+	for _, input := range []string{"hello.y9PTymc7lhrBjf9ncZvVQToR04Q", "hello.y9PTymc7lhrBjf9ncZvVQToR04Q=", "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"} {
+		result, err := legacy.Unsign(input)
+		assertEqual(t, "hello", result, err)
+	}
+	if _, err := strict.Unsign("hello.y9PTymc7lhrBjf9ncZvVQToR04Q"); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	// legacy signatures are never tagged
+	if _, err := legacy.Unsign("hello.hs1:y9PTymc7lhrBjf9ncZvVQToR04Q"); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+
+	allowed, _ := NewCookieSignature([]string{"tobiiscool"}, WithLegacySHA1(), WithAllowedAlgorithms(AlgorithmHS256))
+	if _, err := allowed.Unsign("hello.y9PTymc7lhrBjf9ncZvVQToR04Q"); err != ErrAlgorithmNotAllowed {
+		t.Fatalf("expected error: %s, got: %v", ErrAlgorithmNotAllowed, err)
+	}
+	allowed, _ = NewCookieSignature([]string{"tobiiscool"}, WithLegacySHA1(), WithAllowedAlgorithms(AlgorithmHS256, AlgorithmHS1))
+	result, err := allowed.Unsign("hello.y9PTymc7lhrBjf9ncZvVQToR04Q")
+	assertEqual(t, "hello", result, err)
+
+	if _, err := NewCookieSignature([]string{"tobiiscool"}, WithHash(crypto.SHA1)); err == nil {
+		t.Fatal("expected an error signing with SHA-1")
+	}
+}
+
+func TestBLAKE2b(t *testing.T) {
+	keyed, _ := NewCookieSignature([]string{"tobiiscool"}, WithKeyedBLAKE2b())
+	keyed512, _ := NewCookieSignature([]string{"tobiiscool"}, WithKeyedBLAKE2b(), WithHash(crypto.BLAKE2b_512))
+	hmacBLAKE2b, _ := NewCookieSignature([]string{"tobiiscool"}, WithHash(crypto.BLAKE2b_256))
+	long, _ := NewCookieSignature([]string{strings.Repeat("x", 100)}, WithKeyedBLAKE2b())
+
+	for _, tc := range []struct {
+		cs       *CookieSignature
+		expected string
+	}{
+		{keyed, "hello.db+ayC3XUdnGmsIZxxvzn29Ljm7scUt4C3yzLoCvyHQ"},
+		{keyed512, "hello.gsfG+DbTbJA/Ekezfpk09eUzEg/9KBNV7q/HkM76EL0BKen8yIgmecAhYd9xLMZUAwyP5W1+meiGYnjjFnPp5Q"},
+		{hmacBLAKE2b, "hello.hJ2SqHq593AJkPC7EBDZw2xQsfRENhl4z3K8VjV9YU4"},
+		// secrets longer than 64 bytes are hashed first
+		{long, "hello.iPcV2IFMHljrV9P0UkKM43xo9aONuYUgJUnrM7KaSY8"},
+	} {
+		val, err := tc.cs.Sign("hello")
+		assertEqual(t, tc.expected, val, err)
+		result, err := tc.cs.Unsign(val)
+		assertEqual(t, "hello", result, err)
+	}
+
+	// untagged signatures of the same length are verified with the algorithm of the signer
+	if _, err := keyed.Unsign("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	result, err := keyed.Unsign("hello.hs256:uZV1qDYJ1h8SMjxE+HtqXUSkGpnAqXxZwyPmmwohytU")
+	assertEqual(t, "hello", result, err)
+
+	tagged, _ := NewCookieSignature([]string{"tobiiscool"}, WithKeyedBLAKE2b(), WithAlgorithmTag())
+	val, err := tagged.Sign("hello")
+	if err != nil || !strings.HasPrefix(val, "hello.b2b256:") {
+		t.Fatalf("unexpected tagged value: %s, %v", val, err)
+	}
+	untagged, _ := NewCookieSignature([]string{"tobiiscool"})
+	result, err = untagged.Unsign(val)
+	assertEqual(t, "hello", result, err)
+	allowed, _ := NewCookieSignature([]string{"tobiiscool"}, WithAllowedAlgorithms(AlgorithmHS256))
+	if _, err := allowed.Unsign(val); err != ErrAlgorithmNotAllowed {
+		t.Fatalf("expected error: %s, got: %v", ErrAlgorithmNotAllowed, err)
+	}
+
+	if _, err := NewCookieSignature([]string{"tobiiscool"}, WithKeyedBLAKE2b(), WithHash(crypto.SHA256)); err == nil {
+		t.Fatal("expected an error for a keyed SHA-256")
+	}
+}
+
+func TestRejectedAlgorithmTiming(t *testing.T) {
+	var macs int
+	provider := macProviderFunc(func(input []byte) ([]byte, error) {
+		macs++
+		return testMACProvider("tobiiscool").MAC(input)
+	})
+	cs, _ := NewCookieSignatureFromMAC([]MACProvider{provider, provider}, WithAllowedAlgorithms(AlgorithmHS256))
+
+	// the MACs are computed whether the algorithm is rejected or the signature is invalid
+	for input, expected := range map[string]error{
+		"hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QA":       errInvalidSignature,
+		"hello.hs512:DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI": ErrAlgorithmNotAllowed,
+		"hello.y9PTymc7lhrBjf9ncZvVQToR04Q":                       ErrAlgorithmNotAllowed,
+	} {
+		macs = 0
+		if _, err := cs.Unsign(input); err != expected {
+			t.Fatalf("expected error: %s for %s, got: %v", expected, input, err)
+		}
+		if macs != 2 {
+			t.Fatalf("expected 2 MACs for %s, got: %d", input, macs)
+		}
+	}
+
+	// legacy SHA-1 signatures without WithLegacySHA1
+	cs, _ = NewCookieSignatureFromMAC([]MACProvider{provider})
+	macs = 0
+	if _, err := cs.Unsign("hello.y9PTymc7lhrBjf9ncZvVQToR04Q"); err != errInvalidSignature || macs != 1 {
+		t.Fatalf("expected error: %s after 1 MAC, got: %v after %d", errInvalidSignature, err, macs)
+	}
+}
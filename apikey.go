@@ -0,0 +1,89 @@
+package cookiesignature
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	apiKeyAlphabet       = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	apiKeyRandomLength   = 32
+	apiKeyChecksumLength = 11
+	apiKeySeparator      = "_"
+)
+
+var (
+	errInvalidAPIKey = errors.New("invalid api key")
+
+	apiKeySegment = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+)
+
+// APIKey is a parsed API key
+type APIKey struct {
+	Prefix string
+	// KeyID identifies the key, e.g. to look up its owner and its revocation status
+	KeyID string
+}
+
+// MintAPIKey creates a new API key of the form <prefix>_<key id>_<random part><checksum>, e.g. myapp_k42_....
+// The distinctive prefix and fixed shape let secret scanners detect leaked keys with APIKeyPattern,
+// and the checksum, a MAC of the rest of the key, lets VerifyAPIKey reject forged keys offline, before any lookup.
+// The prefix and the key ID must be alphanumeric
+func (cs CookieSignature) MintAPIKey(prefix string, keyID string) (string, error) {
+	if !apiKeySegment.MatchString(prefix) || !apiKeySegment.MatchString(keyID) {
+		return "", errors.New("api key prefix and key id must be alphanumeric")
+	}
+	random, err := randomString(apiKeyRandomLength, apiKeyAlphabet)
+	if err != nil {
+		return "", err
+	}
+
+	body := prefix + apiKeySeparator + keyID + apiKeySeparator + random
+	hashBytes, err := cs.signingMAC(body)
+	if err != nil {
+		return "", err
+	}
+	return body + apiKeyChecksum(hashBytes), nil
+}
+
+// VerifyAPIKey checks the checksum of the API key with the keys of the signature and returns its prefix and key ID
+func (cs CookieSignature) VerifyAPIKey(key string) (APIKey, error) {
+	parts := strings.Split(key, apiKeySeparator)
+	if len(parts) != 3 || !apiKeySegment.MatchString(parts[0]) || !apiKeySegment.MatchString(parts[1]) ||
+		len(parts[2]) != apiKeyRandomLength+apiKeyChecksumLength || !apiKeySegment.MatchString(parts[2]) {
+		return APIKey{}, errInvalidAPIKey
+	}
+
+	body, checksum := key[:len(key)-apiKeyChecksumLength], key[len(key)-apiKeyChecksumLength:]
+	for _, verificationKey := range cs.usage.mostRecentlyUsedFirst(cs.verificationKeys()) {
+		hashBytes, err := verificationKey.computeMAC(body)
+		if err != nil {
+			return APIKey{}, err
+		}
+		if hmac.Equal([]byte(apiKeyChecksum(hashBytes)), []byte(checksum)) {
+			cs.usage.record(verificationKey.id)
+			return APIKey{Prefix: parts[0], KeyID: parts[1]}, nil
+		}
+	}
+	return APIKey{}, errInvalidAPIKey
+}
+
+// APIKeyPattern returns a regular expression matching the API keys of the prefix, for secret scanners
+func APIKeyPattern(prefix string) *regexp.Regexp {
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(prefix) + `_[A-Za-z0-9]+_[A-Za-z0-9]{` + strconv.Itoa(apiKeyRandomLength+apiKeyChecksumLength) + `}\b`)
+}
+
+// apiKeyChecksum encodes the first 8 bytes of the MAC in base62, left padded to a fixed length
+func apiKeyChecksum(hashBytes []byte) string {
+	n := binary.BigEndian.Uint64(hashBytes[:8])
+	checksum := make([]byte, apiKeyChecksumLength)
+	for i := len(checksum) - 1; i >= 0; i-- {
+		checksum[i] = apiKeyAlphabet[n%62]
+		n /= 62
+	}
+	return string(checksum)
+}
@@ -0,0 +1,49 @@
+package cookiesignature
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAPIKey(t *testing.T) {
+	old, _ := NewCookieSignature([]string{"tobiiscool"})
+	cs, _ := NewCookieSignature([]string{"n3wsecr3t", "tobiiscool"})
+
+	if _, err := cs.MintAPIKey("my_app", "k42"); err == nil {
+		t.Fatalf("expected a prefix error")
+	}
+	key, err := old.MintAPIKey("myapp", "k42")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if !strings.HasPrefix(key, "myapp_k42_") || len(key) != len("myapp_k42_")+43 {
+		t.Fatalf("unexpected key: %s", key)
+	}
+
+	result, err := cs.VerifyAPIKey(key)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if result != (APIKey{Prefix: "myapp", KeyID: "k42"}) {
+		t.Fatalf("unexpected key: %+v", result)
+	}
+
+	forged := strings.Replace(key, "_k42_", "_k43_", 1)
+	tampered := key[:len(key)-1] + "x"
+	if key[len(key)-1] == 'x' {
+		tampered = key[:len(key)-1] + "y"
+	}
+	for _, k := range []string{forged, tampered, key[1:], "myapp_k42", ""} {
+		if _, err := cs.VerifyAPIKey(k); err != errInvalidAPIKey {
+			t.Fatalf("%s: expected error: %s, got: %v", k, errInvalidAPIKey, err)
+		}
+	}
+
+	pattern := APIKeyPattern("myapp")
+	if found := pattern.FindString("export API_KEY=" + key + "\n"); found != key {
+		t.Fatalf("expected the pattern to find: %s, got: %s", key, found)
+	}
+	if pattern.MatchString("myapp_k42_short") {
+		t.Fatalf("expected the pattern not to match a short key")
+	}
+}
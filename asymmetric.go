@@ -0,0 +1,114 @@
+package cookiesignature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errUnsupportedPublicKey = errors.New("unsupported public key type, expected ed25519, ECDSA or RSA")
+
+// AsymmetricSignature signs values with a private key and verifies them with public keys,
+// so services that only verify cookies never hold a key able to sign them.
+// Any crypto.Signer is accepted, e.g. a hardware token, a cloud KMS wrapper or an in-memory ed25519, ECDSA or RSA key.
+// ECDSA and RSA keys sign the SHA-256 digest of the value, RSA signers choose between PKCS #1 v1.5 and PSS by themselves
+type AsymmetricSignature struct {
+	signer     crypto.Signer
+	publicKeys []crypto.PublicKey
+}
+
+// NewAsymmetricSignature creates a new AsymmetricSignature instance that signs with the signer
+// and verifies with its public key and the additional public keys, e.g. the keys of previous signers during a rotation
+func NewAsymmetricSignature(signer crypto.Signer, publicKeys ...crypto.PublicKey) (*AsymmetricSignature, error) {
+	if signer == nil {
+		return nil, errors.New("signer must be provided")
+	}
+	return newAsymmetricSignature(signer, append([]crypto.PublicKey{signer.Public()}, publicKeys...))
+}
+
+// NewAsymmetricVerifier creates a new AsymmetricSignature instance that only verifies with the public keys
+func NewAsymmetricVerifier(publicKeys ...crypto.PublicKey) (*AsymmetricSignature, error) {
+	if len(publicKeys) == 0 {
+		return nil, errors.New("public key must be provided")
+	}
+	return newAsymmetricSignature(nil, publicKeys)
+}
+
+func newAsymmetricSignature(signer crypto.Signer, publicKeys []crypto.PublicKey) (*AsymmetricSignature, error) {
+	for i, publicKey := range publicKeys {
+		switch publicKey.(type) {
+		case ed25519.PublicKey, *ecdsa.PublicKey, *rsa.PublicKey:
+		default:
+			return nil, fmt.Errorf("public key at index %d: %w", i, errUnsupportedPublicKey)
+		}
+	}
+	return &AsymmetricSignature{signer: signer, publicKeys: publicKeys}, nil
+}
+
+// Sign signs the input string and returns a joined string of the input and the signature
+func (as AsymmetricSignature) Sign(input string) (string, error) {
+	if input == "" {
+		return "", errEmptyUnsignedValue
+	}
+	if as.signer == nil {
+		return "", errors.New("verifier can't sign")
+	}
+
+	var signature []byte
+	var err error
+	if _, ok := as.signer.Public().(ed25519.PublicKey); ok {
+		signature, err = as.signer.Sign(rand.Reader, []byte(input), crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256([]byte(input))
+		signature, err = as.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s.%s", input, base64.RawURLEncoding.EncodeToString(signature)), nil
+}
+
+// Unsign verifies the input with any public key and extracts the value (the part of the string before the last '.')
+func (as AsymmetricSignature) Unsign(input string) (string, error) {
+	if input == "" {
+		return "", errEmptySignedValue
+	}
+	index := strings.LastIndex(input, ".")
+	if index < 0 {
+		return "", errInvalidSignature
+	}
+	value := input[:index]
+	signature, err := base64.RawURLEncoding.DecodeString(input[index+1:])
+	if err != nil {
+		return "", errInvalidSignature
+	}
+
+	digest := sha256.Sum256([]byte(value))
+	for _, publicKey := range as.publicKeys {
+		if verifyAsymmetric(publicKey, value, digest[:], signature) {
+			return value, nil
+		}
+	}
+	return "", errInvalidSignature
+}
+
+func verifyAsymmetric(publicKey crypto.PublicKey, value string, digest []byte, signature []byte) bool {
+	switch key := publicKey.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, []byte(value), signature)
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(key, digest, signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature) == nil ||
+			rsa.VerifyPSS(key, crypto.SHA256, digest, signature, nil) == nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,61 @@
+package cookiesignature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"testing"
+)
+
+// pssSigner signs with RSA-PSS instead of the PKCS #1 v1.5 default of rsa.PrivateKey
+type pssSigner struct {
+	*rsa.PrivateKey
+}
+
+func (s pssSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.PrivateKey.Sign(rand, digest, &rsa.PSSOptions{Hash: opts.HashFunc()})
+}
+
+func TestAsymmetricSignature(t *testing.T) {
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	ecdsaKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	for _, signer := range []crypto.Signer{ed25519Key, ecdsaKey, rsaKey, pssSigner{rsaKey}} {
+		as, err := NewAsymmetricSignature(signer)
+		if err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		signed, err := as.Sign("hello")
+		if err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		result, err := as.Unsign(signed)
+		assertEqual(t, "hello", result, err)
+
+		verifier, _ := NewAsymmetricVerifier(signer.Public())
+		result, err = verifier.Unsign(signed)
+		assertEqual(t, "hello", result, err)
+		if _, err := verifier.Sign("hello"); err == nil {
+			t.Fatalf("expected the verifier not to sign")
+		}
+		if _, err := verifier.Unsign("hell0" + signed[5:]); err != errInvalidSignature {
+			t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+		}
+	}
+
+	// old public keys keep verifying after a rotation
+	old, _ := NewAsymmetricSignature(ecdsaKey)
+	signed, _ := old.Sign("hello")
+	rotated, _ := NewAsymmetricSignature(ed25519Key, ecdsaKey.Public())
+	result, err := rotated.Unsign(signed)
+	assertEqual(t, "hello", result, err)
+
+	if _, err := NewAsymmetricVerifier("not a key"); err == nil || err.Error() != "public key at index 0: "+errUnsupportedPublicKey.Error() {
+		t.Fatalf("expected error: %s, got: %s", errUnsupportedPublicKey, err)
+	}
+}
@@ -0,0 +1,43 @@
+package cookiesignature
+
+import "errors"
+
+// ErrInsufficientAuthLevel is returned when a timed token doesn't meet the authentication level
+// or the authentication methods required by the endpoint, so the user can be sent to a step-up authentication
+var ErrInsufficientAuthLevel = errors.New("token authentication level is insufficient")
+
+// WithMinAuthLevel requires the AuthLevel claim of the timed token to be at least level.
+// Combine it with WithMaxAge to require a recent step-up
+func WithMinAuthLevel(level int) VerifyOption {
+	return func(o *verifyOptions) {
+		o.minAuthLevel = level
+	}
+}
+
+// WithAuthMethods requires the AuthMethods claim of the timed token to include every method, e.g. otp
+func WithAuthMethods(methods ...string) VerifyOption {
+	return func(o *verifyOptions) {
+		o.authMethods = append(o.authMethods, methods...)
+	}
+}
+
+func validateAuthLevel(claims Claims, opts verifyOptions) error {
+	if claims.AuthLevel < opts.minAuthLevel {
+		return ErrInsufficientAuthLevel
+	}
+	for _, required := range opts.authMethods {
+		if !containsString(claims.AuthMethods, required) {
+			return ErrInsufficientAuthLevel
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package cookiesignature
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuthLevel(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	now := time.Now().Unix()
+	password, _ := cs.SignClaims(Claims{Value: "42", IssuedAt: now, AuthLevel: 1, AuthMethods: []string{"pwd"}})
+	stepUp, _ := cs.SignClaims(Claims{Value: "42", IssuedAt: now, AuthLevel: 2, AuthMethods: []string{"pwd", "otp"}})
+
+	value, err := cs.UnsignTimed(stepUp, WithMinAuthLevel(2), WithAuthMethods("otp"))
+	assertEqual(t, "42", value, err)
+	value, err = cs.UnsignTimed(password, WithMinAuthLevel(1))
+	assertEqual(t, "42", value, err)
+
+	for _, opts := range [][]VerifyOption{
+		{WithMinAuthLevel(2)},
+		{WithAuthMethods("otp")},
+		{WithAuthMethods("pwd", "hwk")},
+	} {
+		if _, err := cs.UnsignTimed(password, opts...); err != ErrInsufficientAuthLevel {
+			t.Fatalf("expected error: %s, got: %v", ErrInsufficientAuthLevel, err)
+		}
+	}
+}
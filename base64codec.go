@@ -0,0 +1,63 @@
+package cookiesignature
+
+import "encoding/base64"
+
+// Base64Codec encodes and decodes unpadded standard base64, the encoding of the signatures and of the values of SignBase64.
+// *base64.Encoding implements it, as do the encodings of most SIMD-accelerated base64 packages,
+// so deployments whose cookie throughput is bound by the encoder can plug one in
+type Base64Codec interface {
+	EncodedLen(n int) int
+	Encode(dst []byte, src []byte)
+	DecodedLen(n int) int
+	Decode(dst []byte, src []byte) (int, error)
+}
+
+// WithBase64Codec sets the codec of the signatures and of the values of SignBase64 and UnsignBase64.
+// The codec must encode unpadded standard base64 (base64.RawStdEncoding), the default if nil
+func WithBase64Codec(codec Base64Codec) Option {
+	return func(o *options) {
+		o.base64Codec = codec
+	}
+}
+
+func (o options) codec() Base64Codec {
+	if o.base64Codec == nil {
+		return base64.RawStdEncoding
+	}
+	return o.base64Codec
+}
+
+func encodeBase64(codec Base64Codec, src []byte) string {
+	encoded := make([]byte, codec.EncodedLen(len(src)))
+	codec.Encode(encoded, src)
+	return bytesToString(encoded)
+}
+
+func decodeBase64(codec Base64Codec, src string) ([]byte, error) {
+	decoded := make([]byte, codec.DecodedLen(len(src)))
+	n, err := codec.Decode(decoded, stringToBytes(src))
+	if err != nil {
+		return nil, err
+	}
+	return decoded[:n], nil
+}
+
+// appendBase64 appends the unpadded encoding of src to dst, padded with '=' if padding is true
+func appendBase64(dst []byte, codec Base64Codec, src []byte, padding bool) []byte {
+	size := codec.EncodedLen(len(src))
+	padSize := 0
+	if padding && len(src)%3 != 0 {
+		padSize = 3 - len(src)%3
+	}
+	if cap(dst)-len(dst) < size+padSize {
+		grown := make([]byte, len(dst), len(dst)+size+padSize)
+		copy(grown, dst)
+		dst = grown
+	}
+	codec.Encode(dst[len(dst):len(dst)+size], src)
+	dst = dst[:len(dst)+size]
+	for i := 0; i < padSize; i++ {
+		dst = append(dst, '=')
+	}
+	return dst
+}
@@ -0,0 +1,88 @@
+package cookiesignature
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type countingCodec struct {
+	*base64.Encoding
+	encoded int
+	decoded int
+}
+
+func (c *countingCodec) Encode(dst []byte, src []byte) {
+	c.encoded++
+	c.Encoding.Encode(dst, src)
+}
+
+func (c *countingCodec) Decode(dst []byte, src []byte) (int, error) {
+	c.decoded++
+	return c.Encoding.Decode(dst, src)
+}
+
+func TestBase64Codec(t *testing.T) {
+	codec := &countingCodec{Encoding: base64.RawStdEncoding}
+	for _, pool := range []bool{true, false} {
+		cs, _ := NewCookieSignature([]string{"tobiiscool"}, WithBase64Codec(codec), WithBufferPool(pool))
+
+		val, err := cs.Sign("hello")
+		assertEqual(t, "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", val, err)
+		val, err = cs.Unsign("hello.DGDUkGlIkCzPz-C0B064FNgHdEjox7ch8tOBGslZ5QI=")
+		assertEqual(t, "hello", val, err)
+
+		for _, input := range []string{"h", "he", "hel"} {
+			signed, err := cs.SignBase64(input)
+			expected, _ := Sign(base64.StdEncoding.EncodeToString([]byte(input)), []byte("tobiiscool"))
+			assertEqual(t, expected, signed, err)
+
+			decoded, err := cs.UnsignBase64(signed)
+			assertEqual(t, input, string(decoded), err)
+		}
+	}
+	if codec.encoded != 14 || codec.decoded != 14 {
+		t.Fatalf("expected the codec to encode and decode 14 times, got: %d and %d", codec.encoded, codec.decoded)
+	}
+}
+
+func TestBase64CodecPurposeFormats(t *testing.T) {
+	codec := &countingCodec{Encoding: base64.RawStdEncoding}
+	cs, _ := NewCookieSignature([]string{"tobiiscool"}, WithBase64Codec(codec))
+
+	token, err := cs.signPurposeJSON("test", map[string]int{"n": 1})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	var value map[string]int
+	if err := cs.unsignPurposeJSON("test", token, &value); err != nil || value["n"] != 1 {
+		t.Fatalf("unexpected value: %v, %v", value, err)
+	}
+
+	scope := CookieScope{Domain: "example.com", Path: "/admin"}
+	scoped, err := cs.SignScoped("hello", scope)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	val, err := cs.UnsignScoped(scoped, scope)
+	assertEqual(t, "hello", val, err)
+
+	recorder := httptest.NewRecorder()
+	if err := cs.WriteCookies(recorder, testSwappedCookieBinding{Level: 3}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range recorder.Result().Cookies() {
+		request.AddCookie(cookie)
+	}
+	var bound testSwappedCookieBinding
+	if err := cs.ReadCookies(request, &bound); err != nil || bound.Level != 3 {
+		t.Fatalf("unexpected value: %+v, %v", bound, err)
+	}
+
+	// the signatures of the three formats and the base64 value of the bound cookie
+	if codec.encoded != 4 {
+		t.Fatalf("expected the codec to encode 4 times, got: %d", codec.encoded)
+	}
+}
@@ -0,0 +1,33 @@
+package cookiesignature
+
+import (
+	"context"
+	"encoding/base64"
+)
+
+// SignBytes signs an arbitrary binary payload, e.g. protobuf or gob bytes. The value half of the output
+// is the unpadded url-safe base64 encoding of the payload, which never contains '.', so it needs no escaping in cookies
+func (cs CookieSignature) SignBytes(payload []byte) (string, error) {
+	if len(payload) == 0 {
+		return "", errEmptyUnsignedValue
+	}
+	return cs.Sign(base64.RawURLEncoding.EncodeToString(payload))
+}
+
+// UnsignBytes verifies the value signed by SignBytes and returns the decoded payload
+func (cs CookieSignature) UnsignBytes(input string) ([]byte, error) {
+	return cs.UnsignBytesContext(context.Background(), input)
+}
+
+// UnsignBytesContext is like UnsignBytes, the context is passed to the hooks
+func (cs CookieSignature) UnsignBytesContext(ctx context.Context, input string) ([]byte, error) {
+	value, err := cs.UnsignContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errInvalidSignature
+	}
+	return payload, nil
+}
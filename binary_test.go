@@ -0,0 +1,37 @@
+package cookiesignature
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSignBytes(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"}, WithURLEncoding())
+
+	if _, err := cs.SignBytes(nil); err != errEmptyUnsignedValue {
+		t.Fatalf("expected error: %s, got: %v", errEmptyUnsignedValue, err)
+	}
+
+	payload := []byte{0x08, 0x96, 0x01, '.', 0x00, 0xff, 0xfe, '.'}
+	signed, err := cs.SignBytes(payload)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if value := signed[:strings.Index(signed, ".")]; value != "CJYBLgD__i4" {
+		t.Fatalf("expected the url-safe encoding of the payload, got: %s", value)
+	}
+	result, err := cs.UnsignBytes(signed)
+	if err != nil || !bytes.Equal(result, payload) {
+		t.Fatalf("expected %v, got: %v, %v", payload, result, err)
+	}
+
+	// the value must be url-safe base64 even if the signature is valid
+	signed, _ = cs.Sign("hello world")
+	if _, err := cs.UnsignBytes(signed); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	if _, err := cs.UnsignBytes("CJYBLgD__i4.invalid"); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+}
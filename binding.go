@@ -0,0 +1,198 @@
+package cookiesignature
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	cookieTagName        = "cookie"
+	cookieBindingPurpose = "cookie"
+)
+
+var errInvalidBindingTarget = errors.New("binding target must be a pointer to a struct")
+
+// cookieField describes a struct field bound to a cookie by the `cookie:"name,maxage=3600,encrypted"` tag
+type cookieField struct {
+	index     int
+	cookie    http.Cookie
+	encrypted bool
+}
+
+// WriteCookies signs every struct field with a `cookie` tag into its own cookie of the response.
+// The tag is a comma-separated list of the cookie name and the options
+// maxage=<seconds>, path=<path>, domain=<domain>, samesite=<lax|strict|none>, secure, httponly and encrypted.
+// Field values are serialized to JSON. Encrypted fields are encrypted like Encrypt instead of being signed.
+// The cookie name is covered by the MAC or authenticated with the ciphertext, so a value can't be moved to another cookie
+func (cs CookieSignature) WriteCookies(w http.ResponseWriter, value interface{}) error {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return errInvalidBindingTarget
+	}
+
+	fields, err := parseCookieFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	cookies := make([]http.Cookie, 0, len(fields))
+	for _, field := range fields {
+		rawValue, err := json.Marshal(rv.Field(field.index).Interface())
+		if err != nil {
+			return err
+		}
+
+		cookie := field.cookie
+		if field.encrypted {
+			cookie.Value, err = cs.encrypt(rawValue, []byte(encodeValues([]string{cookieBindingPurpose, cookie.Name})))
+		} else {
+			cookie.Value, err = cs.signBoundCookie(cookie.Name, rawValue)
+		}
+		if err != nil {
+			return err
+		}
+		cookies = append(cookies, cookie)
+	}
+
+	for i := range cookies {
+		http.SetCookie(w, &cookies[i])
+	}
+	return nil
+}
+
+// ReadCookies verifies the cookies of the request and binds them into the struct fields with a `cookie` tag.
+// Fields whose cookie is missing are left untouched
+func (cs CookieSignature) ReadCookies(r *http.Request, value interface{}) error {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errInvalidBindingTarget
+	}
+	rv = rv.Elem()
+
+	fields, err := parseCookieFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		cookie, err := r.Cookie(field.cookie.Name)
+		if err != nil {
+			continue
+		}
+
+		var rawValue []byte
+		if field.encrypted {
+			rawValue, err = cs.decrypt(cookie.Value, []byte(encodeValues([]string{cookieBindingPurpose, field.cookie.Name})))
+		} else {
+			rawValue, err = cs.unsignBoundCookie(field.cookie.Name, cookie.Value)
+		}
+		if err != nil {
+			return fmt.Errorf("cookie %s: %w", field.cookie.Name, err)
+		}
+
+		if err := json.Unmarshal(rawValue, rv.Field(field.index).Addr().Interface()); err != nil {
+			return fmt.Errorf("cookie %s: %w", field.cookie.Name, err)
+		}
+	}
+	return nil
+}
+
+// signBoundCookie signs the base64 value with the cookie name in the MAC
+func (cs CookieSignature) signBoundCookie(name string, value []byte) (string, error) {
+	payload := cs.encodeValue(string(value))
+	hashBytes, err := cs.signingMAC(encodeValues([]string{cookieBindingPurpose, name, payload}))
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + encodeBase64(cs.opts.codec(), hashBytes), nil
+}
+
+// unsignBoundCookie verifies the value signed by signBoundCookie for the cookie name and decodes it
+func (cs CookieSignature) unsignBoundCookie(name string, input string) ([]byte, error) {
+	index := strings.LastIndex(input, ".")
+	if index < 0 {
+		return nil, errInvalidSignature
+	}
+	payload := input[:index]
+	if _, err := cs.unsign(encodeValues([]string{cookieBindingPurpose, name, payload}) + input[index:]); err != nil {
+		return nil, err
+	}
+	return decodeBase64(cs.opts.codec(), strings.TrimRight(payload, "="))
+}
+
+func parseCookieFields(rt reflect.Type) ([]cookieField, error) {
+	var fields []cookieField
+	for i := 0; i < rt.NumField(); i++ {
+		structField := rt.Field(i)
+		tag, ok := structField.Tag.Lookup(cookieTagName)
+		if !ok || tag == "-" || structField.PkgPath != "" {
+			continue
+		}
+
+		field, err := parseCookieTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", structField.Name, err)
+		}
+		field.index = i
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func parseCookieTag(tag string) (cookieField, error) {
+	parts := strings.Split(tag, ",")
+	field := cookieField{
+		cookie: http.Cookie{Name: strings.TrimSpace(parts[0]), Path: "/"},
+	}
+	if field.cookie.Name == "" {
+		return field, errors.New("cookie name must not be empty")
+	}
+
+	for _, option := range parts[1:] {
+		key, value := strings.TrimSpace(option), ""
+		if i := strings.Index(key, "="); i >= 0 {
+			key, value = key[:i], key[i+1:]
+		}
+
+		switch key {
+		case "maxage":
+			maxAge, err := strconv.Atoi(value)
+			if err != nil {
+				return field, fmt.Errorf("invalid maxage: %s", value)
+			}
+			field.cookie.MaxAge = maxAge
+		case "path":
+			field.cookie.Path = value
+		case "domain":
+			field.cookie.Domain = value
+		case "samesite":
+			switch strings.ToLower(value) {
+			case "lax":
+				field.cookie.SameSite = http.SameSiteLaxMode
+			case "strict":
+				field.cookie.SameSite = http.SameSiteStrictMode
+			case "none":
+				field.cookie.SameSite = http.SameSiteNoneMode
+			default:
+				return field, fmt.Errorf("invalid samesite: %s", value)
+			}
+		case "secure":
+			field.cookie.Secure = true
+		case "httponly":
+			field.cookie.HttpOnly = true
+		case "encrypted":
+			field.encrypted = true
+		default:
+			return field, fmt.Errorf("unknown cookie option: %s", key)
+		}
+	}
+	return field, nil
+}
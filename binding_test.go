@@ -0,0 +1,91 @@
+package cookiesignature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testCookieBinding struct {
+	UserID   int      `cookie:"uid,maxage=3600,httponly"`
+	Email    string   `cookie:"email,encrypted,samesite=strict"`
+	Roles    []string `cookie:"roles"`
+	Ignored  string
+	internal string `cookie:"internal"`
+}
+
+type testSwappedCookieBinding struct {
+	Level  int    `cookie:"level"`
+	Secret string `cookie:"secret,encrypted"`
+}
+
+func TestCookieBinding(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	input := testCookieBinding{UserID: 42, Email: "tobi@example.com", Roles: []string{"admin"}, Ignored: "foo", internal: "bar"}
+	recorder := httptest.NewRecorder()
+	if err := cs.WriteCookies(recorder, input); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 3 {
+		t.Fatalf("expected 3 cookies, got: %d", len(cookies))
+	}
+	if cookies[0].Name != "uid" || cookies[0].MaxAge != 3600 || !cookies[0].HttpOnly {
+		t.Fatalf("unexpected cookie: %s", cookies[0])
+	}
+	if cookies[1].SameSite != http.SameSiteStrictMode || strings.Contains(cookies[1].Value, "tobi") {
+		t.Fatalf("unexpected cookie: %s", cookies[1])
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	var output testCookieBinding
+	if err := cs.ReadCookies(request, &output); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if output.UserID != 42 || output.Email != "tobi@example.com" || len(output.Roles) != 1 || output.Roles[0] != "admin" {
+		t.Fatalf("unexpected output: %+v", output)
+	}
+	if output.Ignored != "" || output.internal != "" {
+		t.Fatalf("expected untagged fields to be ignored, got: %+v", output)
+	}
+
+	// values can't be moved to another cookie
+	var swapped testSwappedCookieBinding
+	for _, fields := range [][2]string{{"uid", "level"}, {"email", "secret"}} {
+		request = httptest.NewRequest(http.MethodGet, "/", nil)
+		for _, cookie := range cookies {
+			if cookie.Name == fields[0] {
+				request.AddCookie(&http.Cookie{Name: fields[1], Value: cookie.Value})
+			}
+		}
+		if err := cs.ReadCookies(request, &swapped); err == nil || !strings.HasPrefix(err.Error(), "cookie "+fields[1]+":") {
+			t.Fatalf("expected an error moving %s to %s, got: %v", fields[0], fields[1], err)
+		}
+	}
+
+	request = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(&http.Cookie{Name: "uid", Value: "NDI=.invalid"})
+	if err := cs.ReadCookies(request, &output); err == nil || err.Error() != "cookie uid: invalid signature" {
+		t.Fatalf("expected error: cookie uid: invalid signature, got: %s", err)
+	}
+
+	if err := cs.ReadCookies(request, output); err != errInvalidBindingTarget {
+		t.Fatalf("expected error: %s, got: %s", errInvalidBindingTarget, err)
+	}
+
+	var invalid struct {
+		Value string `cookie:"value,maxage=abc"`
+	}
+	if err := cs.WriteCookies(recorder, invalid); err == nil || err.Error() != "field Value: invalid maxage: abc" {
+		t.Fatalf("expected error: field Value: invalid maxage: abc, got: %s", err)
+	}
+}
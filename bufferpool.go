@@ -0,0 +1,54 @@
+package cookiesignature
+
+import "sync"
+
+// maxPooledBufferSize bounds the buffers returned to the pool, so a few huge values don't pin memory
+const maxPooledBufferSize = 64 << 10
+
+var encodeBuffers = sync.Pool{
+	New: func() interface{} {
+		buffer := make([]byte, 0, 256)
+		return &buffer
+	},
+}
+
+// WithBufferPool sets whether Sign and SignBase64 encode into pooled buffers rather than allocating
+// intermediate strings on each call. It is enabled by default, disable it in memory-constrained environments
+// where idle pooled buffers cost more than the allocations they save
+func WithBufferPool(enabled bool) Option {
+	return func(o *options) {
+		o.noBufferPool = !enabled
+	}
+}
+
+// joinSignature returns the input joined with the tag and the base64 encoded hash
+func (cs CookieSignature) joinSignature(input string, tag string, hashBytes []byte) string {
+	if cs.opts.noBufferPool {
+		return input + "." + tag + encodeBase64(cs.opts.codec(), hashBytes)
+	}
+	buffer := encodeBuffers.Get().(*[]byte)
+	defer putEncodeBuffer(buffer)
+
+	*buffer = append(append(append((*buffer)[:0], input...), '.'), tag...)
+	*buffer = appendBase64(*buffer, cs.opts.codec(), hashBytes, false)
+	return string(*buffer)
+}
+
+// encodeValue returns the padded standard base64 encoding of the input
+func (cs CookieSignature) encodeValue(input string) string {
+	if cs.opts.noBufferPool {
+		return bytesToString(appendBase64(nil, cs.opts.codec(), stringToBytes(input), true))
+	}
+	buffer := encodeBuffers.Get().(*[]byte)
+	defer putEncodeBuffer(buffer)
+
+	*buffer = appendBase64((*buffer)[:0], cs.opts.codec(), stringToBytes(input), true)
+	return string(*buffer)
+}
+
+func putEncodeBuffer(buffer *[]byte) {
+	if cap(*buffer) > maxPooledBufferSize {
+		return
+	}
+	encodeBuffers.Put(buffer)
+}
@@ -0,0 +1,47 @@
+package cookiesignature
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBufferPool(t *testing.T) {
+	pooled, _ := NewCookieSignature([]string{"tobiiscool"})
+	unpooled, _ := NewCookieSignature([]string{"tobiiscool"}, WithBufferPool(false))
+
+	val, err := pooled.Sign("hello")
+	assertEqual(t, "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", val, err)
+	val, err = unpooled.Sign("hello")
+	assertEqual(t, "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", val, err)
+
+	// values larger than the pooled buffers and the buffers dropped from the pool
+	for _, input := range []string{"a", strings.Repeat("b", 300), strings.Repeat("c", maxPooledBufferSize+1), "d"} {
+		expected, err := unpooled.SignBase64(input)
+		if err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		val, err := pooled.SignBase64(input)
+		assertEqual(t, expected, val, err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(input string) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				signed, err := pooled.Sign(input)
+				if err != nil {
+					t.Errorf("expected no error, got: %s", err)
+					return
+				}
+				if value, err := pooled.Unsign(signed); err != nil || value != input {
+					t.Errorf("expected %s, got: %s, %v", input, value, err)
+					return
+				}
+			}
+		}(strings.Repeat("x", i+1))
+	}
+	wg.Wait()
+}
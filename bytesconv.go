@@ -0,0 +1,15 @@
+//go:build !cookiesignature_unsafe
+// +build !cookiesignature_unsafe
+
+package cookiesignature
+
+// stringToBytes converts the string to a byte slice that must not be modified.
+// Build with the cookiesignature_unsafe tag to convert without copying
+func stringToBytes(s string) []byte {
+	return []byte(s)
+}
+
+// bytesToString converts the byte slice to a string, the slice must not be modified afterwards
+func bytesToString(b []byte) string {
+	return string(b)
+}
@@ -0,0 +1,70 @@
+//go:build go1.18
+// +build go1.18
+
+package cookiesignature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// the fuzz tests run with and without the cookiesignature_unsafe tag, e.g.
+// go test -tags cookiesignature_unsafe -fuzz FuzzSignAliasing
+func FuzzBytesConversion(f *testing.F) {
+	f.Add([]byte("hello"))
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		expected := string(data)
+		if b := stringToBytes(expected); string(b) != expected || len(b) != len(expected) {
+			t.Fatalf("expected %q, got: %q", expected, b)
+		}
+		if s := bytesToString(data); s != expected {
+			t.Fatalf("expected %q, got: %q", expected, s)
+		}
+	})
+}
+
+func FuzzSignAliasing(f *testing.F) {
+	f.Add("tobiiscool", "hello", "world")
+	f.Add("n3wsecr3t", "s:abc.def", "s:abc.deg")
+	f.Fuzz(func(t *testing.T, secret string, first string, second string) {
+		if secret == "" || first == "" || second == "" {
+			return
+		}
+		reference := func(input string) string {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write([]byte(input))
+			return input + "." + strings.TrimRight(base64.StdEncoding.EncodeToString(mac.Sum(nil)), "=")
+		}
+		cs, err := NewCookieSignature([]string{secret})
+		if err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+
+		input := string([]byte(first))
+		signed, err := cs.Sign(input)
+		if err != nil || signed != reference(first) {
+			t.Fatalf("expected %q, got: %q, %v", reference(first), signed, err)
+		}
+		// signing and verifying other values must neither change the input nor the previous results
+		if other, err := cs.Sign(second); err != nil || other != reference(second) {
+			t.Fatalf("expected %q, got: %q, %v", reference(second), other, err)
+		}
+		if _, err := cs.Unsign(reference(second)); err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		if input != first || signed != reference(first) {
+			t.Fatalf("expected %q and %q to be unchanged, got: %q and %q", first, reference(first), input, signed)
+		}
+
+		if value, err := cs.Unsign(signed); err != nil || value != first {
+			t.Fatalf("expected %q, got: %q, %v", first, value, err)
+		}
+		if encoded, err := cs.SignBase64(first); err != nil || encoded != reference(base64.StdEncoding.EncodeToString([]byte(first))) {
+			t.Fatalf("unexpected base64 signature: %q, %v", encoded, err)
+		}
+	})
+}
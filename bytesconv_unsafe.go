@@ -0,0 +1,19 @@
+//go:build cookiesignature_unsafe
+// +build cookiesignature_unsafe
+
+package cookiesignature
+
+import "unsafe"
+
+// stringToBytes returns a byte slice sharing the memory of the string, it must not be modified
+func stringToBytes(s string) []byte {
+	return *(*[]byte)(unsafe.Pointer(&struct {
+		string
+		int
+	}{s, len(s)}))
+}
+
+// bytesToString returns a string sharing the memory of the byte slice, the slice must not be modified afterwards
+func bytesToString(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
@@ -0,0 +1,193 @@
+package cookiesignature
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// WithCanonicalJSON makes SignJSON serialize values in the canonical form of RFC 8785 (JCS):
+// object keys are sorted, whitespace is removed and numbers and strings have a single representation.
+// Equivalent structures then always produce identical signatures, whichever language encoded them,
+// so signatures can be compared across implementations and cookie writes are idempotent
+func WithCanonicalJSON() Option {
+	return func(o *options) {
+		o.canonicalJSON = true
+	}
+}
+
+// CanonicalJSON serializes the value to JSON in the canonical form of RFC 8785.
+// Numbers are IEEE 754 doubles, so integers beyond 2^53 lose precision like they do in JavaScript
+func CanonicalJSON(value interface{}) ([]byte, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalizeJSON(payload)
+}
+
+// canonicalizeJSON rewrites the JSON document in the canonical form of RFC 8785
+func canonicalizeJSON(payload []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := writeCanonicalJSON(&buf, document); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeCanonicalJSON(buf *bytes.Buffer, value interface{}) error {
+	switch value := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(value))
+	case string:
+		writeCanonicalString(buf, value)
+	case json.Number:
+		f, err := strconv.ParseFloat(string(value), 64)
+		if err != nil {
+			return err
+		}
+		number, err := formatCanonicalNumber(f)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(number)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, element := range value {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonicalJSON(buf, element); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		// keys are sorted by their UTF-16 code units, like JavaScript does
+		sort.Slice(keys, func(i, j int) bool {
+			return compareUTF16(keys[i], keys[j]) < 0
+		})
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, key)
+			buf.WriteByte(':')
+			if err := writeCanonicalJSON(buf, value[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unexpected JSON value: %T", value)
+	}
+	return nil
+}
+
+// writeCanonicalString escapes only the quote, the backslash and the control characters, with the short escapes when they exist
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// formatCanonicalNumber formats the number like Number.prototype.toString of ECMAScript
+func formatCanonicalNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", errors.New("NaN and infinite numbers aren't valid JSON")
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+
+	// the shortest digits that round-trip, and the position n of the decimal point relative to them
+	scientific := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exponent := scientific, 0
+	if index := strings.IndexByte(scientific, 'e'); index >= 0 {
+		mantissa = scientific[:index]
+		exponent, _ = strconv.Atoi(scientific[index+1:])
+	}
+	digits := strings.Replace(mantissa, ".", "", 1)
+	k, n := len(digits), exponent+1
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k), nil
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:], nil
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits, nil
+	}
+	exponentSign := "+"
+	if n-1 < 0 {
+		exponentSign = "-"
+	}
+	result := digits[:1]
+	if k > 1 {
+		result += "." + digits[1:]
+	}
+	return sign + result + "e" + exponentSign + strconv.Itoa(abs(n-1)), nil
+}
+
+func compareUTF16(a string, b string) int {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return int(ua[i]) - int(ub[i])
+		}
+	}
+	return len(ua) - len(ub)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
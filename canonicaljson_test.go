@@ -0,0 +1,74 @@
+package cookiesignature
+
+import (
+	"encoding/base64"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	// examples of RFC 8785
+	result, err := canonicalizeJSON([]byte(`{
+		"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+		"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+		"literals": [null, true, false]
+	}`))
+	assertEqual(t, `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`, string(result), err)
+
+	result, err = canonicalizeJSON([]byte(`{"\u20ac":"Euro Sign","\r":"Carriage Return","\ufb33":"Hebrew Letter Dalet With Dagesh","1":"One","\ud83d\ude00":"Emoji: Grinning Face","\u0080":"Control","\u00f6":"Latin Small Letter O With Diaeresis"}`))
+	assertEqual(t, "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"ö\":\"Latin Small Letter O With Diaeresis\",\"€\":\"Euro Sign\",\"😀\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}", string(result), err)
+
+	for input, expected := range map[float64]string{
+		0:                     "0",
+		math.Copysign(0, -1):  "0",
+		-1:                    "-1",
+		1e21:                  "1e+21",
+		1e20:                  "100000000000000000000",
+		9007199254740992:      "9007199254740992",
+		0.000001:              "0.000001",
+		0.0000001:             "1e-7",
+		-1.5e-10:              "-1.5e-10",
+		math.MaxFloat64:       "1.7976931348623157e+308",
+		5e-324:                "5e-324",
+		123.456:               "123.456",
+		1.2345678901234567e21: "1.2345678901234568e+21",
+	} {
+		result, err := formatCanonicalNumber(input)
+		assertEqual(t, expected, result, err)
+	}
+	if _, err := formatCanonicalNumber(math.Inf(1)); err == nil {
+		t.Fatalf("expected an error for an infinite number")
+	}
+
+	result, err = CanonicalJSON(struct {
+		B string  `json:"b"`
+		A float64 `json:"a"`
+	}{B: "<&>\u2028", A: 2.5})
+	assertEqual(t, "{\"a\":2.5,\"b\":\"<&>\u2028\"}", string(result), err)
+}
+
+func TestSignJSONCanonical(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"tobiiscool"}, WithCanonicalJSON())
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	first, err := cs.SignJSON(map[string]interface{}{"b": 1, "a": []int{1, 2}})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	second, err := cs.SignJSON(struct {
+		A []float64 `json:"a"`
+		B float64   `json:"b"`
+	}{A: []float64{1.0, 2.0}, B: 1.0})
+	assertEqual(t, first, second, err)
+
+	payload, err := base64.StdEncoding.DecodeString(first[:strings.LastIndex(first, ".")])
+	assertEqual(t, `{"a":[1,2],"b":1}`, string(payload), err)
+
+	var result map[string]interface{}
+	if err := cs.UnsignJSON(first, &result); err != nil || result["b"] != 1.0 {
+		t.Fatalf("unexpected result: %v, %v", result, err)
+	}
+}
@@ -0,0 +1,159 @@
+package cookiesignature
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	chainPurpose   = "resign-chain"
+	chainSeparator = "~"
+)
+
+// signatureChain is a value with the signatures of its lineage, the first one by SignChain
+type signatureChain struct {
+	value      string
+	signatures []string
+}
+
+// SignChain signs the value as the first link of a re-sign chain, e.g. "hello.0~<signature>".
+// Each ResignChain appends a link whose MAC covers the previous signature and the depth of the chain,
+// so audits can prove the rotation lineage of a value rather than just its current validity
+func (cs CookieSignature) SignChain(value string) (string, error) {
+	if value == "" {
+		return "", errEmptyUnsignedValue
+	}
+	return cs.appendChainLink(signatureChain{value: value})
+}
+
+// UnsignChain verifies the last link of the chain and returns the value and the depth of the chain,
+// 0 if it was never re-signed
+func (cs CookieSignature) UnsignChain(signed string) (string, int, error) {
+	if signed == "" {
+		return "", 0, errEmptySignedValue
+	}
+	if decoded, ok := cs.decodeInput(signed); ok {
+		signed = decoded
+	}
+	chain, err := parseSignatureChain(signed)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := cs.verifyChainLink(chain, chain.depth()); err != nil {
+		return "", 0, err
+	}
+	return chain.value, chain.depth(), nil
+}
+
+// ResignChain verifies the last link of the chain with from, or cs itself if nil, and appends a link signed by cs.
+// The chain is returned unchanged if its last link is already signed by the signing key of cs
+func (cs CookieSignature) ResignChain(signed string, from *CookieSignature) (string, error) {
+	if from == nil {
+		from = &cs
+	}
+	if signed == "" {
+		return "", errEmptySignedValue
+	}
+	decoded := signed
+	if unescaped, ok := from.decodeInput(signed); ok {
+		decoded = unescaped
+	}
+	chain, err := parseSignatureChain(decoded)
+	if err != nil {
+		return "", err
+	}
+	if err := from.verifyChainLink(chain, chain.depth()); err != nil {
+		return "", err
+	}
+
+	last, err := cs.chainLinkSignature(chain, chain.depth())
+	if err != nil {
+		return "", err
+	}
+	if last == chain.signatures[chain.depth()] {
+		return signed, nil
+	}
+	return cs.appendChainLink(chain)
+}
+
+// AuditChain verifies every link of the chain, from the first signature to the last, and returns for each depth
+// the index of the signer that verified it. The signers hold the keys used over the lifetime of the chain,
+// e.g. one CookieSignature per secret ever used, including the retired ones
+func AuditChain(signed string, signers ...*CookieSignature) ([]int, error) {
+	if strings.Contains(signed, "%") {
+		if decoded, err := url.PathUnescape(signed); err == nil {
+			signed = decoded
+		}
+	}
+	chain, err := parseSignatureChain(signed)
+	if err != nil {
+		return nil, err
+	}
+
+	lineage := make([]int, len(chain.signatures))
+	for depth := range chain.signatures {
+		lineage[depth] = -1
+		for i, signer := range signers {
+			if signer != nil && signer.verifyChainLink(chain, depth) == nil {
+				lineage[depth] = i
+				break
+			}
+		}
+		if lineage[depth] < 0 {
+			return nil, fmt.Errorf("chain link at depth %d: %w", depth, errInvalidSignature)
+		}
+	}
+	return lineage, nil
+}
+
+func (c signatureChain) depth() int {
+	return len(c.signatures) - 1
+}
+
+func (c signatureChain) macInput(depth int) string {
+	previous := ""
+	if depth > 0 {
+		previous = c.signatures[depth-1]
+	}
+	return encodeValues([]string{chainPurpose, c.value, strconv.Itoa(depth), previous})
+}
+
+func (cs CookieSignature) appendChainLink(chain signatureChain) (string, error) {
+	depth := len(chain.signatures)
+	signature, err := cs.chainLinkSignature(chain, depth)
+	if err != nil {
+		return "", err
+	}
+	signatures := append(append([]string{}, chain.signatures...), signature)
+	return cs.encodeOutput(chain.value + "." + strconv.Itoa(depth) + chainSeparator + strings.Join(signatures, chainSeparator)), nil
+}
+
+// chainLinkSignature computes the signature of the link at depth with the signing key
+func (cs CookieSignature) chainLinkSignature(chain signatureChain, depth int) (string, error) {
+	hashBytes, err := cs.signingMAC(chain.macInput(depth))
+	if err != nil {
+		return "", err
+	}
+	return hashBase64(hashBytes), nil
+}
+
+func (cs CookieSignature) verifyChainLink(chain signatureChain, depth int) error {
+	_, err := cs.unsign(chain.macInput(depth) + "." + chain.signatures[depth])
+	return err
+}
+
+// parseSignatureChain parses a chain of the form "<value>.<depth>~<signature 0>~...~<signature depth>"
+func parseSignatureChain(signed string) (signatureChain, error) {
+	index := strings.LastIndex(signed, ".")
+	if index <= 0 {
+		return signatureChain{}, errInvalidSignature
+	}
+	parts := strings.Split(signed[index+1:], chainSeparator)
+	depth, err := strconv.Atoi(parts[0])
+	if err != nil || parts[0] != strconv.Itoa(depth) || depth < 0 || len(parts) < 2 || depth != len(parts)-2 {
+		return signatureChain{}, errInvalidSignature
+	}
+	return signatureChain{value: signed[:index], signatures: parts[1:]}, nil
+}
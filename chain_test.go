@@ -0,0 +1,82 @@
+package cookiesignature
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSignChain(t *testing.T) {
+	first, _ := NewCookieSignature([]string{"tobiiscool"})
+	second, _ := NewCookieSignature([]string{"n3wsecr3t"})
+	third, _ := NewCookieSignature([]string{"th1rds3cret"})
+
+	signed, err := first.SignChain("hello.world")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if !strings.HasPrefix(signed, "hello.world.0~") {
+		t.Fatalf("unexpected chain: %s", signed)
+	}
+	if _, err := first.Unsign(signed); err == nil {
+		t.Fatalf("expected the chain not to verify as a signed value")
+	}
+
+	resigned, err := second.ResignChain(signed, first)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if again, err := second.ResignChain(resigned, nil); err != nil || again != resigned {
+		t.Fatalf("expected the chain to be unchanged, got: %s, %v", again, err)
+	}
+	resigned, err = third.ResignChain(resigned, second)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	value, depth, err := third.UnsignChain(resigned)
+	if err != nil || value != "hello.world" || depth != 2 {
+		t.Fatalf("expected hello.world at depth 2, got: %s at depth %d, %v", value, depth, err)
+	}
+	if _, _, err := second.UnsignChain(resigned); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	if _, err := third.ResignChain(signed, second); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+
+	lineage, err := AuditChain(resigned, third, first, second)
+	if err != nil || len(lineage) != 3 || lineage[0] != 1 || lineage[1] != 2 || lineage[2] != 0 {
+		t.Fatalf("unexpected lineage: %v, %v", lineage, err)
+	}
+	if _, err := AuditChain(resigned, first, third); !errors.Is(err, errInvalidSignature) || !strings.Contains(err.Error(), "depth 1") {
+		t.Fatalf("expected an invalid link at depth 1, got: %v", err)
+	}
+
+	// the links can't be dropped, reordered or have their depth changed
+	index := strings.LastIndex(resigned, ".")
+	signatures := strings.Split(resigned[index+1:], "~")[1:]
+	for _, forged := range []string{
+		"hello.world.1~" + signatures[1] + "~" + signatures[2],
+		"hello.world.0~" + signatures[2],
+		"hello.world.1~" + signatures[0] + "~" + signatures[2],
+		"hello.world.+2~" + strings.Join(signatures, "~"),
+		"hello.world." + strings.Join(signatures, "~"),
+	} {
+		if _, _, err := third.UnsignChain(forged); err != errInvalidSignature {
+			t.Fatalf("expected error: %s for %s, got: %v", errInvalidSignature, forged, err)
+		}
+	}
+
+	// negative and empty depths
+	for _, forged := range []string{"hello.-1", "hello.", "hello.-1~", "hello.~" + signatures[0]} {
+		if _, _, err := third.UnsignChain(forged); err != errInvalidSignature {
+			t.Fatalf("expected error: %s for %s, got: %v", errInvalidSignature, forged, err)
+		}
+		if _, err := third.ResignChain(forged, nil); err != errInvalidSignature {
+			t.Fatalf("expected error: %s for %s, got: %v", errInvalidSignature, forged, err)
+		}
+		if _, err := AuditChain(forged, third); err != errInvalidSignature {
+			t.Fatalf("expected error: %s for %s, got: %v", errInvalidSignature, forged, err)
+		}
+	}
+}
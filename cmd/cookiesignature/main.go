@@ -0,0 +1,86 @@
+// Command cookiesignature signs and verifies cookie values from the command line,
+// and runs the interoperability test vectors shared with the node and Python implementations
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	cookiesignature "github.com/hgiasac/go-cookie-signature"
+)
+
+const usage = `usage: cookiesignature <command> [arguments]
+
+commands:
+  sign -secret <secret> <value>       sign the value
+  unsign -secret <secret> <signed>    verify the signed value and print the value
+  serve -keys <file> [-addr :8080]    run the signing service, authorized by COOKIESIGNATURE_SIGN_TOKEN,
+                                      admin endpoints are enabled by COOKIESIGNATURE_ADMIN_TOKEN
+  vectors emit                        write the test-vector corpus to stdout
+  vectors verify [file]               verify the test-vector corpus of the file, or stdin`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "cookiesignature:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "sign", "unsign":
+		return runSignature(args[0], args[1:], stdout)
+	case "serve":
+		return runServe(args[1:], stdout)
+	case "vectors":
+		return runVectors(args[1:], stdin, stdout)
+	}
+	return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+}
+
+// secretsFlag collects the values of a repeated -secret flag
+type secretsFlag []string
+
+func (s *secretsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *secretsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func runSignature(command string, args []string, stdout io.Writer) error {
+	var secrets secretsFlag
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.Var(&secrets, "secret", "secret key, repeat to verify with several secrets, the first one signs")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(usage)
+	}
+
+	cs, err := cookiesignature.NewCookieSignature(secrets)
+	if err != nil {
+		return err
+	}
+	var result string
+	if command == "sign" {
+		result, err = cs.Sign(flags.Arg(0))
+	} else {
+		result, err = cs.Unsign(flags.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, result)
+	return err
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestSignCommands(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := run([]string{"sign", "-secret", "tobiiscool", "hello"}, nil, &stdout); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if stdout.String() != "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI\n" {
+		t.Fatalf("unexpected output: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	if err := run([]string{"unsign", "-secret", "n3wsecr3t", "-secret", "tobiiscool", "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"}, nil, &stdout); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if stdout.String() != "hello\n" {
+		t.Fatalf("unexpected output: %s", stdout.String())
+	}
+
+	if err := run([]string{"unsign", "-secret", "n3wsecr3t", "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"}, nil, &stdout); err == nil {
+		t.Fatalf("expected an invalid signature error")
+	}
+	if err := run([]string{"unknown"}, nil, &stdout); err == nil {
+		t.Fatalf("expected an unknown command error")
+	}
+}
+
+func TestVectorsCommand(t *testing.T) {
+	corpus, err := ioutil.ReadFile("../../testdata/vectors.json")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	// the checked-in corpus must be regenerated when the cases change
+	var stdout bytes.Buffer
+	if err := run([]string{"vectors", "emit"}, nil, &stdout); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if stdout.String() != string(corpus) {
+		t.Fatalf("expected the emitted vectors to match testdata/vectors.json")
+	}
+
+	stdout.Reset()
+	if err := run([]string{"vectors", "verify", "../../testdata/vectors.json"}, nil, &stdout); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if stdout.String() != "ok 12 vectors\n" {
+		t.Fatalf("unexpected output: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	tampered := strings.Replace(string(corpus), "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", "hello.FGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", 1)
+	if err := run([]string{"vectors", "verify"}, strings.NewReader(tampered), &stdout); err == nil {
+		t.Fatalf("expected a failed vector error")
+	}
+	if !strings.HasPrefix(stdout.String(), "FAIL ascii value: ") {
+		t.Fatalf("unexpected output: %s", stdout.String())
+	}
+}
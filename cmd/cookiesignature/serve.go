@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	cookiesignature "github.com/hgiasac/go-cookie-signature"
+	"github.com/hgiasac/go-cookie-signature/service"
+)
+
+const (
+	// adminTokenEnv holds the bearer token of the admin endpoints, kept out of the arguments visible to ps
+	adminTokenEnv = "COOKIESIGNATURE_ADMIN_TOKEN"
+	// signTokenEnv holds the bearer token of the sign endpoints
+	signTokenEnv = "COOKIESIGNATURE_SIGN_TOKEN"
+)
+
+func runServe(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "listen address")
+	keysPath := flags.String("keys", "", "key file, created with a new active key if it doesn't exist")
+	maxKeyAge := flags.Duration("max-key-age", 30*24*time.Hour, "how long a key stays active")
+	overlap := flags.Duration("overlap", time.Hour, "how long a new key is pending before it's promoted")
+	verifyOnlyPeriod := flags.Duration("verify-only-period", 7*24*time.Hour, "how long a demoted key keeps verifying")
+	drainQuietPeriod := flags.Duration("drain-quiet-period", 24*time.Hour, "how long a draining key must verify nothing before it's safe to retire")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *keysPath == "" {
+		return errors.New("key file must be provided")
+	}
+	signToken := os.Getenv(signTokenEnv)
+	if signToken == "" {
+		return errors.New(signTokenEnv + " must be set to the token of the sign endpoints")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	store := cookiesignature.FileKeyStore{Path: *keysPath}
+	keys, err := store.Load(ctx)
+	if os.IsNotExist(err) {
+		key, err := cookiesignature.GenerateKey()
+		if err != nil {
+			return err
+		}
+		key.State = cookiesignature.KeyActive
+		key.CreatedAt = time.Now()
+		keys = []cookiesignature.Key{key}
+		if err := store.Save(ctx, keys); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	keyRing, err := cookiesignature.NewKeyRing(keys...)
+	if err != nil {
+		return err
+	}
+
+	logger := log.New(stdout, "", log.LstdFlags)
+	rotator := &cookiesignature.Rotator{
+		KeyRing: keyRing,
+		Policy:  cookiesignature.RotationPolicy{MaxKeyAge: *maxKeyAge, Overlap: *overlap, VerifyOnlyPeriod: *verifyOnlyPeriod},
+		Store:   store,
+		OnError: func(err error) { logger.Println("rotation failed:", err) },
+	}
+	config := service.Config{
+		AuthorizeSigning: service.BearerToken(signToken),
+		DrainQuietPeriod: *drainQuietPeriod,
+		OnHealthError:    func(err error) { logger.Println("health check failed:", err) },
+	}
+	if token := os.Getenv(adminTokenEnv); token != "" {
+		config.Authorize = service.BearerToken(token)
+	}
+	handler, err := service.New(rotator, config)
+	if err != nil {
+		return err
+	}
+
+	go rotator.Run(ctx)
+	server := &http.Server{Addr: *addr, Handler: handler}
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+	logger.Println("listening on", *addr)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	cookiesignature "github.com/hgiasac/go-cookie-signature"
+)
+
+const (
+	operationSign   = "sign"
+	operationUnsign = "unsign"
+)
+
+// vector is a test case of the corpus. Sign vectors expect Sign(value) to return signed with the first secret.
+// Unsign vectors expect Unsign(signed) to return value with any secret if valid, and to fail otherwise
+type vector struct {
+	Description string   `json:"description"`
+	Operation   string   `json:"operation"`
+	Secrets     []string `json:"secrets"`
+	Value       string   `json:"value,omitempty"`
+	Signed      string   `json:"signed"`
+	Valid       bool     `json:"valid"`
+}
+
+type vectorCase struct {
+	vector
+	// signWith signs the value of valid unsign vectors, instead of the first secret
+	signWith string
+}
+
+var vectorCases = []vectorCase{
+	{vector: vector{Description: "ascii value", Operation: operationSign, Secrets: []string{"tobiiscool"}, Value: "hello", Valid: true}},
+	{vector: vector{Description: "value with dots", Operation: operationSign, Secrets: []string{"tobiiscool"}, Value: "user.42.admin", Valid: true}},
+	{vector: vector{Description: "unicode value", Operation: operationSign, Secrets: []string{"tobiiscool"}, Value: "héllo wörld ✓", Valid: true}},
+	{vector: vector{Description: "json value", Operation: operationSign, Secrets: []string{"tobiiscool"}, Value: `{"id":1,"roles":["admin"]}`, Valid: true}},
+	{vector: vector{Description: "unicode secret", Operation: operationSign, Secrets: []string{"s3crét ✓"}, Value: "hello", Valid: true}},
+	{vector: vector{Description: "signed with the first secret", Operation: operationUnsign, Secrets: []string{"n3wsecr3t", "tobiiscool"}, Value: "hello", Valid: true}},
+	{vector: vector{Description: "signed with a rotated secret", Operation: operationUnsign, Secrets: []string{"n3wsecr3t", "tobiiscool"}, Value: "hello", Valid: true}, signWith: "tobiiscool"},
+	{vector: vector{Description: "tampered signature", Operation: operationUnsign, Secrets: []string{"tobiiscool"}, Signed: "hello.EGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"}},
+	{vector: vector{Description: "tampered value", Operation: operationUnsign, Secrets: []string{"tobiiscool"}, Signed: "hellp.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"}},
+	{vector: vector{Description: "unknown secret", Operation: operationUnsign, Secrets: []string{"n3wsecr3t"}, Signed: "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"}},
+	{vector: vector{Description: "missing signature", Operation: operationUnsign, Secrets: []string{"tobiiscool"}, Signed: "hello"}},
+	{vector: vector{Description: "empty signature", Operation: operationUnsign, Secrets: []string{"tobiiscool"}, Signed: "hello."}},
+}
+
+func runVectors(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "emit":
+		vectors, err := emitVectors()
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(stdout)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(vectors)
+	case "verify":
+		if len(args) > 1 {
+			file, err := os.Open(args[1])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			stdin = file
+		}
+		var vectors []vector
+		if err := json.NewDecoder(stdin).Decode(&vectors); err != nil {
+			return err
+		}
+		return verifyVectors(vectors, stdout)
+	}
+	return fmt.Errorf("unknown vectors command %q\n%s", args[0], usage)
+}
+
+// emitVectors computes the signed values of the valid cases
+func emitVectors() ([]vector, error) {
+	vectors := make([]vector, len(vectorCases))
+	for i, c := range vectorCases {
+		v := c.vector
+		if v.Valid {
+			secret := c.signWith
+			if secret == "" {
+				secret = v.Secrets[0]
+			}
+			signed, err := cookiesignature.Sign(v.Value, []byte(secret))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", v.Description, err)
+			}
+			v.Signed = signed
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+// verifyVectors prints one line per failed vector and returns an error if any failed
+func verifyVectors(vectors []vector, stdout io.Writer) error {
+	failed := 0
+	for _, v := range vectors {
+		if err := verifyVector(v); err != nil {
+			failed++
+			fmt.Fprintf(stdout, "FAIL %s: %s\n", v.Description, err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d vectors failed", failed, len(vectors))
+	}
+	_, err := fmt.Fprintf(stdout, "ok %d vectors\n", len(vectors))
+	return err
+}
+
+func verifyVector(v vector) error {
+	cs, err := cookiesignature.NewCookieSignature(v.Secrets)
+	if err != nil {
+		return err
+	}
+
+	switch v.Operation {
+	case operationSign:
+		signed, err := cs.Sign(v.Value)
+		if err != nil {
+			return err
+		}
+		if signed != v.Signed {
+			return fmt.Errorf("expected: %s, got: %s", v.Signed, signed)
+		}
+		fallthrough
+	case operationUnsign:
+		value, err := cs.Unsign(v.Signed)
+		if !v.Valid {
+			if err == nil {
+				return fmt.Errorf("expected an invalid signature, got: %s", value)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if value != v.Value {
+			return fmt.Errorf("expected: %s, got: %s", v.Value, value)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown operation %q", v.Operation)
+}
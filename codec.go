@@ -0,0 +1,93 @@
+package cookiesignature
+
+import (
+	"encoding/hex"
+	"errors"
+	"hash"
+)
+
+const codecSubkeyLabel = "codec"
+
+// Codec names a token format signed with the keys of a KeyRing
+type Codec string
+
+const (
+	// CodecNative is the format of CookieSignature
+	CodecNative Codec = "native"
+	// CodecKeygrip is the format of Keygrip and CookieSession
+	CodecKeygrip Codec = "keygrip"
+	// CodecIron is the format of IronSeal and SealIronSession
+	CodecIron Codec = "iron"
+	// CodecRails is the format of RailsCookieEncryptor
+	CodecRails Codec = "rails"
+)
+
+// CodecKeys enables several compatibility codecs on one key ring. Each codec signs with its own HKDF subkeys
+// of the keys, DeriveSubkey(secret, "codec", name), and never with the keys themselves,
+// so a token minted for one format can never verify as another. This is a guarantee of the API rather than a convention:
+// the codecs created by CodecKeys have no way to share a key
+type CodecKeys struct {
+	keyRing *KeyRing
+}
+
+// NewCodecKeys creates a new CodecKeys instance deriving the keys of the codecs from the key ring
+func NewCodecKeys(keyRing *KeyRing) (*CodecKeys, error) {
+	if keyRing == nil {
+		return nil, errors.New("key ring must be provided")
+	}
+	return &CodecKeys{keyRing: keyRing}, nil
+}
+
+// Secrets returns the subkeys of the codec derived from the keys that verify, the active key first,
+// e.g. to configure the same codec in a node.js service
+func (ck *CodecKeys) Secrets(codec Codec) [][]byte {
+	keys := ck.keyRing.verificationKeys()
+	secrets := make([][]byte, len(keys))
+	for i, key := range keys {
+		secrets[i] = DeriveSubkey(key.secret, codecSubkeyLabel, string(codec))
+	}
+	return secrets
+}
+
+// Native creates a CookieSignature signing with the native subkeys. Changes of the key ring apply immediately.
+// Subkey labels set by WithSubkey are appended to the label of the codec, they can't remove it
+func (ck *CodecKeys) Native(opts ...Option) (*CookieSignature, error) {
+	opts = append(opts, func(o *options) {
+		o.subkeyLabels = append([]string{codecSubkeyLabel, string(CodecNative)}, o.subkeyLabels...)
+	})
+	return NewCookieSignatureFromKeyRing(ck.keyRing, opts...)
+}
+
+// Keygrip creates a Keygrip signing with the keygrip subkeys. It holds the keys of the key ring at the time of the call,
+// create it again after a rotation. If hashFunc is nil, SHA-1 is used like keygrip does by default
+func (ck *CodecKeys) Keygrip(hashFunc func() hash.Hash) (*Keygrip, error) {
+	secrets := ck.Secrets(CodecKeygrip)
+	keys := make([]string, len(secrets))
+	for i, secret := range secrets {
+		keys[i] = string(secret)
+	}
+	return NewKeygrip(keys, hashFunc)
+}
+
+// IronPasswords returns the hex-encoded iron subkeys by key ID, the passwords of IronUnseal
+func (ck *CodecKeys) IronPasswords() map[string]string {
+	keys := ck.keyRing.verificationKeys()
+	passwords := make(map[string]string, len(keys))
+	for i, secret := range ck.Secrets(CodecIron) {
+		passwords[keys[i].id] = hex.EncodeToString(secret)
+	}
+	return passwords
+}
+
+// IronPassword returns the iron password of the active key, the password of IronSeal
+func (ck *CodecKeys) IronPassword() IronPassword {
+	active := ck.keyRing.Active()
+	return IronPassword{ID: active.ID, Secret: hex.EncodeToString(DeriveSubkey(active.Secret, codecSubkeyLabel, string(CodecIron)))}
+}
+
+// Rails creates a RailsCookieEncryptor whose secret_key_base is the hex-encoded rails subkey of the active key.
+// Only cookies encrypted with the active key decrypt, create it again after a rotation
+func (ck *CodecKeys) Rails(options RailsOptions) (*RailsCookieEncryptor, error) {
+	active := ck.keyRing.Active()
+	return NewRailsCookieEncryptor(hex.EncodeToString(DeriveSubkey(active.Secret, codecSubkeyLabel, string(CodecRails))), options)
+}
@@ -0,0 +1,82 @@
+package cookiesignature
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCodecKeys(t *testing.T) {
+	if _, err := NewCodecKeys(nil); err == nil {
+		t.Fatalf("expected an error for a nil key ring")
+	}
+	kr, _ := NewKeyRing(
+		Key{ID: "k1", Secret: []byte("n3wsecr3t"), State: KeyActive},
+		Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyVerifyOnly},
+	)
+	ck, _ := NewCodecKeys(kr)
+
+	secrets := map[Codec][][]byte{}
+	for _, codec := range []Codec{CodecNative, CodecKeygrip, CodecIron, CodecRails} {
+		secrets[codec] = ck.Secrets(codec)
+		if len(secrets[codec]) != 2 {
+			t.Fatalf("expected a subkey per key, got: %d", len(secrets[codec]))
+		}
+	}
+	for codec, subkeys := range secrets {
+		for other, otherSubkeys := range secrets {
+			if codec != other && bytes.Equal(subkeys[0], otherSubkeys[0]) {
+				t.Fatalf("expected %s and %s not to share a key", codec, other)
+			}
+		}
+	}
+
+	native, _ := ck.Native(WithSubkey("override"))
+	signed, err := native.Sign("session=hello")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	plain, _ := NewCookieSignatureFromKeyRing(kr)
+	if _, err := plain.Unsign(signed); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	unlabeled, _ := ck.Native()
+	if _, err := unlabeled.Unsign(signed); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	result, err := native.Unsign(signed)
+	assertEqual(t, "session=hello", result, err)
+
+	// a keygrip digest of the same data with the same hash never verifies as a native signature, and vice versa
+	keygrip, _ := ck.Keygrip(sha256.New)
+	digest := keygrip.Sign("session=hello")
+	if _, err := native.Unsign("session=hello." + digest); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	if keygrip.Verify("session=hello", strings.TrimPrefix(signed, "session=hello.")) {
+		t.Fatalf("expected the native signature not to verify as a keygrip digest")
+	}
+
+	sealed, err := IronSeal("hello", ck.IronPassword(), time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	var value string
+	if err := IronUnseal(sealed, ck.IronPasswords(), &value); err != nil || value != "hello" {
+		t.Fatalf("expected hello, got: %s, %v", value, err)
+	}
+	if passwords := ck.IronPasswords(); len(passwords) != 2 || passwords["k1"] != ck.IronPassword().Secret {
+		t.Fatalf("unexpected passwords: %v", passwords)
+	}
+
+	rails, err := ck.Rails(RailsOptions{})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	encrypted, _ := rails.Encrypt("session", "hello", time.Time{})
+	if err := rails.Decrypt("session", encrypted, &value); err != nil || value != "hello" {
+		t.Fatalf("expected hello, got: %s, %v", value, err)
+	}
+}
@@ -0,0 +1,35 @@
+package cookiesignature
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+// compareKey keys the digests of EqualString. It is random per process so digests can't be precomputed
+var compareKey = func() []byte {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// Equal compares two MACs in constant time. MACs of different lengths are never equal.
+// Only the length of the inputs may leak through timing, which is public for MACs
+func Equal(a []byte, b []byte) bool {
+	return len(a) == len(b) && subtle.ConstantTimeCompare(a, b) == 1
+}
+
+// EqualString compares two secret strings, e.g. API keys or webhook tokens, without leaking their content or length through timing.
+// Both strings are hashed with a random key first, so the comparison always works on inputs of the same length
+func EqualString(a string, b string) bool {
+	return Equal(compareDigest(a), compareDigest(b)) && subtle.ConstantTimeEq(int32(len(a)), int32(len(b))) == 1
+}
+
+func compareDigest(input string) []byte {
+	mac := hmac.New(sha256.New, compareKey)
+	_, _ = mac.Write([]byte(input))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,22 @@
+package cookiesignature
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	if !Equal([]byte("signature"), []byte("signature")) {
+		t.Fatal("expected equal")
+	}
+	if Equal([]byte("signature"), []byte("signaturf")) || Equal([]byte("signature"), []byte("signature2")) || Equal(nil, []byte("a")) {
+		t.Fatal("expected not equal")
+	}
+	if !Equal(nil, []byte{}) {
+		t.Fatal("expected empty inputs to be equal")
+	}
+
+	if !EqualString("sk_live_123", "sk_live_123") || !EqualString("", "") {
+		t.Fatal("expected equal")
+	}
+	if EqualString("sk_live_123", "sk_live_124") || EqualString("sk_live_123", "sk_live_1234") || EqualString("", "a") {
+		t.Fatal("expected not equal")
+	}
+}
@@ -0,0 +1,415 @@
+// Package config builds a configured cookie signer from a JSON config file and environment variables,
+// so services don't have to repeat the same bootstrap code
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	cookiesignature "github.com/hgiasac/go-cookie-signature"
+)
+
+const (
+	// DefaultEnvPrefix is the prefix of the environment variables read by Load
+	DefaultEnvPrefix = "COOKIESIGNATURE"
+	// AlgorithmHS256 is HMAC-SHA256, the algorithm of node-cookie-signature and the default
+	AlgorithmHS256 = "HS256"
+	// AlgorithmHS384 is HMAC-SHA384
+	AlgorithmHS384 = "HS384"
+	// AlgorithmHS512 is HMAC-SHA512
+	AlgorithmHS512 = "HS512"
+)
+
+// lookupEnv is replaced in tests
+var lookupEnv = os.LookupEnv
+
+// Duration is a time.Duration encoded as a duration string in JSON, e.g. "1h30m"
+type Duration time.Duration
+
+// MarshalJSON encodes the duration to a duration string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decodes the duration from a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.New(`duration must be a string, e.g. "1h"`)
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// CookieConfig holds the attributes of the cookie
+type CookieConfig struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Domain   string `json:"domain"`
+	MaxAge   int    `json:"max_age"`
+	Secure   bool   `json:"secure"`
+	HTTPOnly bool   `json:"http_only"`
+	// SameSite is one of lax, strict or none. The attribute is omitted if empty
+	SameSite string `json:"same_site"`
+}
+
+// Config is the declarative configuration of a Signer
+type Config struct {
+	// Algorithm of the signatures, one of HS256, the default, HS384 or HS512
+	Algorithm string `json:"algorithm"`
+	// Secrets, the first one signs and every one verifies
+	Secrets []string `json:"secrets"`
+	// SecretEncoding of the secrets, one of raw, the default, base64 or hex. The files of docker_secret hold raw keys
+	SecretEncoding string `json:"secret_encoding"`
+	// DockerSecret is the name of a Docker secret holding the keys, used instead of Secrets
+	DockerSecret string `json:"docker_secret"`
+	// Environment label mixed into the keys, e.g. staging or prod
+	Environment string `json:"environment"`
+	// TTL of the signed values. Values are signed into timed tokens if positive
+	TTL Duration `json:"ttl"`
+	// Leeway is the tolerated clock skew of timed tokens
+	Leeway Duration `json:"leeway"`
+	// ParseMode is either lenient, the default, or strict
+	ParseMode   string       `json:"parse_mode"`
+	URLEncoding bool         `json:"url_encoding"`
+	Cookie      CookieConfig `json:"cookie"`
+}
+
+// Load reads the config file at path, or at $COOKIESIGNATURE_CONFIG_FILE if path is empty,
+// overrides it with the COOKIESIGNATURE_* environment variables and validates the result.
+// No config file is read if both are empty
+func Load(path string) (Config, error) {
+	if path == "" {
+		path, _ = lookupEnv(DefaultEnvPrefix + "_CONFIG_FILE")
+	}
+
+	var config Config
+	if path != "" {
+		var err error
+		if config, err = ReadFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := config.ApplyEnv(DefaultEnvPrefix); err != nil {
+		return Config{}, err
+	}
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// ReadFile reads a JSON config file. Unknown fields are rejected, so typos don't go unnoticed
+func ReadFile(path string) (Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var config Config
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return Config{}, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// ApplyEnv overrides the config with the environment variables of the prefix:
+// <prefix>_ALGORITHM, <prefix>_SECRETS (comma-separated), <prefix>_SECRET_ENCODING, <prefix>_DOCKER_SECRET, <prefix>_ENVIRONMENT, <prefix>_TTL, <prefix>_LEEWAY,
+// <prefix>_PARSE_MODE, <prefix>_URL_ENCODING, <prefix>_COOKIE_NAME, <prefix>_COOKIE_PATH, <prefix>_COOKIE_DOMAIN,
+// <prefix>_COOKIE_MAX_AGE, <prefix>_COOKIE_SECURE, <prefix>_COOKIE_HTTP_ONLY and <prefix>_COOKIE_SAME_SITE
+func (c *Config) ApplyEnv(prefix string) error {
+	env := func(name string) (string, bool) {
+		return lookupEnv(prefix + "_" + name)
+	}
+	var err error
+	setString := func(name string, target *string) {
+		if value, ok := env(name); ok {
+			*target = value
+		}
+	}
+	setBool := func(name string, target *bool) {
+		if value, ok := env(name); ok && err == nil {
+			if *target, err = strconv.ParseBool(value); err != nil {
+				err = fmt.Errorf("%s_%s: %w", prefix, name, err)
+			}
+		}
+	}
+	setDuration := func(name string, target *Duration) {
+		if value, ok := env(name); ok && err == nil {
+			duration, parseErr := time.ParseDuration(value)
+			if parseErr != nil {
+				err = fmt.Errorf("%s_%s: %w", prefix, name, parseErr)
+				return
+			}
+			*target = Duration(duration)
+		}
+	}
+
+	setString("ALGORITHM", &c.Algorithm)
+	if value, ok := env("SECRETS"); ok {
+		c.Secrets = nil
+		for _, secret := range strings.Split(value, ",") {
+			if secret = strings.TrimSpace(secret); secret != "" {
+				c.Secrets = append(c.Secrets, secret)
+			}
+		}
+	}
+	setString("SECRET_ENCODING", &c.SecretEncoding)
+	setString("DOCKER_SECRET", &c.DockerSecret)
+	setString("ENVIRONMENT", &c.Environment)
+	setDuration("TTL", &c.TTL)
+	setDuration("LEEWAY", &c.Leeway)
+	setString("PARSE_MODE", &c.ParseMode)
+	setBool("URL_ENCODING", &c.URLEncoding)
+	setString("COOKIE_NAME", &c.Cookie.Name)
+	setString("COOKIE_PATH", &c.Cookie.Path)
+	setString("COOKIE_DOMAIN", &c.Cookie.Domain)
+	if value, ok := env("COOKIE_MAX_AGE"); ok && err == nil {
+		if c.Cookie.MaxAge, err = strconv.Atoi(value); err != nil {
+			err = fmt.Errorf("%s_COOKIE_MAX_AGE: %w", prefix, err)
+		}
+	}
+	setBool("COOKIE_SECURE", &c.Cookie.Secure)
+	setBool("COOKIE_HTTP_ONLY", &c.Cookie.HTTPOnly)
+	setString("COOKIE_SAME_SITE", &c.Cookie.SameSite)
+	return err
+}
+
+// Validate checks the config against its schema
+func (c Config) Validate() error {
+	if _, err := c.hash(); err != nil {
+		return err
+	}
+	if len(c.Secrets) == 0 && c.DockerSecret == "" {
+		return errors.New("secrets: either secrets or docker_secret must be provided")
+	}
+	if len(c.Secrets) > 0 && c.DockerSecret != "" {
+		return errors.New("secrets: secrets and docker_secret are mutually exclusive")
+	}
+	for i, secret := range c.Secrets {
+		if secret == "" {
+			return fmt.Errorf("secrets: secret key at index %d must not be empty", i)
+		}
+	}
+	if c.TTL < 0 {
+		return errors.New("ttl: must not be negative")
+	}
+	if c.Leeway < 0 {
+		return errors.New("leeway: must not be negative")
+	}
+	secretEncoding, err := c.secretEncoding()
+	if err != nil {
+		return err
+	}
+	if c.DockerSecret != "" && secretEncoding != cookiesignature.SecretEncodingRaw {
+		return errors.New("secret_encoding: docker_secret files hold raw keys, the encoding only applies to secrets")
+	}
+	if _, err := c.parseMode(); err != nil {
+		return err
+	}
+	if c.Cookie.Name == "" {
+		return errors.New("cookie.name: must not be empty")
+	}
+	if strings.ContainsAny(c.Cookie.Name, " \t\r\n;,=\"") {
+		return fmt.Errorf("cookie.name: invalid cookie name: %s", c.Cookie.Name)
+	}
+	if c.Cookie.MaxAge < 0 {
+		return errors.New("cookie.max_age: must not be negative")
+	}
+	if _, err := c.sameSite(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Options returns the options of the signer
+func (c Config) Options() ([]cookiesignature.Option, error) {
+	parseMode, err := c.parseMode()
+	if err != nil {
+		return nil, err
+	}
+	secretEncoding, err := c.secretEncoding()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := c.hash()
+	if err != nil {
+		return nil, err
+	}
+	opts := []cookiesignature.Option{
+		cookiesignature.WithHash(hash),
+		cookiesignature.WithParseMode(parseMode),
+		cookiesignature.WithSecretEncoding(secretEncoding),
+		cookiesignature.WithLeeway(time.Duration(c.Leeway)),
+		cookiesignature.WithEnvironment(c.Environment),
+	}
+	if c.URLEncoding {
+		opts = append(opts, cookiesignature.WithURLEncoding())
+	}
+	return opts, nil
+}
+
+// HTTPCookie returns the template of the cookie
+func (c Config) HTTPCookie() (http.Cookie, error) {
+	sameSite, err := c.sameSite()
+	if err != nil {
+		return http.Cookie{}, err
+	}
+	cookie := http.Cookie{
+		Name:     c.Cookie.Name,
+		Path:     c.Cookie.Path,
+		Domain:   c.Cookie.Domain,
+		MaxAge:   c.Cookie.MaxAge,
+		Secure:   c.Cookie.Secure,
+		HttpOnly: c.Cookie.HTTPOnly,
+		SameSite: sameSite,
+	}
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if cookie.MaxAge == 0 && c.TTL > 0 {
+		cookie.MaxAge = int(time.Duration(c.TTL) / time.Second)
+	}
+	return cookie, nil
+}
+
+// New validates the config and builds the signer
+func (c Config) New() (*Signer, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	opts, err := c.Options()
+	if err != nil {
+		return nil, err
+	}
+	cookie, err := c.HTTPCookie()
+	if err != nil {
+		return nil, err
+	}
+
+	var cs *cookiesignature.CookieSignature
+	if c.DockerSecret != "" {
+		keys, err := cookiesignature.DockerSecretProvider{Name: c.DockerSecret}.Keys(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		keyRing, err := cookiesignature.NewKeyRing(keys...)
+		if err != nil {
+			return nil, err
+		}
+		cs, err = cookiesignature.NewCookieSignatureFromKeyRing(keyRing, opts...)
+		if err != nil {
+			return nil, err
+		}
+	} else if cs, err = cookiesignature.NewCookieSignature(c.Secrets, opts...); err != nil {
+		return nil, err
+	}
+
+	return &Signer{CookieSignature: cs, TTL: time.Duration(c.TTL), Cookie: cookie}, nil
+}
+
+func (c Config) hash() (crypto.Hash, error) {
+	switch c.Algorithm {
+	case "", AlgorithmHS256:
+		return crypto.SHA256, nil
+	case AlgorithmHS384:
+		return crypto.SHA384, nil
+	case AlgorithmHS512:
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("algorithm: unsupported algorithm: %s", c.Algorithm)
+	}
+}
+
+func (c Config) parseMode() (cookiesignature.ParseMode, error) {
+	switch strings.ToLower(c.ParseMode) {
+	case "", "lenient":
+		return cookiesignature.ParseLenient, nil
+	case "strict":
+		return cookiesignature.ParseStrict, nil
+	default:
+		return 0, fmt.Errorf("parse_mode: invalid parse mode: %s", c.ParseMode)
+	}
+}
+
+func (c Config) secretEncoding() (cookiesignature.SecretEncoding, error) {
+	switch strings.ToLower(c.SecretEncoding) {
+	case "", "raw":
+		return cookiesignature.SecretEncodingRaw, nil
+	case "base64":
+		return cookiesignature.SecretEncodingBase64, nil
+	case "hex":
+		return cookiesignature.SecretEncodingHex, nil
+	default:
+		return 0, fmt.Errorf("secret_encoding: invalid secret encoding: %s", c.SecretEncoding)
+	}
+}
+
+func (c Config) sameSite() (http.SameSite, error) {
+	switch strings.ToLower(c.Cookie.SameSite) {
+	case "":
+		return 0, nil
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("cookie.same_site: invalid same site: %s", c.Cookie.SameSite)
+	}
+}
+
+// Signer is a CookieSignature with the TTL and the cookie attributes of its config
+type Signer struct {
+	*cookiesignature.CookieSignature
+	TTL    time.Duration
+	Cookie http.Cookie
+}
+
+// SetCookie signs the value into the cookie of the response, as a timed token if the TTL is positive
+func (s Signer) SetCookie(w http.ResponseWriter, value string) error {
+	var signed string
+	var err error
+	if s.TTL > 0 {
+		signed, err = s.SignTimed(value, s.TTL)
+	} else {
+		signed, err = s.Sign(value)
+	}
+	if err != nil {
+		return err
+	}
+
+	cookie := s.Cookie
+	cookie.Value = signed
+	http.SetCookie(w, &cookie)
+	return nil
+}
+
+// ReadCookie verifies the cookie of the request and returns its value
+func (s Signer) ReadCookie(r *http.Request) (string, error) {
+	cookie, err := r.Cookie(s.Cookie.Name)
+	if err != nil {
+		return "", err
+	}
+	if s.TTL > 0 {
+		return s.UnsignTimed(cookie.Value)
+	}
+	return s.Unsign(cookie.Value)
+}
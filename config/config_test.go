@@ -0,0 +1,129 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testEnv(env map[string]string) func() {
+	lookupEnv = func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+	return func() { lookupEnv = os.LookupEnv }
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cookiesignature")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(`{
+		"secrets": ["tobiiscool"],
+		"ttl": "1h",
+		"parse_mode": "strict",
+		"cookie": {"name": "session", "secure": true, "same_site": "lax"}
+	}`), 0600); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	defer testEnv(map[string]string{
+		"COOKIESIGNATURE_CONFIG_FILE":      path,
+		"COOKIESIGNATURE_SECRETS":          "n3wsecr3t, tobiiscool",
+		"COOKIESIGNATURE_COOKIE_HTTP_ONLY": "true",
+	})()
+
+	config, err := Load("")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(config.Secrets) != 2 || config.Secrets[0] != "n3wsecr3t" || !config.Cookie.HTTPOnly || !config.Cookie.Secure {
+		t.Fatalf("unexpected config: %+v", config)
+	}
+
+	signer, err := config.New()
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if signer.TTL != time.Hour || signer.Cookie.MaxAge != 3600 || signer.Cookie.Path != "/" || signer.Cookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("unexpected signer: %+v", signer)
+	}
+
+	recorder := httptest.NewRecorder()
+	if err := signer.SetCookie(recorder, "hello"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range recorder.Result().Cookies() {
+		request.AddCookie(cookie)
+	}
+	value, err := signer.ReadCookie(request)
+	if err != nil || value != "hello" {
+		t.Fatalf("expected: hello, got: %s, %v", value, err)
+	}
+
+	config.Algorithm = AlgorithmHS512
+	if signer, err = config.New(); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	signed, err := signer.Sign("hello")
+	if err != nil || len(signed) != len("hello.")+86 {
+		t.Fatalf("expected an HMAC-SHA512 signature, got: %s, %v", signed, err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cookiesignature")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(`{"secret": ["tobiiscool"]}`), 0600); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	defer testEnv(nil)()
+	if _, err := Load(path); err == nil {
+		t.Fatalf("expected an unknown field error")
+	}
+
+	for env, expected := range map[string]string{
+		"COOKIESIGNATURE_ALGORITHM=ES256":       "algorithm: unsupported algorithm: ES256",
+		"COOKIESIGNATURE_COOKIE_NAME=":          "cookie.name: must not be empty",
+		"COOKIESIGNATURE_COOKIE_MAX_AGE=abc":    `COOKIESIGNATURE_COOKIE_MAX_AGE: strconv.Atoi: parsing "abc": invalid syntax`,
+		"COOKIESIGNATURE_COOKIE_SAME_SITE=lol":  "cookie.same_site: invalid same site: lol",
+		"COOKIESIGNATURE_PARSE_MODE=permissive": "parse_mode: invalid parse mode: permissive",
+		"COOKIESIGNATURE_SECRET_ENCODING=b32":   "secret_encoding: invalid secret encoding: b32",
+		"COOKIESIGNATURE_SECRETS=":              "secrets: either secrets or docker_secret must be provided",
+	} {
+		values := map[string]string{"COOKIESIGNATURE_SECRETS": "tobiiscool", "COOKIESIGNATURE_COOKIE_NAME": "session"}
+		for i := range env {
+			if env[i] == '=' {
+				values[env[:i]] = env[i+1:]
+				break
+			}
+		}
+		testEnv(values)
+		if _, err := Load(""); err == nil || err.Error() != expected {
+			t.Fatalf("expected error: %s, got: %v", expected, err)
+		}
+	}
+
+	config := Config{DockerSecret: "cookie", SecretEncoding: "hex", Cookie: CookieConfig{Name: "session"}}
+	if err := config.Validate(); err == nil || err.Error() != "secret_encoding: docker_secret files hold raw keys, the encoding only applies to secrets" {
+		t.Fatalf("expected a secret encoding error, got: %v", err)
+	}
+	config.SecretEncoding = "raw"
+	if err := config.Validate(); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+}
@@ -0,0 +1,100 @@
+package cookiesignature
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const consentPurpose = "consent"
+
+// ErrConsentOutdated is returned when a consent was given to an older version of the policy, the user must be asked again
+var ErrConsentOutdated = errors.New("consent was given to an outdated policy")
+
+// ConsentPurposes is the bitmap of the purposes a user consented to
+type ConsentPurposes uint32
+
+const (
+	// ConsentNecessary covers the cookies required by the site to work
+	ConsentNecessary ConsentPurposes = 1 << iota
+	// ConsentPreferences covers the cookies remembering the choices of the user
+	ConsentPreferences
+	// ConsentStatistics covers the analytics cookies
+	ConsentStatistics
+	// ConsentMarketing covers the advertising and tracking cookies
+	ConsentMarketing
+)
+
+// Has reports whether every purpose of the mask was consented to
+func (p ConsentPurposes) Has(mask ConsentPurposes) bool {
+	return p&mask == mask
+}
+
+// Consent is the consent state of a user, stored client-side in a signed cookie
+type Consent struct {
+	Purposes ConsentPurposes
+	// PolicyVersion is the version of the privacy policy the user consented to
+	PolicyVersion int
+	// GivenAt is when the user made the choice
+	GivenAt time.Time
+}
+
+// SignConsent signs the consent state into the value "<policy version>.<purposes>.<unix time>.<signature>",
+// readable by client-side scripts gating third-party tags and tamper-evident for the server.
+// GivenAt defaults to now. The SignatureID of the value identifies the consent in audit logs
+func (cs CookieSignature) SignConsent(consent Consent) (string, error) {
+	if consent.PolicyVersion < 0 {
+		return "", errors.New("policy version must not be negative")
+	}
+	if consent.GivenAt.IsZero() {
+		consent.GivenAt = timeNow()
+	}
+	payload := strconv.Itoa(consent.PolicyVersion) + "." +
+		strconv.FormatUint(uint64(consent.Purposes), 10) + "." +
+		strconv.FormatInt(consent.GivenAt.Unix(), 10)
+	hashBytes, err := cs.signingMAC(encodeValues([]string{consentPurpose, payload}))
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + hashBase64(hashBytes), nil
+}
+
+// UnsignConsent verifies the consent signed by SignConsent. It returns ErrConsentOutdated if it was given
+// to a policy older than policyVersion, and ErrTokenNotFresh if it was given longer than maxAge ago,
+// e.g. to ask again every year. The consent never ages if maxAge isn't positive
+func (cs CookieSignature) UnsignConsent(input string, policyVersion int, maxAge time.Duration) (Consent, error) {
+	index := strings.LastIndex(input, ".")
+	if index < 0 {
+		return Consent{}, errInvalidSignature
+	}
+	payload := input[:index]
+	if _, err := cs.unsign(encodeValues([]string{consentPurpose, payload}) + input[index:]); err != nil {
+		return Consent{}, err
+	}
+
+	fields := strings.Split(payload, ".")
+	if len(fields) != 3 {
+		return Consent{}, errInvalidSignature
+	}
+	version, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Consent{}, errInvalidSignature
+	}
+	purposes, err := strconv.ParseUint(fields[1], 10, 32)
+	if err != nil {
+		return Consent{}, errInvalidSignature
+	}
+	givenAt, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return Consent{}, errInvalidSignature
+	}
+
+	if version < policyVersion {
+		return Consent{}, ErrConsentOutdated
+	}
+	if err := cs.validateClaims(Claims{IssuedAt: givenAt}, verifyOptions{maxAge: maxAge}); err != nil {
+		return Consent{}, err
+	}
+	return Consent{Purposes: ConsentPurposes(purposes), PolicyVersion: version, GivenAt: time.Unix(givenAt, 0)}, nil
+}
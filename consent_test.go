@@ -0,0 +1,48 @@
+package cookiesignature
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConsent(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return start }
+	defer func() { timeNow = time.Now }()
+
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	signed, err := cs.SignConsent(Consent{Purposes: ConsentNecessary | ConsentStatistics, PolicyVersion: 2})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if !strings.HasPrefix(signed, "2.5.1600000000.") {
+		t.Fatalf("unexpected consent: %s", signed)
+	}
+
+	consent, err := cs.UnsignConsent(signed, 2, 365*24*time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if !consent.Purposes.Has(ConsentStatistics) || consent.Purposes.Has(ConsentStatistics|ConsentMarketing) || consent.PolicyVersion != 2 || !consent.GivenAt.Equal(start) {
+		t.Fatalf("unexpected consent: %+v", consent)
+	}
+
+	if _, err := cs.UnsignConsent(signed, 3, 0); err != ErrConsentOutdated {
+		t.Fatalf("expected error: %s, got: %v", ErrConsentOutdated, err)
+	}
+	if _, err := cs.UnsignConsent(strings.Replace(signed, "2.5.", "2.13.", 1), 2, 0); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	if _, err := cs.UnsignConsent(cs.MustSign("2.5.1600000000"), 2, 0); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+
+	timeNow = func() time.Time { return start.Add(366 * 24 * time.Hour) }
+	if _, err := cs.UnsignConsent(signed, 2, 365*24*time.Hour); err != ErrTokenNotFresh {
+		t.Fatalf("expected error: %s, got: %v", ErrTokenNotFresh, err)
+	}
+	if _, err := cs.UnsignConsent(signed, 2, 0); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+}
@@ -0,0 +1,175 @@
+package cookiesignature
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCookieSessionName = "session"
+	keygripSignatureSuffix   = ".sig"
+)
+
+var keygripReplacer = strings.NewReplacer("/", "_", "+", "-", "=", "")
+
+// Keygrip signs and verifies data in the same way as [keygrip], which is used by
+// the cookies and cookie-session npm packages to sign cookies into a separate "name.sig" cookie.
+// The first key is used to sign, all keys are tried to verify.
+//
+// [keygrip]: https://github.com/crypto-utils/keygrip
+type Keygrip struct {
+	keys [][]byte
+	hash func() hash.Hash
+}
+
+// NewKeygrip creates a new Keygrip instance. If hashFunc is nil, SHA-1 is used like keygrip does by default
+func NewKeygrip(keys []string, hashFunc func() hash.Hash) (*Keygrip, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("secret key must be provided")
+	}
+	if hashFunc == nil {
+		hashFunc = sha1.New
+	}
+
+	result := Keygrip{hash: hashFunc}
+	for i, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("secret key at index %d must not be empty", i)
+		}
+		result.keys = append(result.keys, []byte(key))
+	}
+	return &result, nil
+}
+
+// Sign computes the url-safe base64 digest of data with the first key
+func (kg Keygrip) Sign(data string) string {
+	return kg.sign(data, kg.keys[0])
+}
+
+// Index returns the index of the key that matches the digest, or -1 if no key matches
+func (kg Keygrip) Index(data string, digest string) int {
+	for i, key := range kg.keys {
+		if EqualString(digest, kg.sign(data, key)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Verify reports whether the digest matches data with any key
+func (kg Keygrip) Verify(data string, digest string) bool {
+	return kg.Index(data, digest) > -1
+}
+
+func (kg Keygrip) sign(data string, key []byte) string {
+	mac := hmac.New(kg.hash, key)
+	_, _ = mac.Write([]byte(data))
+	return keygripReplacer.Replace(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// CookieSession reads and writes sessions stored in the same format as [cookie-session]:
+// the session is a base64 encoded JSON body, signed by keygrip into a separate "name.sig" cookie.
+// Writing a blank session deletes both cookies.
+//
+// [cookie-session]: https://github.com/expressjs/cookie-session
+type CookieSession struct {
+	// Name of the session cookie. Defaults to "session"
+	Name string
+	// Keys sign and verify the session. Sessions are neither signed nor verified if Keys is nil
+	Keys *Keygrip
+	// Cookie is the template of attributes (Path, Domain, MaxAge, Secure, HttpOnly, SameSite) of written cookies
+	Cookie http.Cookie
+}
+
+// Read verifies and decodes the session of the request into the session value.
+// It returns http.ErrNoCookie if the request has no session cookie
+func (cs CookieSession) Read(r *http.Request, session interface{}) error {
+	name := cs.name()
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return err
+	}
+	if cookie.Value == "" {
+		return http.ErrNoCookie
+	}
+
+	if cs.Keys != nil {
+		sig, err := r.Cookie(name + keygripSignatureSuffix)
+		if err != nil || !cs.Keys.Verify(name+"="+cookie.Value, sig.Value) {
+			return errInvalidSignature
+		}
+	}
+
+	return DecodeCookieSessionBody(cookie.Value, session)
+}
+
+// Write encodes and signs the session into the response.
+// Blank sessions (nil or without any field) delete the session cookies, in the same way as cookie-session does
+func (cs CookieSession) Write(w http.ResponseWriter, session interface{}) error {
+	body, err := EncodeCookieSessionBody(session)
+	if err != nil {
+		return err
+	}
+
+	name := cs.name()
+	deleted := body == ""
+	cs.setCookie(w, name, body, deleted)
+	if cs.Keys != nil {
+		cs.setCookie(w, name+keygripSignatureSuffix, cs.Keys.Sign(name+"="+body), deleted)
+	}
+	return nil
+}
+
+func (cs CookieSession) name() string {
+	if cs.Name == "" {
+		return defaultCookieSessionName
+	}
+	return cs.Name
+}
+
+func (cs CookieSession) setCookie(w http.ResponseWriter, name string, value string, deleted bool) {
+	cookie := cs.Cookie
+	cookie.Name = name
+	cookie.Value = value
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if deleted {
+		cookie.Expires = time.Unix(0, 0)
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(w, &cookie)
+}
+
+// EncodeCookieSessionBody serializes the session to a base64 encoded JSON body.
+// It returns an empty string if the session is blank
+func EncodeCookieSessionBody(session interface{}) (string, error) {
+	if session == nil {
+		return "", nil
+	}
+	body, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+	if string(body) == "null" || string(body) == "{}" {
+		return "", nil
+	}
+	return base64.StdEncoding.EncodeToString(body), nil
+}
+
+// DecodeCookieSessionBody decodes a base64 encoded JSON body into the session value
+func DecodeCookieSessionBody(body string, session interface{}) error {
+	rawBody, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(rawBody, session)
+}
@@ -0,0 +1,80 @@
+package cookiesignature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKeygrip(t *testing.T) {
+	if _, err := NewKeygrip([]string{}, nil); err == nil || err.Error() != "secret key must be provided" {
+		t.Fatalf("expected error: secret key must be provided, got: %s", err)
+	}
+
+	kg, err := NewKeygrip([]string{"new secret", "keyboard cat"}, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	// signed by keygrip with the "keyboard cat" key
+	digest := "YlK1BI7yKLBneJPf_rwkbwO44BM"
+	if index := kg.Index("session=eyJ1c2VyIjoidG9iaSJ9", digest); index != 1 {
+		t.Fatalf("expected index: 1, got: %d", index)
+	}
+	if kg.Verify("session=eyJ1c2VyIjoidG9iaSJ8", digest) {
+		t.Fatal("expected invalid digest")
+	}
+	assertNotEqual(t, digest, kg.Sign("session=eyJ1c2VyIjoidG9iaSJ9"), nil)
+}
+
+func TestCookieSession(t *testing.T) {
+	kg, err := NewKeygrip([]string{"keyboard cat"}, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	cs := CookieSession{Keys: kg, Cookie: http.Cookie{HttpOnly: true}}
+
+	recorder := httptest.NewRecorder()
+	if err := cs.Write(recorder, map[string]string{"user": "tobi"}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 2 {
+		t.Fatalf("expected 2 cookies, got: %d", len(cookies))
+	}
+	assertEqual(t, "eyJ1c2VyIjoidG9iaSJ9", cookies[0].Value, nil)
+	assertEqual(t, "session.sig", cookies[1].Name, nil)
+	assertEqual(t, "YlK1BI7yKLBneJPf_rwkbwO44BM", cookies[1].Value, nil)
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	var session map[string]string
+	if err := cs.Read(request, &session); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	assertEqual(t, "tobi", session["user"], nil)
+
+	request = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(&http.Cookie{Name: "session", Value: "eyJ1c2VyIjoiYWRtaW4ifQ=="})
+	request.AddCookie(cookies[1])
+	if err := cs.Read(request, &session); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+
+	if err := cs.Read(httptest.NewRequest(http.MethodGet, "/", nil), &session); err != http.ErrNoCookie {
+		t.Fatalf("expected error: %s, got: %s", http.ErrNoCookie, err)
+	}
+
+	// blank sessions delete both cookies
+	recorder = httptest.NewRecorder()
+	if err := cs.Write(recorder, map[string]string{}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	for _, cookie := range recorder.Result().Cookies() {
+		if cookie.MaxAge != -1 {
+			t.Fatalf("expected deleted cookie, got: %s", cookie)
+		}
+	}
+}
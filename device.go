@@ -0,0 +1,16 @@
+package cookiesignature
+
+import "errors"
+
+// ErrDeviceMismatch is returned when a timed token bound to a device is used from another device,
+// e.g. a session cookie copied to another browser
+var ErrDeviceMismatch = errors.New("token was issued to another device")
+
+// WithDevice requires the Device claim of the timed token to match the identifier of the requesting device,
+// e.g. a device ID kept in the local storage of the app or a hash of the TLS client certificate.
+// Tokens without a Device claim are rejected too
+func WithDevice(deviceID string) VerifyOption {
+	return func(o *verifyOptions) {
+		o.device = &deviceID
+	}
+}
@@ -0,0 +1,26 @@
+package cookiesignature
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithDevice(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	bound, err := cs.SignClaims(Claims{Value: "hello", IssuedAt: time.Now().Unix(), Device: "device-1"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	unbound, _ := cs.SignTimed("hello", time.Hour)
+
+	value, err := cs.UnsignTimed(bound, WithDevice("device-1"))
+	assertEqual(t, "hello", value, err)
+	value, err = cs.UnsignTimed(bound)
+	assertEqual(t, "hello", value, err)
+
+	for _, token := range []string{bound, unbound} {
+		if _, err := cs.UnsignTimed(token, WithDevice("device-2")); err != ErrDeviceMismatch {
+			t.Fatalf("expected error: %s, got: %v", ErrDeviceMismatch, err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package cookiesignature
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+const (
+	digestPurpose = "digest"
+	digestPrefix  = "sha256:"
+)
+
+// ErrDigestMismatch is returned when the value doesn't match the digest of a valid signed digest
+var ErrDigestMismatch = errors.New("digest doesn't match the value")
+
+// SignDigest signs the SHA-256 digest of the value read from r instead of the value itself, and embeds the digest
+// in the output, e.g. "sha256:<digest>.<signature>". Very large values stored elsewhere, e.g. in an object store,
+// can then be integrity-checked without passing the entire body through the cookie path.
+// Signed digests are bound to their purpose, so they never verify with Unsign and vice versa
+func (cs CookieSignature) SignDigest(r io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	payload := digestPrefix + base64.RawURLEncoding.EncodeToString(hash.Sum(nil))
+	hashBytes, err := cs.signingMAC(digestMACInput(payload))
+	if err != nil {
+		return "", err
+	}
+	return cs.encodeOutput(payload + "." + hashBase64(hashBytes)), nil
+}
+
+// UnsignDigest verifies the signed digest and returns the SHA-256 digest it holds
+func (cs CookieSignature) UnsignDigest(signed string) ([]byte, error) {
+	if signed == "" {
+		return nil, errEmptySignedValue
+	}
+	if decoded, ok := cs.decodeInput(signed); ok {
+		signed = decoded
+	}
+
+	index := strings.LastIndex(signed, ".")
+	if index < 0 || !strings.HasPrefix(signed[:index], digestPrefix) {
+		return nil, errInvalidSignature
+	}
+	payload := signed[:index]
+	if _, err := cs.unsign(digestMACInput(payload) + signed[index:]); err != nil {
+		return nil, err
+	}
+	digest, err := base64.RawURLEncoding.DecodeString(payload[len(digestPrefix):])
+	if err != nil || len(digest) != sha256.Size {
+		return nil, errInvalidSignature
+	}
+	return digest, nil
+}
+
+// VerifyDigest verifies the signed digest and checks that it matches the value read from r
+func (cs CookieSignature) VerifyDigest(signed string, r io.Reader) error {
+	digest, err := cs.UnsignDigest(signed)
+	if err != nil {
+		return err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return err
+	}
+	if !Equal(digest, hash.Sum(nil)) {
+		return ErrDigestMismatch
+	}
+	return nil
+}
+
+func digestMACInput(payload string) string {
+	return encodeValues([]string{digestPurpose, payload})
+}
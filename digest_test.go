@@ -0,0 +1,55 @@
+package cookiesignature
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("read failed") }
+
+func TestSignDigest(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	body := bytes.Repeat([]byte("hello"), 100000)
+
+	signed, err := cs.SignDigest(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if !strings.HasPrefix(signed, "sha256:") || len(signed) > 100 {
+		t.Fatalf("expected a short signed digest, got: %s", signed)
+	}
+	if err := cs.VerifyDigest(signed, bytes.NewReader(body)); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if err := cs.VerifyDigest(signed, bytes.NewReader(body[1:])); err != ErrDigestMismatch {
+		t.Fatalf("expected error: %s, got: %v", ErrDigestMismatch, err)
+	}
+	if err := cs.VerifyDigest(signed, failingReader{}); err == nil || err.Error() != "read failed" {
+		t.Fatalf("expected error: read failed, got: %v", err)
+	}
+	if _, err := cs.SignDigest(failingReader{}); err == nil || err.Error() != "read failed" {
+		t.Fatalf("expected error: read failed, got: %v", err)
+	}
+
+	// signed digests and signed values aren't interchangeable
+	if _, err := cs.Unsign(signed); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	index := strings.LastIndex(signed, ".")
+	forged, _ := cs.Sign(signed[:index])
+	if _, err := cs.UnsignDigest(forged); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+
+	other, _ := NewCookieSignature([]string{"n3wsecr3t"})
+	if err := other.VerifyDigest(signed, bytes.NewReader(body)); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	if _, err := cs.UnsignDigest("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+}
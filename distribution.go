@@ -0,0 +1,121 @@
+package cookiesignature
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+const defaultKeyDistributionChannel = "cookiesignature:keys"
+
+// ErrStaleKeyUpdate is reported through OnError when a key ring update older than the last applied one is skipped
+var ErrStaleKeyUpdate = errors.New("key ring update is stale")
+
+// RedisPubSubClient is the subset of the Redis commands used by RedisKeyDistributor
+type RedisPubSubClient interface {
+	// Incr increments the integer value of the key and returns it, creating it as 0 first if it doesn't exist
+	Incr(ctx context.Context, key string) (int64, error)
+	// Publish posts the message to the channel
+	Publish(ctx context.Context, channel string, message string) error
+	// Subscribe returns the messages posted to the channel until the context is done
+	Subscribe(ctx context.Context, channel string) (<-chan string, error)
+}
+
+// keyRingUpdate is the message broadcast by RedisKeyDistributor
+type keyRingUpdate struct {
+	Version int64 `json:"version"`
+	Keys    []Key `json:"keys"`
+}
+
+// RedisKeyDistributor broadcasts the keys of a KeyRing to every replica through Redis pub/sub,
+// so a rotation on one instance is picked up by the others within seconds.
+// The messages hold the secrets, the channel must only be reachable by trusted clients
+type RedisKeyDistributor struct {
+	Client  RedisPubSubClient
+	KeyRing *KeyRing
+	// Channel of the updates. Defaults to "cookiesignature:keys"
+	Channel string
+	// OnError is called with the errors of received updates, which are skipped
+	OnError func(err error)
+
+	mu      sync.Mutex
+	version int64
+}
+
+// Publish broadcasts the keys of the key ring
+func (d *RedisKeyDistributor) Publish(ctx context.Context) error {
+	return d.Save(ctx, d.KeyRing.Keys())
+}
+
+// Load returns the keys of the key ring. Redis pub/sub doesn't persist the updates,
+// keep the keys in a durable store too to survive a restart of every replica
+func (d *RedisKeyDistributor) Load(_ context.Context) ([]Key, error) {
+	return d.KeyRing.Keys(), nil
+}
+
+// Save broadcasts the keys, so the distributor can be the Store of a Rotator
+func (d *RedisKeyDistributor) Save(ctx context.Context, keys []Key) error {
+	// the version is issued by Redis, so the updates of replicas with skewed clocks are ordered too
+	version, err := d.Client.Incr(ctx, d.channel()+":version")
+	if err != nil {
+		return err
+	}
+	message, err := json.Marshal(keyRingUpdate{Version: version, Keys: keys})
+	if err != nil {
+		return err
+	}
+	return d.Client.Publish(ctx, d.channel(), string(message))
+}
+
+// Run replaces the keys of the key ring with every update received, until the context is done.
+// Updates older than the last applied one are skipped and reported as ErrStaleKeyUpdate,
+// so reordered messages never roll a rotation back
+func (d *RedisKeyDistributor) Run(ctx context.Context) error {
+	if d.KeyRing == nil {
+		return errors.New("key ring must be provided")
+	}
+	messages, err := d.Client.Subscribe(ctx, d.channel())
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case message, ok := <-messages:
+			if !ok {
+				return errors.New("subscription closed")
+			}
+			if err := d.apply(message); err != nil && d.OnError != nil {
+				d.OnError(err)
+			}
+		}
+	}
+}
+
+func (d *RedisKeyDistributor) apply(message string) error {
+	var update keyRingUpdate
+	if err := json.Unmarshal([]byte(message), &update); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if update.Version <= d.version {
+		return ErrStaleKeyUpdate
+	}
+	if err := d.KeyRing.Replace(update.Keys); err != nil {
+		return err
+	}
+	d.version = update.Version
+	return nil
+}
+
+func (d *RedisKeyDistributor) channel() string {
+	if d.Channel == "" {
+		return defaultKeyDistributionChannel
+	}
+	return d.Channel
+}
@@ -0,0 +1,102 @@
+package cookiesignature
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testRedisPubSub struct {
+	mu          sync.Mutex
+	subscribers map[string][]chan string
+	counters    map[string]int64
+}
+
+func (ps *testRedisPubSub) Incr(_ context.Context, key string) (int64, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.counters == nil {
+		ps.counters = make(map[string]int64)
+	}
+	ps.counters[key]++
+	return ps.counters[key], nil
+}
+
+func (ps *testRedisPubSub) Publish(_ context.Context, channel string, message string) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	for _, subscriber := range ps.subscribers[channel] {
+		subscriber <- message
+	}
+	return nil
+}
+
+func (ps *testRedisPubSub) Subscribe(_ context.Context, channel string) (<-chan string, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.subscribers == nil {
+		ps.subscribers = make(map[string][]chan string)
+	}
+	subscriber := make(chan string, 10)
+	ps.subscribers[channel] = append(ps.subscribers[channel], subscriber)
+	return subscriber, nil
+}
+
+func TestRedisKeyDistributor(t *testing.T) {
+	client := &testRedisPubSub{}
+	leaderRing, _ := NewKeyRing(Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive})
+	replicaRing, _ := NewKeyRing(Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive})
+
+	leader := &RedisKeyDistributor{Client: client, KeyRing: leaderRing}
+	replica := &RedisKeyDistributor{Client: client, KeyRing: replicaRing}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- replica.Run(ctx) }()
+	for {
+		client.mu.Lock()
+		subscribed := len(client.subscribers[defaultKeyDistributionChannel]) > 0
+		client.mu.Unlock()
+		if subscribed {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := leaderRing.Add(Key{ID: "k1", Secret: []byte("luna"), State: KeyPending}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if err := leaderRing.Promote("k1"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if err := leader.Publish(ctx); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for replicaRing.Active().ID != "k1" {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the replica to pick up the new active key")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected error: %s, got: %s", context.Canceled, err)
+	}
+
+	// stale updates are skipped
+	if err := replica.apply(`{"version":1,"keys":[{"id":"k0","secret":"dG9iaWlzY29vbA==","state":"active"}]}`); err != ErrStaleKeyUpdate {
+		t.Fatalf("expected error: %s, got: %v", ErrStaleKeyUpdate, err)
+	}
+	if replicaRing.Active().ID != "k1" {
+		t.Fatalf("expected the stale update to be skipped")
+	}
+	if err := replica.apply(`{"version":1`); err == nil {
+		t.Fatalf("expected an invalid message error")
+	}
+}
+
+var _ KeyStore = &RedisKeyDistributor{}
@@ -0,0 +1,47 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const defaultDockerSecretsDir = "/run/secrets"
+
+// DockerSecretProvider is a SecretProvider that reads the keys from Docker or Swarm secrets,
+// one secret per file in /run/secrets. The files <name>, <name>.1, <name>.2 and so on are keys,
+// ordered by their suffix in natural order with the last one being the active key.
+// Trailing newlines are trimmed. Use WatchSecretProvider to pick up the secrets of a rolling update
+type DockerSecretProvider struct {
+	// Name of the secret, the base name of the files
+	Name string
+	// Dir of the secrets. Defaults to /run/secrets
+	Dir string
+}
+
+// Keys reads the keys from the secret files
+func (p DockerSecretProvider) Keys(_ context.Context) ([]Key, error) {
+	if p.Name == "" {
+		return nil, errors.New("secret name must be provided")
+	}
+	dir := p.Dir
+	if dir == "" {
+		dir = defaultDockerSecretsDir
+	}
+
+	files, err := readSecretVolume(dir)
+	if err != nil {
+		return nil, err
+	}
+	secrets := make(map[string][]byte)
+	for name, value := range files {
+		if name == p.Name || strings.HasPrefix(name, p.Name+".") {
+			secrets[name] = value
+		}
+	}
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("no secret %s found in %s", p.Name, dir)
+	}
+	return keysFromSecrets(secrets)
+}
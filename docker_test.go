@@ -0,0 +1,43 @@
+package cookiesignature
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDockerSecretProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cookiesignature")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, value := range map[string]string{
+		"cookie_secret":    "tobiiscool\n",
+		"cookie_secret.2":  "luna\n",
+		"cookie_secret.10": "loki\r\n",
+		"db_password":      "hunter2",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(value), 0600); err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+	}
+
+	keys, err := DockerSecretProvider{Name: "cookie_secret", Dir: dir}.Keys(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(keys) != 3 || keys[0].ID != "cookie_secret" || keys[1].ID != "cookie_secret.2" || keys[2].ID != "cookie_secret.10" {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+	if keys[2].State != KeyActive || string(keys[2].Secret) != "loki" || string(keys[0].Secret) != "tobiiscool" {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+
+	if _, err := (DockerSecretProvider{Name: "session_secret", Dir: dir}).Keys(context.Background()); err == nil {
+		t.Fatalf("expected a not found error")
+	}
+}
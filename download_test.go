@@ -0,0 +1,56 @@
+package cookiesignature
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignedFileServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cookiesignature")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "report.txt"), []byte("hello"), 0600); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	_ = ioutil.WriteFile(filepath.Join(dir, "other.txt"), []byte("other"), 0600)
+
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	handler := cs.RequireSignedURL(http.StripPrefix("/files", http.FileServer(http.Dir(dir))))
+
+	signedURL, err := cs.SignDownloadURL("/files/report.txt", time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, signedURL, nil))
+	if w.Code != http.StatusOK || w.Body.String() != "hello" {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+
+	for _, path := range []string{
+		"/files/report.txt",
+		strings.Replace(signedURL, "report", "other", 1),
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("%s: expected status: %d, got: %d", path, http.StatusForbidden, w.Code)
+		}
+	}
+
+	timeNow = func() time.Time { return time.Now().Add(time.Hour) }
+	defer func() { timeNow = time.Now }()
+	w = httptest.NewRecorder()
+	cs.SignedFileServer(http.Dir(dir)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, signedURL, nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status: %d, got: %d", http.StatusForbidden, w.Code)
+	}
+}
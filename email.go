@@ -0,0 +1,75 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	emailTokenIDLength = 16
+	emailTokenPurpose  = "email-token"
+)
+
+var errPurposeMismatch = errors.New("token purpose mismatch")
+
+// EmailToken is the payload of an email verification token
+type EmailToken struct {
+	UserID string
+	Email  string
+}
+
+type emailTokenClaims struct {
+	ID        string `json:"jti"`
+	Purpose   string `json:"pur"`
+	UserID    string `json:"uid"`
+	Email     string `json:"eml"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// SignEmailToken signs a token of an email verification flow for the user and the email address, valid for ttl.
+// The purpose, e.g. "verify-email" or "change-email", must match on verification,
+// so a token mailed by one flow can't complete another
+func (cs CookieSignature) SignEmailToken(purpose string, userID string, email string, ttl time.Duration) (string, error) {
+	if purpose == "" || userID == "" || email == "" {
+		return "", errors.New("purpose, user id and email must not be empty")
+	}
+	if ttl <= 0 {
+		return "", errors.New("token ttl must be positive")
+	}
+	id, err := randomBase64(emailTokenIDLength)
+	if err != nil {
+		return "", err
+	}
+
+	now := timeNow()
+	return cs.signPurposeJSON(emailTokenPurpose, emailTokenClaims{
+		ID:        id,
+		Purpose:   purpose,
+		UserID:    userID,
+		Email:     email,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+}
+
+// UnsignEmailToken verifies the token of the purpose, checks that it didn't expire and returns its payload.
+// The token is used up in the nonce store, a second verification returns ErrTokenUsed.
+// Tokens can be verified any number of times if nonces is nil
+func (cs CookieSignature) UnsignEmailToken(ctx context.Context, token string, purpose string, nonces NonceStore) (EmailToken, error) {
+	var claims emailTokenClaims
+	if err := cs.unsignPurposeJSON(emailTokenPurpose, token, &claims); err != nil {
+		return EmailToken{}, err
+	}
+	if claims.Purpose != purpose {
+		return EmailToken{}, errPurposeMismatch
+	}
+	if err := cs.validateClaims(Claims{IssuedAt: claims.IssuedAt, ExpiresAt: claims.ExpiresAt}, verifyOptions{}); err != nil {
+		return EmailToken{}, err
+	}
+	if err := cs.useNonce(ctx, nonces, claims.ID, claims.ExpiresAt); err != nil {
+		return EmailToken{}, err
+	}
+	return EmailToken{UserID: claims.UserID, Email: claims.Email}, nil
+}
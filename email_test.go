@@ -0,0 +1,61 @@
+package cookiesignature
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEmailToken(t *testing.T) {
+	timeNow = func() time.Time { return time.Unix(1600000000, 0) }
+	defer func() { timeNow = time.Now }()
+
+	ctx := context.Background()
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	if _, err := cs.SignEmailToken("verify-email", "", "tobi@example.com", time.Hour); err == nil {
+		t.Fatalf("expected an empty user id error")
+	}
+	token, err := cs.SignEmailToken("verify-email", "42", "tobi@example.com", time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	if _, err := cs.UnsignEmailToken(ctx, token, "change-email", nil); err != errPurposeMismatch {
+		t.Fatalf("expected error: %s, got: %v", errPurposeMismatch, err)
+	}
+	// other JSON tokens with the same fields aren't email tokens
+	forged, _ := cs.SignJSON(map[string]interface{}{"jti": "x", "pur": "verify-email", "uid": "1", "eml": "eve@example.com", "iat": 1600000000, "exp": 1600003600})
+	if _, err := cs.UnsignEmailToken(ctx, forged, "verify-email", nil); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+
+	nonces := NewMemoryNonceStore()
+	result, err := cs.UnsignEmailToken(ctx, token, "verify-email", nonces)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if result != (EmailToken{UserID: "42", Email: "tobi@example.com"}) {
+		t.Fatalf("unexpected token: %+v", result)
+	}
+	if _, err := cs.UnsignEmailToken(ctx, token, "verify-email", nonces); err != ErrTokenUsed {
+		t.Fatalf("expected error: %s, got: %v", ErrTokenUsed, err)
+	}
+
+	// the nonce is kept while the token verifies within the leeway
+	lenient, _ := NewCookieSignature([]string{"tobiiscool"}, WithLeeway(10*time.Second))
+	token, _ = lenient.SignEmailToken("verify-email", "42", "tobi@example.com", time.Hour)
+	if _, err := lenient.UnsignEmailToken(ctx, token, "verify-email", nonces); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	timeNow = func() time.Time { return time.Unix(1600003602, 0) }
+	if _, err := lenient.UnsignEmailToken(ctx, token, "verify-email", nonces); err != ErrTokenUsed {
+		t.Fatalf("expected error: %s, got: %v", ErrTokenUsed, err)
+	}
+
+	timeNow = func() time.Time { return time.Unix(1600000000, 0) }
+	other, _ := cs.SignEmailToken("verify-email", "42", "tobi@example.com", time.Hour)
+	timeNow = func() time.Time { return time.Unix(1600003600, 0) }
+	if _, err := cs.UnsignEmailToken(ctx, other, "verify-email", nonces); err != ErrTokenExpired {
+		t.Fatalf("expected error: %s, got: %v", ErrTokenExpired, err)
+	}
+}
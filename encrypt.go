@@ -0,0 +1,85 @@
+package cookiesignature
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+const encryptionKeyLabel = "cookiesignature encryption"
+
+var (
+	errInvalidCiphertext     = errors.New("invalid ciphertext")
+	errEncryptionUnsupported = errors.New("encryption requires secret keys, MAC providers can't encrypt")
+)
+
+// Encrypt encrypts and authenticates the plaintext with AES-256-GCM.
+// The encryption key is derived from the newest secret, so it never equals the signing key.
+// The result is url-safe base64 encoded
+func (cs CookieSignature) Encrypt(plaintext []byte) (string, error) {
+	return cs.encrypt(plaintext, nil)
+}
+
+// encrypt encrypts the plaintext, authenticating the additional data with it
+func (cs CookieSignature) encrypt(plaintext []byte, additionalData []byte) (string, error) {
+	secret := cs.signingSecret()
+	if secret == nil {
+		return "", errEncryptionUnsupported
+	}
+	aead, err := newEncryptionAEAD(secret)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, additionalData)), nil
+}
+
+// Decrypt decrypts the ciphertext created by Encrypt with any secret
+func (cs CookieSignature) Decrypt(ciphertext string) ([]byte, error) {
+	return cs.decrypt(ciphertext, nil)
+}
+
+// decrypt decrypts the ciphertext created by encrypt with the same additional data
+func (cs CookieSignature) decrypt(ciphertext string, additionalData []byte) ([]byte, error) {
+	encrypted, err := base64.RawURLEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	if cs.signingSecret() == nil {
+		return nil, errEncryptionUnsupported
+	}
+	for _, key := range cs.verificationKeys() {
+		aead, err := newEncryptionAEAD(key.secret)
+		if err != nil {
+			return nil, err
+		}
+		if len(encrypted) < aead.NonceSize()+aead.Overhead() {
+			return nil, errInvalidCiphertext
+		}
+		nonceSize := aead.NonceSize()
+		if result, err := aead.Open(nil, encrypted[:nonceSize], encrypted[nonceSize:], additionalData); err == nil {
+			cs.usage.record(key.id)
+			return result, nil
+		}
+	}
+	return nil, errInvalidCiphertext
+}
+
+func newEncryptionAEAD(secret []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(encryptionKeyLabel))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
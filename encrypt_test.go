@@ -0,0 +1,30 @@
+package cookiesignature
+
+import "testing"
+
+func TestEncrypt(t *testing.T) {
+	oldCS, err := NewCookieSignature([]string{"olds3cret"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	cs, err := NewCookieSignature([]string{"n3wsecr3t", "olds3cret"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	encrypted, err := oldCS.Encrypt([]byte("hello"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	result, err := cs.Decrypt(encrypted)
+	assertEqual(t, "hello", string(result), err)
+
+	encrypted2, err := cs.Encrypt([]byte("hello"))
+	assertNotEqual(t, encrypted, encrypted2, err)
+	if _, err := oldCS.Decrypt(encrypted2); err != errInvalidCiphertext {
+		t.Fatalf("expected error: %s, got: %s", errInvalidCiphertext, err)
+	}
+	if _, err := cs.Decrypt("aGVsbG8"); err != errInvalidCiphertext {
+		t.Fatalf("expected error: %s, got: %s", errInvalidCiphertext, err)
+	}
+}
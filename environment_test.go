@@ -0,0 +1,29 @@
+package cookiesignature
+
+import "testing"
+
+func TestEnvironment(t *testing.T) {
+	staging, _ := NewCookieSignature([]string{"tobiiscool"}, WithEnvironment("staging"))
+	prod, _ := NewCookieSignature([]string{"tobiiscool"}, WithEnvironment("prod"))
+	plain, _ := NewCookieSignature([]string{"tobiiscool"})
+
+	stagingSigned, err := staging.Sign("hello")
+	assertNotEqual(t, "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", stagingSigned, err)
+	result, err := staging.Unsign(stagingSigned)
+	assertEqual(t, "hello", result, err)
+
+	if _, err := prod.Unsign(stagingSigned); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+	if _, err := plain.Unsign(stagingSigned); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+
+	// key rings derive the same environment keys
+	keyRing, _ := NewKeyRing(Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive})
+	ringStaging, _ := NewCookieSignatureFromKeyRing(keyRing, WithEnvironment("staging"))
+	signed, err := ringStaging.Sign("hello")
+	assertEqual(t, stagingSigned, signed, err)
+	result, err = ringStaging.Unsign(stagingSigned)
+	assertEqual(t, "hello", result, err)
+}
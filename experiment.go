@@ -0,0 +1,181 @@
+package cookiesignature
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	experimentPurpose = "experiment"
+	// ExperimentCookiePrefix prefixes the ID of an experiment in the default name of its cookie
+	ExperimentCookiePrefix = "exp_"
+)
+
+var (
+	// ErrExperimentMismatch is returned when an assignment belongs to another experiment
+	ErrExperimentMismatch = errors.New("assignment belongs to another experiment")
+	// ErrUnknownVariant is returned when the variant of an assignment isn't a variant of the experiment anymore
+	ErrUnknownVariant = errors.New("unknown experiment variant")
+)
+
+// Experiment is an A/B experiment whose subjects are assigned to one of the variants
+type Experiment struct {
+	ID       string
+	Variants []string
+	// Weights of the variants, in the same order. The variants are equally likely if empty
+	Weights []int
+	// TTL of the assignments. They never expire if not positive
+	TTL time.Duration
+	// Cookie is the template of the assignment cookie. Its name defaults to the ID prefixed by ExperimentCookiePrefix
+	Cookie http.Cookie
+}
+
+// ExperimentAssignment is the variant of an experiment a subject was assigned to
+type ExperimentAssignment struct {
+	ExperimentID string `json:"xid"`
+	Variant      string `json:"var"`
+	ExpiresAt    int64  `json:"exp,omitempty"`
+}
+
+// Assign deterministically picks the variant of the subject, e.g. a user or device ID,
+// so every instance assigns a subject to the same variant without shared state
+func (e Experiment) Assign(subject string) (string, error) {
+	if e.ID == "" || len(e.Variants) == 0 {
+		return "", errors.New("experiment id and variants must be provided")
+	}
+	if len(e.Weights) != 0 && len(e.Weights) != len(e.Variants) {
+		return "", errors.New("experiment must have one weight per variant")
+	}
+
+	total := uint64(0)
+	for i := range e.Variants {
+		weight := 1
+		if len(e.Weights) > 0 {
+			weight = e.Weights[i]
+		}
+		if weight < 0 {
+			return "", errors.New("experiment weights must not be negative")
+		}
+		total += uint64(weight)
+	}
+	if total == 0 {
+		return "", errors.New("experiment weights must not all be zero")
+	}
+
+	sum := sha256.Sum256([]byte(encodeValues([]string{e.ID, subject})))
+	bucket := binary.BigEndian.Uint64(sum[:8]) % total
+	for i, variant := range e.Variants {
+		weight := uint64(1)
+		if len(e.Weights) > 0 {
+			weight = uint64(e.Weights[i])
+		}
+		if bucket < weight {
+			return variant, nil
+		}
+		bucket -= weight
+	}
+	// unreachable, the bucket is lower than the total weight
+	return "", errors.New("no experiment variant")
+}
+
+// SignExperiment assigns the subject to a variant of the experiment and signs the assignment,
+// so analytics can trust that the bucket reported by a client wasn't tampered with
+func (cs CookieSignature) SignExperiment(e Experiment, subject string) (string, error) {
+	variant, err := e.Assign(subject)
+	if err != nil {
+		return "", err
+	}
+	return cs.signAssignment(e, variant)
+}
+
+func (cs CookieSignature) signAssignment(e Experiment, variant string) (string, error) {
+	assignment := ExperimentAssignment{ExperimentID: e.ID, Variant: variant}
+	if e.TTL > 0 {
+		assignment.ExpiresAt = timeNow().Add(e.TTL).Unix()
+	}
+	data, err := json.Marshal(assignment)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	hashBytes, err := cs.signingMAC(encodeValues([]string{experimentPurpose, payload}))
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + hashBase64(hashBytes), nil
+}
+
+// UnsignExperiment verifies the assignment signed by SignExperiment for the experiment,
+// checks that it didn't expire and that its variant still exists
+func (cs CookieSignature) UnsignExperiment(e Experiment, input string) (ExperimentAssignment, error) {
+	index := strings.LastIndex(input, ".")
+	if index < 0 {
+		return ExperimentAssignment{}, errInvalidSignature
+	}
+	payload := input[:index]
+	if _, err := cs.unsign(encodeValues([]string{experimentPurpose, payload}) + input[index:]); err != nil {
+		return ExperimentAssignment{}, err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return ExperimentAssignment{}, errInvalidSignature
+	}
+	var assignment ExperimentAssignment
+	if err := json.Unmarshal(data, &assignment); err != nil {
+		return ExperimentAssignment{}, errInvalidSignature
+	}
+
+	if assignment.ExperimentID != e.ID {
+		return ExperimentAssignment{}, ErrExperimentMismatch
+	}
+	if err := cs.validateClaims(Claims{ExpiresAt: assignment.ExpiresAt}, verifyOptions{}); err != nil {
+		return ExperimentAssignment{}, err
+	}
+	for _, variant := range e.Variants {
+		if variant == assignment.Variant {
+			return assignment, nil
+		}
+	}
+	return ExperimentAssignment{}, ErrUnknownVariant
+}
+
+// ExperimentVariant returns the variant of the assignment cookie of the request if it is valid,
+// otherwise it assigns the subject and sets the assignment cookie on the response
+func (cs CookieSignature) ExperimentVariant(w http.ResponseWriter, r *http.Request, e Experiment, subject string) (string, error) {
+	name := e.cookieName()
+	if cookie, err := r.Cookie(name); err == nil {
+		if assignment, err := cs.UnsignExperiment(e, cookie.Value); err == nil {
+			return assignment.Variant, nil
+		}
+	}
+
+	variant, err := e.Assign(subject)
+	if err != nil {
+		return "", err
+	}
+	signed, err := cs.signAssignment(e, variant)
+	if err != nil {
+		return "", err
+	}
+	cookie := e.Cookie
+	cookie.Name = name
+	cookie.Value = signed
+	if e.TTL > 0 && cookie.MaxAge == 0 && cookie.Expires.IsZero() {
+		cookie.MaxAge = int(e.TTL / time.Second)
+	}
+	http.SetCookie(w, &cookie)
+	return variant, nil
+}
+
+func (e Experiment) cookieName() string {
+	if e.Cookie.Name != "" {
+		return e.Cookie.Name
+	}
+	return ExperimentCookiePrefix + e.ID
+}
@@ -0,0 +1,82 @@
+package cookiesignature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestExperiment(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return start }
+	defer func() { timeNow = time.Now }()
+
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	e := Experiment{ID: "checkout-button", Variants: []string{"control", "green"}, Weights: []int{3, 1}, TTL: 24 * time.Hour}
+
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		variant, err := e.Assign(strconv.Itoa(i))
+		if err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		counts[variant]++
+	}
+	if counts["control"] < 2800 || counts["control"] > 3200 || counts["control"]+counts["green"] != 4000 {
+		t.Fatalf("unexpected distribution: %v", counts)
+	}
+	first, _ := e.Assign("user-1")
+	second, _ := e.Assign("user-1")
+	assertEqual(t, first, second, nil)
+
+	signed, err := cs.SignExperiment(e, "user-1")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	assignment, err := cs.UnsignExperiment(e, signed)
+	if err != nil || assignment.Variant != first || assignment.ExpiresAt != start.Add(24*time.Hour).Unix() {
+		t.Fatalf("unexpected assignment: %+v, %v", assignment, err)
+	}
+	if _, err := cs.UnsignExperiment(Experiment{ID: "other", Variants: e.Variants}, signed); err != ErrExperimentMismatch {
+		t.Fatalf("expected error: %s, got: %v", ErrExperimentMismatch, err)
+	}
+	if _, err := cs.UnsignExperiment(Experiment{ID: e.ID, Variants: []string{"blue"}}, signed); err != ErrUnknownVariant {
+		t.Fatalf("expected error: %s, got: %v", ErrUnknownVariant, err)
+	}
+	if _, err := cs.UnsignExperiment(e, cs.MustSign(signed[:len(signed)-44])); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	if _, err := (Experiment{ID: "x", Variants: []string{"a"}, Weights: []int{0}}).Assign("user-1"); err == nil {
+		t.Fatal("expected an error for zero weights")
+	}
+
+	// the cookie keeps the assignment even when the weights change
+	recorder := httptest.NewRecorder()
+	variant, err := cs.ExperimentVariant(recorder, httptest.NewRequest(http.MethodGet, "/", nil), e, "user-1")
+	assertEqual(t, first, variant, err)
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "exp_checkout-button" || cookies[0].MaxAge != 86400 {
+		t.Fatalf("unexpected cookies: %v", cookies)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(cookies[0])
+	changed := e
+	changed.Weights = []int{1, 0}
+	if first == "control" {
+		changed.Weights = []int{0, 1}
+	}
+	recorder = httptest.NewRecorder()
+	variant, err = cs.ExperimentVariant(recorder, request, changed, "user-1")
+	assertEqual(t, first, variant, err)
+	if len(recorder.Result().Cookies()) != 0 {
+		t.Fatal("expected the valid cookie to be kept")
+	}
+
+	timeNow = func() time.Time { return start.Add(25 * time.Hour) }
+	if _, err := cs.UnsignExperiment(e, signed); err != ErrTokenExpired {
+		t.Fatalf("expected error: %s, got: %v", ErrTokenExpired, err)
+	}
+}
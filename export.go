@@ -0,0 +1,170 @@
+package cookiesignature
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	keyRingExportVersion = 1
+	keyRingExportAAD     = "cookiesignature keyring export v1"
+	exportKeyLength      = 32
+)
+
+var errInvalidKeyRingExport = errors.New("invalid key ring export")
+
+// KeyWrapper wraps and unwraps data keys with a key held by a KMS, e.g. the Encrypt and Decrypt calls of AWS KMS or GCP Cloud KMS.
+// It keeps this package free of the SDK dependencies of every KMS
+type KeyWrapper interface {
+	WrapKey(ctx context.Context, key []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// keyRingExport is the format of an exported key ring. The keys are encrypted with AES-256-GCM
+// by a key derived from a password with scrypt, or by a random data key wrapped by a KMS
+type keyRingExport struct {
+	Version    int           `json:"version"`
+	Scrypt     *ScryptParams `json:"scrypt,omitempty"`
+	WrappedKey []byte        `json:"wrapped_key,omitempty"`
+	Ciphertext []byte        `json:"ciphertext"`
+}
+
+// exportedKey is encoded with the secrets of the passphrase keys, which only exist in the encrypted export
+type exportedKey Key
+
+// ExportKeyRing writes the keys of the key ring encrypted with a key derived from the password,
+// e.g. to back them up or to move them to another environment
+func ExportKeyRing(w io.Writer, keyRing *KeyRing, password string) error {
+	params, err := NewScryptParams()
+	if err != nil {
+		return err
+	}
+	key, err := params.Derive(password)
+	if err != nil {
+		return err
+	}
+	return writeKeyRingExport(w, keyRing, key, keyRingExport{Scrypt: &params})
+}
+
+// ExportKeyRingWrapped writes the keys of the key ring encrypted with a random data key wrapped by the KMS
+func ExportKeyRingWrapped(ctx context.Context, w io.Writer, keyRing *KeyRing, wrapper KeyWrapper) error {
+	key := make([]byte, exportKeyLength)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	wrapped, err := wrapper.WrapKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	return writeKeyRingExport(w, keyRing, key, keyRingExport{WrappedKey: wrapped})
+}
+
+// ImportKeyRing reads a key ring exported by ExportKeyRing
+func ImportKeyRing(r io.Reader, password string) (*KeyRing, error) {
+	export, err := readKeyRingExport(r)
+	if err != nil {
+		return nil, err
+	}
+	if export.Scrypt == nil {
+		return nil, errors.New("key ring export isn't protected by a password")
+	}
+	key, err := export.Scrypt.Derive(password)
+	if err != nil {
+		return nil, err
+	}
+	return decryptKeyRingExport(export, key)
+}
+
+// ImportKeyRingWrapped reads a key ring exported by ExportKeyRingWrapped
+func ImportKeyRingWrapped(ctx context.Context, r io.Reader, wrapper KeyWrapper) (*KeyRing, error) {
+	export, err := readKeyRingExport(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(export.WrappedKey) == 0 {
+		return nil, errors.New("key ring export isn't protected by a wrapped key")
+	}
+	key, err := wrapper.UnwrapKey(ctx, export.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return decryptKeyRingExport(export, key)
+}
+
+func writeKeyRingExport(w io.Writer, keyRing *KeyRing, key []byte, export keyRingExport) error {
+	keys := keyRing.Keys()
+	exportedKeys := make([]exportedKey, len(keys))
+	for i, key := range keys {
+		exportedKeys[i] = exportedKey(key)
+	}
+	plaintext, err := json.Marshal(exportedKeys)
+	if err != nil {
+		return err
+	}
+
+	aead, err := newExportAEAD(key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	export.Version = keyRingExportVersion
+	export.Ciphertext = aead.Seal(nonce, nonce, plaintext, []byte(keyRingExportAAD))
+	return json.NewEncoder(w).Encode(export)
+}
+
+func readKeyRingExport(r io.Reader) (keyRingExport, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return keyRingExport{}, err
+	}
+	var export keyRingExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return keyRingExport{}, errInvalidKeyRingExport
+	}
+	if export.Version != keyRingExportVersion {
+		return keyRingExport{}, errInvalidKeyRingExport
+	}
+	return export, nil
+}
+
+func decryptKeyRingExport(export keyRingExport, key []byte) (*KeyRing, error) {
+	aead, err := newExportAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := aead.NonceSize()
+	if len(export.Ciphertext) < nonceSize+aead.Overhead() {
+		return nil, errInvalidKeyRingExport
+	}
+	plaintext, err := aead.Open(nil, export.Ciphertext[:nonceSize], export.Ciphertext[nonceSize:], []byte(keyRingExportAAD))
+	if err != nil {
+		return nil, errInvalidKeyRingExport
+	}
+
+	var exportedKeys []exportedKey
+	if err := json.Unmarshal(plaintext, &exportedKeys); err != nil {
+		return nil, errInvalidKeyRingExport
+	}
+	keys := make([]Key, len(exportedKeys))
+	for i, key := range exportedKeys {
+		keys[i] = Key(key)
+	}
+	return NewKeyRing(keys...)
+}
+
+func newExportAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
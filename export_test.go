@@ -0,0 +1,74 @@
+package cookiesignature
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"strings"
+	"testing"
+)
+
+// testKeyWrapper wraps keys with a local AES-GCM key and a fixed nonce, like a KMS would with its own key
+type testKeyWrapper struct {
+	aead cipher.AEAD
+}
+
+func newTestKeyWrapper() testKeyWrapper {
+	block, _ := aes.NewCipher(bytes.Repeat([]byte{1}, 32))
+	aead, _ := cipher.NewGCM(block)
+	return testKeyWrapper{aead: aead}
+}
+
+func (w testKeyWrapper) WrapKey(_ context.Context, key []byte) ([]byte, error) {
+	return w.aead.Seal(nil, make([]byte, w.aead.NonceSize()), key, nil), nil
+}
+
+func (w testKeyWrapper) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	return w.aead.Open(nil, make([]byte, w.aead.NonceSize()), wrapped, nil)
+}
+
+func TestKeyRingExport(t *testing.T) {
+	passphraseKey, _ := NewPassphraseKey("k1", "correct horse battery staple", ScryptParams{Salt: []byte("pepper"), N: 16, R: 8, P: 1, KeyLength: 32})
+	keyRing, _ := NewKeyRing(Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive}, passphraseKey)
+
+	var exported bytes.Buffer
+	if err := ExportKeyRing(&exported, keyRing, "s3cr3t"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if strings.Contains(exported.String(), "dG9iaWlzY29vbA") {
+		t.Fatalf("expected the secrets to be encrypted, got: %s", exported.String())
+	}
+
+	if _, err := ImportKeyRing(bytes.NewReader(exported.Bytes()), "wrong"); err != errInvalidKeyRingExport {
+		t.Fatalf("expected error: %s, got: %s", errInvalidKeyRingExport, err)
+	}
+	imported, err := ImportKeyRing(bytes.NewReader(exported.Bytes()), "s3cr3t")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	keys := imported.Keys()
+	if len(keys) != 2 || imported.Fingerprint() != keyRing.Fingerprint() || keys[1].Fingerprint() != passphraseKey.Fingerprint() || keys[1].Scrypt == nil {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+
+	wrapper := newTestKeyWrapper()
+	exported.Reset()
+	if err := ExportKeyRingWrapped(context.Background(), &exported, keyRing, wrapper); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if _, err := ImportKeyRing(bytes.NewReader(exported.Bytes()), "s3cr3t"); err == nil {
+		t.Fatalf("expected a missing password protection error")
+	}
+	imported, err = ImportKeyRingWrapped(context.Background(), bytes.NewReader(exported.Bytes()), wrapper)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if imported.Fingerprint() != keyRing.Fingerprint() {
+		t.Fatalf("unexpected keys: %+v", imported.Keys())
+	}
+
+	if _, err := ImportKeyRing(strings.NewReader(`{"version":2}`), "s3cr3t"); err != errInvalidKeyRingExport {
+		t.Fatalf("expected error: %s, got: %s", errInvalidKeyRingExport, err)
+	}
+}
@@ -0,0 +1,226 @@
+package cookiesignature
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultConnectRedisPrefix = "sess:"
+
+// ErrSessionExpired is returned by ExpressSession.Validate when the cookie of the session expired,
+// so migrations can skip expired sessions instead of reporting them as invalid
+var ErrSessionExpired = errors.New("session is expired")
+
+var errInvalidExpressSession = errors.New("invalid express session record")
+
+// ExpressSession is a session record of express-session, as stored by connect-redis or connect-mongo
+type ExpressSession struct {
+	ID     string
+	Cookie ExpressSessionCookie
+	// Data holds the fields of the session other than the cookie, e.g. passport
+	Data map[string]json.RawMessage
+}
+
+// ExpressSessionCookie holds the cookie settings stored with an express-session record
+type ExpressSessionCookie struct {
+	// OriginalMaxAge is the max age of the cookie in milliseconds, nil for a browser-session cookie
+	OriginalMaxAge *int64     `json:"originalMaxAge"`
+	Expires        *time.Time `json:"expires"`
+	Secure         bool       `json:"secure"`
+	HTTPOnly       bool       `json:"httpOnly"`
+	Domain         string     `json:"domain"`
+	Path           string     `json:"path"`
+	// SameSite is either a boolean or one of lax, strict or none
+	SameSite json.RawMessage `json:"sameSite"`
+}
+
+// ParseConnectRedisSession parses a session record of connect-redis. The prefix of the keys defaults to "sess:"
+func ParseConnectRedisSession(key string, value []byte, prefix string) (ExpressSession, error) {
+	if prefix == "" {
+		prefix = defaultConnectRedisPrefix
+	}
+	if !strings.HasPrefix(key, prefix) {
+		return ExpressSession{}, errors.New("session key must be prefixed with " + prefix)
+	}
+	return parseExpressSession(key[len(prefix):], value)
+}
+
+// ParseConnectMongoSession parses a session document of connect-mongo in extended JSON, e.g. exported by mongoexport.
+// Sessions stored both stringified, the default, and as objects are accepted
+func ParseConnectMongoSession(document []byte) (ExpressSession, error) {
+	var doc struct {
+		ID      string          `json:"_id"`
+		Session json.RawMessage `json:"session"`
+		Expires json.RawMessage `json:"expires"`
+	}
+	if err := json.Unmarshal(document, &doc); err != nil {
+		return ExpressSession{}, errInvalidExpressSession
+	}
+
+	data := []byte(doc.Session)
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var stringified string
+		if err := json.Unmarshal(data, &stringified); err != nil {
+			return ExpressSession{}, errInvalidExpressSession
+		}
+		data = []byte(stringified)
+	}
+	session, err := parseExpressSession(doc.ID, data)
+	if err != nil {
+		return ExpressSession{}, err
+	}
+
+	// the expires field of the document is the TTL index of connect-mongo, set even for browser-session cookies
+	if session.Cookie.Expires == nil && len(doc.Expires) > 0 {
+		expires, err := parseExtendedJSONDate(doc.Expires)
+		if err != nil {
+			return ExpressSession{}, err
+		}
+		session.Cookie.Expires = &expires
+	}
+	return session, nil
+}
+
+func parseExpressSession(id string, data []byte) (ExpressSession, error) {
+	session := ExpressSession{ID: id}
+	if err := json.Unmarshal(data, &session.Data); err != nil || session.Data == nil {
+		return ExpressSession{}, errInvalidExpressSession
+	}
+	if cookie, ok := session.Data["cookie"]; ok {
+		if err := json.Unmarshal(cookie, &session.Cookie); err != nil {
+			return ExpressSession{}, errInvalidExpressSession
+		}
+		delete(session.Data, "cookie")
+	}
+	return session, nil
+}
+
+// parseExtendedJSONDate parses a date of MongoDB extended JSON, in relaxed or canonical mode
+func parseExtendedJSONDate(data json.RawMessage) (time.Time, error) {
+	var date struct {
+		Date json.RawMessage `json:"$date"`
+	}
+	if err := json.Unmarshal(data, &date); err == nil && date.Date != nil {
+		data = date.Date
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return time.Time{}, errInvalidExpressSession
+	}
+	switch v := value.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, errInvalidExpressSession
+		}
+		return t, nil
+	case float64:
+		return time.Unix(0, int64(v)*int64(time.Millisecond)), nil
+	case map[string]interface{}:
+		millis, err := strconv.ParseInt(stringValue(v["$numberLong"]), 10, 64)
+		if err != nil {
+			return time.Time{}, errInvalidExpressSession
+		}
+		return time.Unix(0, millis*int64(time.Millisecond)), nil
+	}
+	return time.Time{}, errInvalidExpressSession
+}
+
+func stringValue(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+// Validate checks that the session has an ID and that its cookie is not expired
+func (s ExpressSession) Validate() error {
+	if s.ID == "" {
+		return errors.New("session id must not be empty")
+	}
+	if s.Cookie.Expires != nil && !timeNow().Before(*s.Cookie.Expires) {
+		return ErrSessionExpired
+	}
+	return nil
+}
+
+// HTTPCookie returns the session cookie signed by cs, with the settings stored in the session.
+// The value keeps the "s:" prefixed format of express-session, so the Express application
+// still accepts the cookie during the cutover
+func (s ExpressSession) HTTPCookie(cs *CookieSignature, name string) (*http.Cookie, error) {
+	value, err := cs.SignSessionID(s.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.httpCookie(name, value), nil
+}
+
+// NativeCookie returns the session cookie rewritten to a timed token of cs, with the settings stored in the session.
+// The session ID is the value of the token, which expires with the session, so once the Express application
+// is retired the cookie is verified by UnsignClaims like the cookies issued by the Go application
+func (s ExpressSession) NativeCookie(cs *CookieSignature, name string) (*http.Cookie, error) {
+	claims := Claims{Value: s.ID, IssuedAt: timeNow().Unix()}
+	if s.Cookie.Expires != nil {
+		claims.ExpiresAt = s.Cookie.Expires.Unix()
+	}
+	value, err := cs.SignClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+	return s.httpCookie(name, value), nil
+}
+
+func (s ExpressSession) httpCookie(name string, value string) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     s.Cookie.Path,
+		Domain:   s.Cookie.Domain,
+		Secure:   s.Cookie.Secure,
+		HttpOnly: s.Cookie.HTTPOnly,
+		SameSite: s.Cookie.sameSite(),
+	}
+	if s.Cookie.Expires != nil {
+		cookie.Expires = *s.Cookie.Expires
+	}
+	return cookie
+}
+
+func (c ExpressSessionCookie) sameSite() http.SameSite {
+	var value interface{}
+	_ = json.Unmarshal(c.SameSite, &value)
+	switch v := value.(type) {
+	case bool:
+		// express-session maps true to strict
+		if v {
+			return http.SameSiteStrictMode
+		}
+	case string:
+		switch strings.ToLower(v) {
+		case "lax":
+			return http.SameSiteLaxMode
+		case "strict":
+			return http.SameSiteStrictMode
+		case "none":
+			return http.SameSiteNoneMode
+		}
+	}
+	return 0
+}
+
+// ConnectRedisKey verifies an express-session cookie value and returns the key of its session in connect-redis.
+// The prefix of the keys defaults to "sess:"
+func (cs CookieSignature) ConnectRedisKey(cookieValue string, prefix string) (string, error) {
+	sid, err := cs.UnsignSessionID(cookieValue)
+	if err != nil {
+		return "", err
+	}
+	if prefix == "" {
+		prefix = defaultConnectRedisPrefix
+	}
+	return prefix + sid, nil
+}
@@ -0,0 +1,95 @@
+package cookiesignature
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseConnectRedisSession(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = time.Now }()
+
+	value := `{"cookie":{"originalMaxAge":86400000,"expires":"2021-06-02T00:00:00.000Z","secure":true,"httpOnly":true,"path":"/","sameSite":"lax"},"passport":{"user":42}}`
+	session, err := ParseConnectRedisSession("sess:abc", []byte(value), "")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if session.ID != "abc" || string(session.Data["passport"]) != `{"user":42}` || session.Data["cookie"] != nil {
+		t.Fatalf("unexpected session: %+v", session)
+	}
+	if err := session.Validate(); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	cs, _ := NewCookieSignature([]string{"keyboard cat"})
+	cookie, err := session.HTTPCookie(cs, "connect.sid")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if cookie.SameSite != http.SameSiteLaxMode || !cookie.Secure || !cookie.HttpOnly || cookie.Path != "/" || !cookie.Expires.Equal(*session.Cookie.Expires) {
+		t.Fatalf("unexpected cookie: %+v", cookie)
+	}
+
+	// set by an express application
+	key, err := cs.ConnectRedisKey("s%3Aabc.BpxCrWRpvZMh%2Fwk%2Fdjl34N%2Bm%2BVQEU7K%2F5WenLwJCgFU", "")
+	assertEqual(t, "sess:abc", key, err)
+	key, err = cs.ConnectRedisKey(cookie.Value, "myapp:")
+	assertEqual(t, "myapp:abc", key, err)
+
+	if _, err := ParseConnectRedisSession("other:abc", []byte(value), ""); err == nil {
+		t.Fatalf("expected a prefix error")
+	}
+	if _, err := ParseConnectRedisSession("sess:abc", []byte("null"), ""); err != errInvalidExpressSession {
+		t.Fatalf("expected error: %s, got: %s", errInvalidExpressSession, err)
+	}
+
+	native, err := session.NativeCookie(cs, "session")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if native.Name != "session" || !native.Secure || !native.Expires.Equal(*session.Cookie.Expires) {
+		t.Fatalf("unexpected cookie: %+v", native)
+	}
+	claims, err := cs.UnsignClaims(native.Value)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if claims.Value != "abc" || claims.ExpiresAt != session.Cookie.Expires.Unix() {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	timeNow = func() time.Time { return time.Date(2021, 6, 3, 0, 0, 0, 0, time.UTC) }
+	if err := session.Validate(); err != ErrSessionExpired {
+		t.Fatalf("expected error: %s, got: %s", ErrSessionExpired, err)
+	}
+	if _, err := cs.UnsignClaims(native.Value); err != ErrTokenExpired {
+		t.Fatalf("expected error: %s, got: %v", ErrTokenExpired, err)
+	}
+}
+
+func TestParseConnectMongoSession(t *testing.T) {
+	expires := time.Date(2021, 6, 2, 0, 0, 0, 0, time.UTC)
+	for _, document := range []string{
+		`{"_id":"abc","expires":{"$date":"2021-06-02T00:00:00Z"},"session":"{\"cookie\":{\"originalMaxAge\":null,\"expires\":null,\"sameSite\":true},\"views\":3}"}`,
+		`{"_id":"abc","expires":{"$date":{"$numberLong":"1622592000000"}},"session":{"cookie":{"originalMaxAge":null,"expires":null,"sameSite":true},"views":3}}`,
+	} {
+		session, err := ParseConnectMongoSession([]byte(document))
+		if err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		if session.ID != "abc" || string(session.Data["views"]) != "3" {
+			t.Fatalf("unexpected session: %+v", session)
+		}
+		if session.Cookie.Expires == nil || !session.Cookie.Expires.Equal(expires) {
+			t.Fatalf("expected expires: %s, got: %v", expires, session.Cookie.Expires)
+		}
+		if session.Cookie.sameSite() != http.SameSiteStrictMode {
+			t.Fatalf("expected the strict same site mode, got: %d", session.Cookie.sameSite())
+		}
+	}
+
+	if _, err := ParseConnectMongoSession([]byte(`{"_id":"abc","session":"{invalid"}`)); err != errInvalidExpressSession {
+		t.Fatalf("expected error: %s, got: %s", errInvalidExpressSession, err)
+	}
+}
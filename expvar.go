@@ -0,0 +1,47 @@
+package cookiesignature
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sync"
+)
+
+// expvarMu serializes the lookup and the creation of the expvar maps, as expvar.NewMap panics on a duplicate name
+var expvarMu sync.Mutex
+
+// WithExpvar publishes the sign_success, sign_failure, unsign_success and unsign_failure counters
+// as an expvar map of the name, served at /debug/vars by the expvar handler.
+// Signers configured with the same name share the counters.
+// The constructor returns an error if the name is already published by a variable that isn't a map
+func WithExpvar(name string) Option {
+	counters, err := expvarMap(name)
+	if err != nil {
+		return func(o *options) {
+			o.err = err
+		}
+	}
+
+	return WithHook(func(_ context.Context, event Event) {
+		result := "success"
+		if event.Err != nil {
+			result = "failure"
+		}
+		counters.Add(event.Operation.String()+"_"+result, 1)
+	})
+}
+
+func expvarMap(name string) (*expvar.Map, error) {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	existing := expvar.Get(name)
+	if existing == nil {
+		return expvar.NewMap(name), nil
+	}
+	counters, ok := existing.(*expvar.Map)
+	if !ok {
+		return nil, fmt.Errorf("expvar %s is already published and isn't a map", name)
+	}
+	return counters, nil
+}
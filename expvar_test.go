@@ -0,0 +1,36 @@
+package cookiesignature
+
+import (
+	"expvar"
+	"testing"
+)
+
+func TestExpvar(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"}, WithExpvar("cookiesignature_test"))
+	other, _ := NewCookieSignature([]string{"tobiiscool"}, WithExpvar("cookiesignature_test"))
+
+	signed, _ := cs.Sign("hello")
+	_, _ = cs.Unsign(signed)
+	_, _ = other.Unsign(signed)
+	_, _ = cs.Unsign("hello.invalid")
+	_, _ = cs.Sign("")
+
+	counters := expvar.Get("cookiesignature_test").(*expvar.Map)
+	for name, expected := range map[string]string{
+		"sign_success":   "1",
+		"sign_failure":   "1",
+		"unsign_success": "2",
+		"unsign_failure": "1",
+	} {
+		if got := counters.Get(name); got == nil || got.String() != expected {
+			t.Fatalf("expected %s: %s, got: %v", name, expected, got)
+		}
+	}
+}
+
+func TestExpvarNameCollision(t *testing.T) {
+	expvar.NewInt("cookiesignature_test_int")
+	if _, err := NewCookieSignature([]string{"tobiiscool"}, WithExpvar("cookiesignature_test_int")); err == nil {
+		t.Fatal("expected an error for an expvar that isn't a map")
+	}
+}
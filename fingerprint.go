@@ -0,0 +1,35 @@
+package cookiesignature
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const fingerprintLength = 8
+
+// Fingerprint returns the hex of the first 8 bytes of the SHA-256 of the secret.
+// It identifies the key in logs, dashboards and between teams without revealing it
+func Fingerprint(secret []byte) string {
+	hash := sha256.Sum256(secret)
+	return hex.EncodeToString(hash[:fingerprintLength])
+}
+
+// Fingerprint returns the fingerprint of the secret of the key
+func (k Key) Fingerprint() string {
+	return Fingerprint(k.Secret)
+}
+
+// Fingerprint returns the fingerprint of the active key
+func (kr *KeyRing) Fingerprint() string {
+	return kr.Active().Fingerprint()
+}
+
+// Fingerprint returns the fingerprint of the key that signs, after the derivations of WithSubkey and WithEnvironment.
+// Two signers with the same fingerprint produce the same signatures. It is empty if the MAC is computed by a MACProvider
+func (cs CookieSignature) Fingerprint() string {
+	secret := cs.signingSecret()
+	if secret == nil {
+		return ""
+	}
+	return Fingerprint(secret)
+}
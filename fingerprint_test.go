@@ -0,0 +1,20 @@
+package cookiesignature
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	// echo -n tobiiscool | sha256sum | head -c 16
+	assertEqual(t, "76d6ab99a144287c", Fingerprint([]byte("tobiiscool")), nil)
+
+	key := Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive}
+	assertEqual(t, "76d6ab99a144287c", key.Fingerprint(), nil)
+
+	keyRing, _ := NewKeyRing(key, Key{ID: "k1", Secret: []byte("luna"), State: KeyPending})
+	assertEqual(t, "76d6ab99a144287c", keyRing.Fingerprint(), nil)
+
+	cs, _ := NewCookieSignature([]string{"tobiiscool", "luna"})
+	assertEqual(t, "76d6ab99a144287c", cs.Fingerprint(), nil)
+
+	prod, _ := NewCookieSignature([]string{"tobiiscool"}, WithEnvironment("prod"))
+	assertNotEqual(t, cs.Fingerprint(), prod.Fingerprint(), nil)
+}
@@ -0,0 +1,129 @@
+package cookiesignature
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CSRFFieldName is the name of the hidden input rendered by the csrfField template function
+const CSRFFieldName = "csrf_token"
+
+const (
+	csrfPurpose      = "csrf"
+	formFieldPurpose = "form-field"
+)
+
+var (
+	// ErrInvalidCSRFToken is returned when a form post has no valid CSRF token for the session
+	ErrInvalidCSRFToken = errors.New("invalid csrf token")
+	// ErrFormFieldTampered is returned when a signed hidden field of a form post is missing or was modified
+	ErrFormFieldTampered = errors.New("signed form field is missing or was modified")
+)
+
+// FormFuncs returns the template functions rendering signed hidden inputs, to add to an html/template with Funcs:
+//
+//	{{ csrfField .SessionID }} renders the CSRF token of the session
+//	{{ signedHidden "plan" .Plan }} renders a hidden input whose value is signed for its field name
+//
+// Multi-step forms carry the state of the previous steps in signed hidden inputs, which VerifyForm checks on post
+func (cs CookieSignature) FormFuncs() template.FuncMap {
+	return template.FuncMap{
+		"csrfField":    cs.csrfField,
+		"signedHidden": cs.signedHidden,
+	}
+}
+
+// SignCSRFToken signs a CSRF token for the session. Every token is different, so it doesn't leak through compression,
+// but all of them are valid for the session until it ends
+func (cs CookieSignature) SignCSRFToken(sessionID string) (string, error) {
+	if sessionID == "" {
+		return "", errors.New("session id must not be empty")
+	}
+	nonce, err := randomBase64(16)
+	if err != nil {
+		return "", err
+	}
+	hashBytes, err := cs.signingMAC(encodeValues([]string{csrfPurpose, sessionID, nonce}))
+	if err != nil {
+		return "", err
+	}
+	return nonce + "." + hashBase64(hashBytes), nil
+}
+
+// VerifyCSRFToken verifies the CSRF token signed by SignCSRFToken for the session
+func (cs CookieSignature) VerifyCSRFToken(token string, sessionID string) error {
+	index := strings.LastIndex(token, ".")
+	if sessionID == "" || index < 0 {
+		return ErrInvalidCSRFToken
+	}
+	if _, err := cs.unsign(encodeValues([]string{csrfPurpose, sessionID, token[:index]}) + token[index:]); err != nil {
+		return ErrInvalidCSRFToken
+	}
+	return nil
+}
+
+// VerifyForm parses the form of the request, verifies its CSRF token for the session and its signed fields,
+// and returns the posted values with the signed fields replaced by their verified values
+func (cs CookieSignature) VerifyForm(r *http.Request, sessionID string, signedFields ...string) (url.Values, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	if err := cs.VerifyCSRFToken(r.PostForm.Get(CSRFFieldName), sessionID); err != nil {
+		return nil, err
+	}
+
+	values := make(url.Values, len(r.PostForm))
+	for name, posted := range r.PostForm {
+		values[name] = append([]string(nil), posted...)
+	}
+	for _, name := range signedFields {
+		posted := values[name]
+		if len(posted) == 0 {
+			return nil, ErrFormFieldTampered
+		}
+		for i, signed := range posted {
+			value, err := cs.unsignFormField(name, signed)
+			if err != nil {
+				return nil, ErrFormFieldTampered
+			}
+			posted[i] = value
+		}
+	}
+	return values, nil
+}
+
+func (cs CookieSignature) csrfField(sessionID string) (template.HTML, error) {
+	token, err := cs.SignCSRFToken(sessionID)
+	if err != nil {
+		return "", err
+	}
+	return hiddenInput(CSRFFieldName, token), nil
+}
+
+func (cs CookieSignature) signedHidden(name string, value string) (template.HTML, error) {
+	hashBytes, err := cs.signingMAC(encodeValues([]string{formFieldPurpose, name, value}))
+	if err != nil {
+		return "", err
+	}
+	return hiddenInput(name, value+"."+hashBase64(hashBytes)), nil
+}
+
+// unsignFormField verifies the value of the field signed by signedHidden, the MAC binds the field name
+func (cs CookieSignature) unsignFormField(name string, signed string) (string, error) {
+	index := strings.LastIndex(signed, ".")
+	if index < 0 {
+		return "", errInvalidSignature
+	}
+	value := signed[:index]
+	if _, err := cs.unsign(encodeValues([]string{formFieldPurpose, name, value}) + signed[index:]); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+func hiddenInput(name string, value string) template.HTML {
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(name) + `" value="` + template.HTMLEscapeString(value) + `">`)
+}
@@ -0,0 +1,70 @@
+package cookiesignature
+
+import (
+	"html"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestVerifyForm(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	tmpl := template.Must(template.New("form").Funcs(cs.FormFuncs()).Parse(`<form>{{ csrfField .SessionID }}{{ signedHidden "plan" .Plan }}</form>`))
+
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, map[string]string{"SessionID": "s1", "Plan": `pro "yearly"`}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	inputs := regexp.MustCompile(`name="([^"]+)" value="([^"]+)"`).FindAllStringSubmatch(rendered.String(), -1)
+	if len(inputs) != 2 || inputs[0][1] != CSRFFieldName || inputs[1][1] != "plan" {
+		t.Fatalf("unexpected form: %s", rendered.String())
+	}
+	form := url.Values{}
+	for _, input := range inputs {
+		form.Set(input[1], html.UnescapeString(input[2]))
+	}
+	form.Set("email", "tobi@example.com")
+
+	post := func(form url.Values, sessionID string) (url.Values, error) {
+		request := httptest.NewRequest(http.MethodPost, "/checkout", strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return cs.VerifyForm(request, sessionID, "plan")
+	}
+
+	values, err := post(form, "s1")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	assertEqual(t, `pro "yearly"`, values.Get("plan"), nil)
+	assertEqual(t, "tobi@example.com", values.Get("email"), nil)
+
+	if _, err := post(form, "s2"); err != ErrInvalidCSRFToken {
+		t.Fatalf("expected error: %s, got: %v", ErrInvalidCSRFToken, err)
+	}
+
+	tampered := url.Values{CSRFFieldName: form[CSRFFieldName], "plan": {strings.Replace(form.Get("plan"), "pro", "max", 1)}}
+	if _, err := post(tampered, "s1"); err != ErrFormFieldTampered {
+		t.Fatalf("expected error: %s, got: %v", ErrFormFieldTampered, err)
+	}
+	// a value signed for another field is rejected
+	renamed := url.Values{CSRFFieldName: form[CSRFFieldName], "plan": form[CSRFFieldName]}
+	if _, err := post(renamed, "s1"); err != ErrFormFieldTampered {
+		t.Fatalf("expected error: %s, got: %v", ErrFormFieldTampered, err)
+	}
+	if _, err := post(url.Values{CSRFFieldName: form[CSRFFieldName]}, "s1"); err != ErrFormFieldTampered {
+		t.Fatalf("expected error: %s, got: %v", ErrFormFieldTampered, err)
+	}
+
+	first, _ := cs.SignCSRFToken("s1")
+	second, _ := cs.SignCSRFToken("s1")
+	if first == second {
+		t.Fatal("expected every CSRF token to be different")
+	}
+	if err := cs.VerifyCSRFToken(second, "s1"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+}
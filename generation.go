@@ -0,0 +1,35 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrStaleGeneration is returned when the session generation of a timed token isn't the current generation of its account,
+// e.g. after a logout everywhere
+var ErrStaleGeneration = errors.New("token session generation is stale")
+
+// GenerationFunc returns the current session generation of the account of the claims, e.g. a counter stored with the user
+type GenerationFunc func(ctx context.Context, claims Claims) (uint64, error)
+
+// WithGeneration requires the Generation claim of the timed token to equal the current generation of its account.
+// Bumping the counter of an account invalidates every token signed before at once, e.g. to log out everywhere
+func WithGeneration(current GenerationFunc) VerifyOption {
+	return func(o *verifyOptions) {
+		o.generation = current
+	}
+}
+
+func checkGeneration(ctx context.Context, claims Claims, opts verifyOptions) error {
+	if opts.generation == nil {
+		return nil
+	}
+	current, err := opts.generation(ctx, claims)
+	if err != nil {
+		return err
+	}
+	if claims.Generation != current {
+		return ErrStaleGeneration
+	}
+	return nil
+}
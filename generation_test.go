@@ -0,0 +1,40 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithGeneration(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	generations := map[string]uint64{"user-1": 3}
+	current := WithGeneration(func(_ context.Context, claims Claims) (uint64, error) {
+		generation, ok := generations[claims.Value]
+		if !ok {
+			return 0, errors.New("unknown user")
+		}
+		return generation, nil
+	})
+
+	signed, _ := cs.SignClaims(Claims{Value: "user-1", IssuedAt: time.Now().Unix(), Generation: 3})
+	claims, err := cs.UnsignClaims(signed, current)
+	if err != nil || claims.Generation != 3 {
+		t.Fatalf("unexpected claims: %+v, %v", claims, err)
+	}
+
+	// logging out everywhere bumps the generation
+	generations["user-1"]++
+	if _, err := cs.UnsignClaims(signed, current); err != ErrStaleGeneration {
+		t.Fatalf("expected error: %s, got: %v", ErrStaleGeneration, err)
+	}
+	if _, err := cs.UnsignClaims(signed); err != nil {
+		t.Fatalf("expected no error without the check, got: %s", err)
+	}
+
+	unknown, _ := cs.SignClaims(Claims{Value: "user-2"})
+	if _, err := cs.UnsignClaims(unknown, current); err == nil || err.Error() != "unknown user" {
+		t.Fatalf("expected error: unknown user, got: %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package cookiesignature
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+var errNoSessionMiddleware = errors.New("session middleware is not installed")
+
+type sessionKey struct{}
+
+type contextSession struct {
+	value interface{}
+	err   error
+}
+
+// SessionMiddleware verifies the named session cookie, signed by SignJSON, and attaches the session to the request context,
+// e.g. in front of a gqlgen handler, so resolvers read it with SessionFromContext.
+// newSession returns a pointer to the session type the cookie is unmarshaled into.
+// Requests without a valid session still reach the handler, the resolvers decide which fields need one
+func (cs CookieSignature) SessionMiddleware(name string, newSession func() interface{}) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session, err := cs.readSession(r, name, newSession)
+			ctx := context.WithValue(r.Context(), sessionKey{}, contextSession{value: session, err: err})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// SessionFromContext returns the session attached by SessionMiddleware, or the error of its verification,
+// http.ErrNoCookie if the request had no session cookie
+func SessionFromContext(ctx context.Context) (interface{}, error) {
+	session, ok := ctx.Value(sessionKey{}).(contextSession)
+	if !ok {
+		return nil, errNoSessionMiddleware
+	}
+	return session.value, session.err
+}
+
+// HasuraAuthWebhook is an authentication webhook of Hasura GraphQL Engine, in either GET or POST mode.
+// It verifies the named session cookie of the forwarded client headers, signed by SignJSON,
+// and responds with the session variables, e.g. X-Hasura-User-Id and X-Hasura-Role, returned by sessionVariables.
+// Requests without a valid session are denied with 401, unless sessionVariables returns variables for a nil session,
+// e.g. an anonymous role
+func (cs CookieSignature) HasuraAuthWebhook(name string, newSession func() interface{}, sessionVariables func(session interface{}) (map[string]string, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// in POST mode, the client headers are forwarded in the body instead of the request headers
+		if r.Method == http.MethodPost {
+			var body struct {
+				Headers map[string]string `json:"headers"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			forwarded := r.Clone(r.Context())
+			forwarded.Header = make(http.Header, len(body.Headers))
+			for key, value := range body.Headers {
+				forwarded.Header.Set(key, value)
+			}
+			r = forwarded
+		}
+
+		session, err := cs.readSession(r, name, newSession)
+		if err != nil {
+			session = nil
+		}
+		variables, err := sessionVariables(session)
+		if err != nil || variables == nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(variables)
+	})
+}
+
+func (cs CookieSignature) readSession(r *http.Request, name string, newSession func() interface{}) (interface{}, error) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return nil, err
+	}
+	session := newSession()
+	if err := cs.unsignJSON(r.Context(), cookie.Value, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
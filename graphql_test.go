@@ -0,0 +1,86 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testGraphQLSession struct {
+	UserID int    `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+func newTestGraphQLSession() interface{} {
+	return &testGraphQLSession{}
+}
+
+func TestSessionMiddleware(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	signed, _ := cs.SignJSON(testGraphQLSession{UserID: 42, Role: "user"})
+
+	var session interface{}
+	var sessionErr error
+	handler := cs.SessionMiddleware("session", newTestGraphQLSession)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, sessionErr = SessionFromContext(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	r.AddCookie(&http.Cookie{Name: "session", Value: signed})
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	if sessionErr != nil {
+		t.Fatalf("expected no error, got: %s", sessionErr)
+	}
+	if s, ok := session.(*testGraphQLSession); !ok || s.UserID != 42 {
+		t.Fatalf("unexpected session: %#v", session)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/graphql", nil))
+	if session != nil || sessionErr != http.ErrNoCookie {
+		t.Fatalf("expected error: %s, got: %v", http.ErrNoCookie, sessionErr)
+	}
+
+	if _, err := SessionFromContext(context.Background()); err != errNoSessionMiddleware {
+		t.Fatalf("expected error: %s, got: %v", errNoSessionMiddleware, err)
+	}
+}
+
+func TestHasuraAuthWebhook(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	signed, _ := cs.SignJSON(testGraphQLSession{UserID: 42, Role: "user"})
+
+	handler := cs.HasuraAuthWebhook("session", newTestGraphQLSession, func(session interface{}) (map[string]string, error) {
+		s, ok := session.(*testGraphQLSession)
+		if !ok {
+			return nil, errors.New("unauthenticated")
+		}
+		return map[string]string{"X-Hasura-User-Id": strconv.Itoa(s.UserID), "X-Hasura-Role": s.Role}, nil
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/auth", nil)
+	r.AddCookie(&http.Cookie{Name: "session", Value: signed})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.String() != `{"X-Hasura-Role":"user","X-Hasura-User-Id":"42"}`+"\n" {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+
+	body := `{"headers":{"cookie":"session=` + signed + `"},"request":{"query":"{ me { id } }"}}`
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth", strings.NewReader(body)))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"X-Hasura-User-Id":"42"`) {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/auth", nil)
+	r.AddCookie(&http.Cookie{Name: "session", Value: signed + "x"})
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status: %d, got: %d", http.StatusUnauthorized, w.Code)
+	}
+}
@@ -0,0 +1,64 @@
+package cookiesignature
+
+import (
+	"context"
+	"net/http"
+)
+
+// HealthChecker is implemented by the remote-backed providers and stores of this package.
+// Healthy verifies that the keys are available, so a readiness probe can keep a pod out of traffic it can't sign for
+type HealthChecker interface {
+	Healthy(ctx context.Context) error
+}
+
+// Healthy verifies that the provider returns a valid set of keys
+func (p KubernetesSecretProvider) Healthy(ctx context.Context) error {
+	return healthyKeys(p.Keys(ctx))
+}
+
+// Healthy verifies that the secret files hold a valid set of keys
+func (p DockerSecretProvider) Healthy(ctx context.Context) error {
+	return healthyKeys(p.Keys(ctx))
+}
+
+// Healthy verifies that the file holds a valid set of keys
+func (fs FileKeyStore) Healthy(ctx context.Context) error {
+	return healthyKeys(fs.Load(ctx))
+}
+
+// Healthy verifies that the backend holds a valid set of keys
+func (s *KVKeyStore) Healthy(ctx context.Context) error {
+	entry, err := s.Backend.Get(ctx, s.key())
+	if err != nil {
+		return err
+	}
+	if entry.Revision == 0 {
+		return errKeysNotFound
+	}
+	return healthyKeys(s.decodeKeys(entry.Value))
+}
+
+func healthyKeys(keys []Key, err error) error {
+	if err != nil {
+		return err
+	}
+	return validateKeys(keys)
+}
+
+// HealthHandler returns a readiness probe handler that responds 200 if every checker is healthy, 503 otherwise.
+// Probes are usually unauthenticated, so the response body stays generic and the errors are passed to onError,
+// e.g. to log them, if not nil
+func HealthHandler(onError func(err error), checkers ...HealthChecker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, checker := range checkers {
+			if err := checker.Healthy(r.Context()); err != nil {
+				if onError != nil {
+					onError(err)
+				}
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+}
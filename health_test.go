@@ -0,0 +1,60 @@
+package cookiesignature
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHealthHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cookiesignature")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileStore := FileKeyStore{Path: filepath.Join(dir, "keys.json")}
+	kvStore := &KVKeyStore{Backend: &testKVBackend{}}
+	var reported []error
+	handler := HealthHandler(func(err error) { reported = append(reported, err) }, fileStore, kvStore)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if recorder.Code != http.StatusServiceUnavailable || recorder.Body.String() != "Service Unavailable\n" {
+		t.Fatalf("expected a generic %d response, got: %d %s", http.StatusServiceUnavailable, recorder.Code, recorder.Body.String())
+	}
+	if len(reported) != 1 || !os.IsNotExist(reported[0]) {
+		t.Fatalf("expected the error to be reported, got: %v", reported)
+	}
+
+	keys := []Key{{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive}}
+	if err := fileStore.Save(context.Background(), keys); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if err := kvStore.Healthy(context.Background()); err != errKeysNotFound {
+		t.Fatalf("expected error: %s, got: %s", errKeysNotFound, err)
+	}
+	if err := kvStore.Save(context.Background(), []Key{{ID: "k0", Secret: []byte("tobiiscool"), State: KeyPending}}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if err := kvStore.Healthy(context.Background()); err != errNoActiveKey {
+		t.Fatalf("expected error: %s, got: %s", errNoActiveKey, err)
+	}
+	if err := kvStore.Save(context.Background(), keys); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "ok" {
+		t.Fatalf("expected status: %d, got: %d, %s", http.StatusOK, recorder.Code, recorder.Body.String())
+	}
+
+	if err := (DockerSecretProvider{Name: "cookie_secret", Dir: dir}).Healthy(context.Background()); err == nil {
+		t.Fatalf("expected a not found error")
+	}
+}
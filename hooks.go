@@ -0,0 +1,64 @@
+package cookiesignature
+
+import "context"
+
+// Operation is the operation reported to hooks
+type Operation int
+
+const (
+	// OperationSign is reported by Sign and SignContext
+	OperationSign Operation = iota
+	// OperationUnsign is reported by Unsign and UnsignContext
+	OperationUnsign
+)
+
+// String returns the name of the operation
+func (op Operation) String() string {
+	if op == OperationSign {
+		return "sign"
+	}
+	return "unsign"
+}
+
+// Event describes a completed sign or unsign operation
+type Event struct {
+	Operation Operation
+	// Err is the error of the operation, nil on success
+	Err error
+	// Correlation is the data attached to the context with ContextWithCorrelation, nil if none
+	Correlation interface{}
+}
+
+// Hook is called after every sign and unsign operation, e.g. to audit verification failures.
+// The context is the one passed to SignContext or UnsignContext
+type Hook func(ctx context.Context, event Event)
+
+// WithHook adds a hook called after every sign and unsign operation
+func WithHook(hook Hook) Option {
+	return func(o *options) {
+		o.hooks = append(o.hooks, hook)
+	}
+}
+
+type correlationKey struct{}
+
+// ContextWithCorrelation attaches opaque correlation data, e.g. a request ID or a user ID, to the context.
+// It is passed to the hooks of the operations run with the context, so failures can be tied back to requests
+func ContextWithCorrelation(ctx context.Context, data interface{}) context.Context {
+	return context.WithValue(ctx, correlationKey{}, data)
+}
+
+// CorrelationFromContext returns the correlation data attached to the context, nil if none
+func CorrelationFromContext(ctx context.Context) interface{} {
+	return ctx.Value(correlationKey{})
+}
+
+func (cs CookieSignature) runHooks(ctx context.Context, op Operation, err error) {
+	if len(cs.opts.hooks) == 0 {
+		return
+	}
+	event := Event{Operation: op, Err: err, Correlation: CorrelationFromContext(ctx)}
+	for _, hook := range cs.opts.hooks {
+		hook(ctx, event)
+	}
+}
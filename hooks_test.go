@@ -0,0 +1,54 @@
+package cookiesignature
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHooks(t *testing.T) {
+	var events []Event
+	var contexts []context.Context
+	cs, err := NewCookieSignature([]string{"tobiiscool"}, WithHook(func(ctx context.Context, event Event) {
+		events = append(events, event)
+		contexts = append(contexts, ctx)
+	}))
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	ctx := ContextWithCorrelation(context.Background(), "request-42")
+	signed, err := cs.SignContext(ctx, "hello")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if _, err := cs.UnsignContext(ctx, signed+"x"); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+	if _, err := cs.Unsign(signed); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got: %d", len(events))
+	}
+	if events[0].Operation != OperationSign || events[0].Err != nil || events[0].Correlation != "request-42" || contexts[0] != ctx {
+		t.Fatalf("unexpected event: %+v", events[0])
+	}
+	if events[1].Operation != OperationUnsign || events[1].Err != errInvalidSignature || events[1].Correlation != "request-42" {
+		t.Fatalf("unexpected event: %+v", events[1])
+	}
+	if events[2].Err != nil || events[2].Correlation != nil {
+		t.Fatalf("unexpected event: %+v", events[2])
+	}
+
+	// the context of timed tokens reaches the hooks too
+	events = nil
+	token, _ := cs.SignTimed("hello", time.Hour)
+	if _, err := cs.UnsignClaimsContext(ctx, token); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(events) != 2 || events[1].Operation != OperationUnsign || events[1].Correlation != "request-42" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+}
@@ -0,0 +1,86 @@
+package cookiesignature
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownHost is returned when no signer matches the host
+var ErrUnknownHost = errors.New("no signer for the host")
+
+// HostResolver selects the signer of a request by its host, so a multi-brand platform serving many domains
+// from one binary signs the cookies of each brand with its own keys
+type HostResolver struct {
+	exact     map[string]*CookieSignature
+	wildcards []hostWildcard
+	fallback  *CookieSignature
+}
+
+type hostWildcard struct {
+	// suffix of the matched hosts, including the leading dot
+	suffix string
+	signer *CookieSignature
+}
+
+// NewHostResolver creates a new HostResolver from the signers by host pattern. A pattern is either a host, e.g. "acme.com",
+// a wildcard matching every subdomain of a domain at any depth, e.g. "*.acme.com", or "*" matching any other host.
+// Hosts match exact patterns first, then the wildcard of the longest domain, then "*"
+func NewHostResolver(signers map[string]*CookieSignature) (*HostResolver, error) {
+	hr := &HostResolver{exact: make(map[string]*CookieSignature)}
+	for pattern, signer := range signers {
+		if signer == nil {
+			return nil, fmt.Errorf("signer of host pattern %s must not be nil", pattern)
+		}
+		host := normalizeHost(pattern)
+		switch {
+		case pattern == "*":
+			hr.fallback = signer
+		case strings.HasPrefix(host, "*."):
+			if len(host) == 2 || strings.Contains(host[2:], "*") {
+				return nil, fmt.Errorf("invalid host pattern: %s", pattern)
+			}
+			hr.wildcards = append(hr.wildcards, hostWildcard{suffix: host[1:], signer: signer})
+		case host == "" || strings.Contains(host, "*") || strings.HasSuffix(host, "."):
+			return nil, fmt.Errorf("invalid host pattern: %s", pattern)
+		default:
+			hr.exact[host] = signer
+		}
+	}
+	sort.Slice(hr.wildcards, func(i, j int) bool {
+		return len(hr.wildcards[i].suffix) > len(hr.wildcards[j].suffix)
+	})
+	return hr, nil
+}
+
+// Resolve returns the signer of the host, which may include a port
+func (hr *HostResolver) Resolve(host string) (*CookieSignature, error) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = normalizeHost(host)
+	if signer, ok := hr.exact[host]; ok {
+		return signer, nil
+	}
+	for _, wildcard := range hr.wildcards {
+		if strings.HasSuffix(host, wildcard.suffix) {
+			return wildcard.signer, nil
+		}
+	}
+	if hr.fallback != nil {
+		return hr.fallback, nil
+	}
+	return nil, ErrUnknownHost
+}
+
+// ResolveRequest returns the signer of the host of the request
+func (hr *HostResolver) ResolveRequest(r *http.Request) (*CookieSignature, error) {
+	return hr.Resolve(r.Host)
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
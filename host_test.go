@@ -0,0 +1,63 @@
+package cookiesignature
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHostResolver(t *testing.T) {
+	acme, _ := NewCookieSignature([]string{"acme"})
+	acmeShop, _ := NewCookieSignature([]string{"acme-shop"})
+	acmeEU, _ := NewCookieSignature([]string{"acme-eu"})
+	globex, _ := NewCookieSignature([]string{"globex"})
+
+	if _, err := NewHostResolver(map[string]*CookieSignature{"acme.com": nil}); err == nil {
+		t.Fatalf("expected an error for a nil signer")
+	}
+	for _, pattern := range []string{"", "*.", "a.*.com", "acme*.com"} {
+		if _, err := NewHostResolver(map[string]*CookieSignature{pattern: acme}); err == nil {
+			t.Fatalf("expected an error for the pattern %q", pattern)
+		}
+	}
+
+	hr, err := NewHostResolver(map[string]*CookieSignature{
+		"acme.com":      acme,
+		"*.acme.com":    acme,
+		"shop.acme.com": acmeShop,
+		"*.eu.acme.com": acmeEU,
+		"Globex.com.":   globex,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	for host, expected := range map[string]*CookieSignature{
+		"acme.com":         acme,
+		"www.acme.com":     acme,
+		"shop.acme.com":    acmeShop,
+		"SHOP.acme.com.":   acmeShop,
+		"a.shop.acme.com":  acme,
+		"fr.eu.acme.com":   acmeEU,
+		"eu.acme.com":      acme,
+		"globex.com:8443":  globex,
+		"[::1]:8443":       nil,
+		"notacme.com":      nil,
+		"acme.com.evil.io": nil,
+	} {
+		signer, err := hr.Resolve(host)
+		if expected == nil {
+			if err != ErrUnknownHost {
+				t.Fatalf("expected error: %s for %s, got: %v", ErrUnknownHost, host, err)
+			}
+			continue
+		}
+		if err != nil || signer != expected {
+			t.Fatalf("unexpected signer of %s: %v", host, err)
+		}
+	}
+
+	hr, _ = NewHostResolver(map[string]*CookieSignature{"acme.com": acme, "*": globex})
+	r := httptest.NewRequest("GET", "http://initech.com/", nil)
+	if signer, err := hr.ResolveRequest(r); err != nil || signer != globex {
+		t.Fatalf("expected the fallback signer, got: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package cookiesignature
+
+import (
+	"net/http"
+	"time"
+)
+
+// CookieUnchanged reports whether writing the next signed value over the current one changes nothing but re-sign jitter.
+// Identical values are unchanged. Timed tokens are unchanged when their claims only differ by times within the tolerance,
+// and the current token is valid and signed with the newest secret, so tokens signed with an older secret are still replaced
+func (cs CookieSignature) CookieUnchanged(current string, next string, tolerance time.Duration) bool {
+	if current == next {
+		return true
+	}
+	if tolerance <= 0 || current == "" {
+		return false
+	}
+
+	currentClaims, err := cs.UnsignClaims(current)
+	if err != nil {
+		return false
+	}
+	// the current token only stays if signing its claims again reproduces it
+	if resigned, err := cs.SignClaims(currentClaims); err != nil || resigned != current {
+		return false
+	}
+	nextClaims, err := cs.UnsignClaims(next)
+	if err != nil {
+		return false
+	}
+	return claimsWithinTolerance(currentClaims, nextClaims, tolerance)
+}
+
+// SetCookieIfChanged sets the cookie on the response unless the request carries a cookie of the same name
+// that CookieUnchanged considers equivalent, avoiding Set-Cookie headers that bloat responses and bust caches.
+// The expiration of a skipped cookie isn't refreshed in the browser, keep the tolerance small next to the lifetime.
+// It returns whether the cookie was set
+func (cs CookieSignature) SetCookieIfChanged(w http.ResponseWriter, r *http.Request, cookie *http.Cookie, tolerance time.Duration) bool {
+	if current, err := r.Cookie(cookie.Name); err == nil && cs.CookieUnchanged(current.Value, cookie.Value, tolerance) {
+		return false
+	}
+	http.SetCookie(w, cookie)
+	return true
+}
+
+// claimsWithinTolerance reports whether the claims are equal, except for times that differ by at most the tolerance
+func claimsWithinTolerance(a Claims, b Claims, tolerance time.Duration) bool {
+	if !timeWithinTolerance(a.IssuedAt, b.IssuedAt, tolerance) ||
+		!timeWithinTolerance(a.NotBefore, b.NotBefore, tolerance) ||
+		!timeWithinTolerance(a.ExpiresAt, b.ExpiresAt, tolerance) {
+		return false
+	}
+	if a.ID != b.ID || a.Value != b.Value || a.Device != b.Device || a.AuthLevel != b.AuthLevel || a.Generation != b.Generation ||
+		len(a.AuthMethods) != len(b.AuthMethods) {
+		return false
+	}
+	for i := range a.AuthMethods {
+		if a.AuthMethods[i] != b.AuthMethods[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func timeWithinTolerance(a int64, b int64, tolerance time.Duration) bool {
+	if a == 0 || b == 0 {
+		return a == b
+	}
+	diff := time.Unix(a, 0).Sub(time.Unix(b, 0))
+	return diff <= tolerance && -diff <= tolerance
+}
@@ -0,0 +1,58 @@
+package cookiesignature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCookieUnchanged(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return start }
+	defer func() { timeNow = time.Now }()
+
+	cs, _ := NewCookieSignature([]string{"n3wsecr3t", "tobiiscool"})
+	old, _ := NewCookieSignature([]string{"tobiiscool"})
+	current, _ := cs.SignTimed("hello", time.Hour)
+	rotated, _ := old.SignTimed("hello", time.Hour)
+
+	timeNow = func() time.Time { return start.Add(time.Minute) }
+	next, _ := cs.SignTimed("hello", time.Hour)
+	other, _ := cs.SignTimed("world", time.Hour)
+
+	if !cs.CookieUnchanged(current, current, 0) {
+		t.Fatal("expected identical values to be unchanged")
+	}
+	if !cs.CookieUnchanged(current, next, 5*time.Minute) {
+		t.Fatal("expected the jitter within the tolerance to be ignored")
+	}
+	if cs.CookieUnchanged(current, next, 30*time.Second) {
+		t.Fatal("expected the jitter beyond the tolerance to change the cookie")
+	}
+	if cs.CookieUnchanged(current, other, 5*time.Minute) {
+		t.Fatal("expected a different value to change the cookie")
+	}
+	if cs.CookieUnchanged(rotated, next, 5*time.Minute) {
+		t.Fatal("expected a token signed with an older secret to be replaced")
+	}
+	if cs.CookieUnchanged("hello.invalid", next, 5*time.Minute) {
+		t.Fatal("expected an invalid token to be replaced")
+	}
+	if cs.CookieUnchanged(cs.MustSign("hello"), cs.MustSign("world"), 5*time.Minute) {
+		t.Fatal("expected different values to change the cookie")
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(&http.Cookie{Name: "session", Value: current})
+	recorder := httptest.NewRecorder()
+	if cs.SetCookieIfChanged(recorder, request, &http.Cookie{Name: "session", Value: next}, 5*time.Minute) {
+		t.Fatal("expected the cookie to be skipped")
+	}
+	if !cs.SetCookieIfChanged(recorder, request, &http.Cookie{Name: "other", Value: next}, 5*time.Minute) {
+		t.Fatal("expected the cookie to be set")
+	}
+	if cookies := recorder.Result().Cookies(); len(cookies) != 1 || cookies[0].Name != "other" {
+		t.Fatalf("unexpected cookies: %v", cookies)
+	}
+}
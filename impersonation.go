@@ -0,0 +1,137 @@
+package cookiesignature
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const impersonationPurpose = "impersonation"
+
+type impersonationKey struct{}
+
+// Impersonation records that an actor, e.g. a support agent, acts as another user, the subject
+type Impersonation struct {
+	Actor   string
+	Subject string
+	// Reason is why the actor impersonates the subject, e.g. a ticket number, kept for the audit trail
+	Reason    string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type impersonationClaims struct {
+	Actor     string `json:"act"`
+	Subject   string `json:"sub"`
+	Reason    string `json:"rsn"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// SignImpersonation signs an impersonation of the subject by the actor, valid for ttl, layered over the session cookie of the actor.
+// The MAC covers the signature of the session, so the impersonation ends as soon as the actor logs out or the session is renewed
+func (cs CookieSignature) SignImpersonation(session string, actor string, subject string, reason string, ttl time.Duration) (string, error) {
+	if actor == "" || subject == "" || reason == "" {
+		return "", errors.New("impersonation actor, subject and reason must not be empty")
+	}
+	if ttl <= 0 {
+		return "", errors.New("impersonation ttl must be positive")
+	}
+	sessionID, err := SignatureID(session)
+	if err != nil {
+		return "", err
+	}
+
+	now := timeNow()
+	claims, err := json.Marshal(impersonationClaims{Actor: actor, Subject: subject, Reason: reason, IssuedAt: now.Unix(), ExpiresAt: now.Add(ttl).Unix()})
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+	hashBytes, err := cs.signingMAC(encodeValues([]string{impersonationPurpose, payload, sessionID}))
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + hashBase64(hashBytes), nil
+}
+
+// UnsignImpersonation verifies the impersonation signed by SignImpersonation over the session cookie of the actor
+// and checks that it didn't expire
+func (cs CookieSignature) UnsignImpersonation(session string, input string) (Impersonation, error) {
+	sessionID, err := SignatureID(session)
+	if err != nil {
+		return Impersonation{}, err
+	}
+	index := strings.LastIndex(input, ".")
+	if index < 0 {
+		return Impersonation{}, errInvalidSignature
+	}
+	payload := input[:index]
+	if _, err := cs.unsign(encodeValues([]string{impersonationPurpose, payload, sessionID}) + input[index:]); err != nil {
+		return Impersonation{}, err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return Impersonation{}, errInvalidSignature
+	}
+	var claims impersonationClaims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return Impersonation{}, errInvalidSignature
+	}
+	if err := cs.validateClaims(Claims{IssuedAt: claims.IssuedAt, ExpiresAt: claims.ExpiresAt}, verifyOptions{}); err != nil {
+		return Impersonation{}, err
+	}
+	return Impersonation{
+		Actor:     claims.Actor,
+		Subject:   claims.Subject,
+		Reason:    claims.Reason,
+		IssuedAt:  time.Unix(claims.IssuedAt, 0),
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}, nil
+}
+
+// ImpersonationMiddleware verifies the impersonation cookie of the template over the named session cookie,
+// attaches the impersonation to the request context, read with ImpersonationFromContext, and calls audit with it
+// before every impersonated request. An invalid or expired impersonation cookie is deleted,
+// and the request continues as the actor
+func (cs CookieSignature) ImpersonationMiddleware(sessionCookie string, template http.Cookie, audit func(r *http.Request, impersonation Impersonation)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cookie, err := r.Cookie(template.Name)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var impersonation Impersonation
+			session, err := r.Cookie(sessionCookie)
+			if err == nil {
+				impersonation, err = cs.UnsignImpersonation(session.Value, cookie.Value)
+			}
+			if err != nil {
+				deleted := template
+				deleted.Value = ""
+				deleted.MaxAge = -1
+				http.SetCookie(w, &deleted)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if audit != nil {
+				audit(r, impersonation)
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), impersonationKey{}, impersonation)))
+		})
+	}
+}
+
+// ImpersonationFromContext returns the impersonation verified by ImpersonationMiddleware.
+// Handlers act as the subject, and record the actor in their own audit trail
+func ImpersonationFromContext(ctx context.Context) (Impersonation, bool) {
+	impersonation, ok := ctx.Value(impersonationKey{}).(Impersonation)
+	return impersonation, ok
+}
@@ -0,0 +1,77 @@
+package cookiesignature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestImpersonation(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return start }
+	defer func() { timeNow = time.Now }()
+
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	session := cs.MustSign("agent-7")
+	signed, err := cs.SignImpersonation(session, "agent-7", "user-42", "TICKET-123", 15*time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	impersonation, err := cs.UnsignImpersonation(session, signed)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if impersonation.Actor != "agent-7" || impersonation.Subject != "user-42" || impersonation.Reason != "TICKET-123" || !impersonation.ExpiresAt.Equal(start.Add(15*time.Minute)) {
+		t.Fatalf("unexpected impersonation: %+v", impersonation)
+	}
+
+	// the impersonation ends with the session of the actor
+	if _, err := cs.UnsignImpersonation(cs.MustSign("agent-8"), signed); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	if _, err := cs.SignImpersonation(session, "agent-7", "user-42", "", time.Minute); err == nil {
+		t.Fatal("expected an error without a reason")
+	}
+
+	var audited []Impersonation
+	handler := cs.ImpersonationMiddleware("session", http.Cookie{Name: "impersonate", Path: "/"}, func(r *http.Request, impersonation Impersonation) {
+		audited = append(audited, impersonation)
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if impersonation, ok := ImpersonationFromContext(r.Context()); ok {
+			w.Write([]byte(impersonation.Actor + " as " + impersonation.Subject))
+		}
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(&http.Cookie{Name: "session", Value: session})
+	request.AddCookie(&http.Cookie{Name: "impersonate", Value: signed})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Body.String() != "agent-7 as user-42" || len(audited) != 1 {
+		t.Fatalf("unexpected response: %s, audited: %v", recorder.Body, audited)
+	}
+
+	timeNow = func() time.Time { return start.Add(time.Hour) }
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	cookies := recorder.Result().Cookies()
+	if recorder.Body.String() != "" || len(audited) != 1 || len(cookies) != 1 || cookies[0].MaxAge != -1 {
+		t.Fatalf("expected the expired impersonation to be deleted, got: %s %v", recorder.Body, cookies)
+	}
+}
+
+func TestImpersonationTaggedSession(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"}, WithAlgorithmTag())
+	session := cs.MustSign("agent-7")
+	signed, err := cs.SignImpersonation(session, "agent-7", "user-42", "TICKET-123", 15*time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	impersonation, err := cs.UnsignImpersonation(session, signed)
+	assertEqual(t, "user-42", impersonation.Subject, err)
+	if _, err := cs.UnsignImpersonation(cs.MustSign("agent-8"), signed); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+}
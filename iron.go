@@ -0,0 +1,279 @@
+package cookiesignature
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	ironMacPrefix          = "Fe26.2"
+	ironSaltBytes          = 32
+	ironKeyBytes           = 32
+	ironIVBytes            = aes.BlockSize
+	ironMinPasswordLength  = 32
+	ironTimestampSkew      = 60 * time.Second
+	ironSessionVersion     = "2"
+	ironSessionDelimiter   = "~"
+	ironDefaultPasswordKey = "default"
+)
+
+var (
+	// ErrSealExpired is returned when unsealing an iron seal whose expiration has passed
+	ErrSealExpired = errors.New("expired seal")
+
+	errIronPasswordTooShort = fmt.Errorf("password must be at least %d characters", ironMinPasswordLength)
+	errIronInvalidSeal      = errors.New("incorrect number of sealed components")
+	errIronWrongPrefix      = errors.New("wrong mac prefix")
+	errIronInvalidExp       = errors.New("invalid expiration")
+	errIronBadHMAC          = errors.New("bad hmac value")
+	errIronInvalidPadding   = errors.New("invalid padding")
+
+	ironPasswordIDPattern = regexp.MustCompile(`^\w*$`)
+)
+
+// IronPassword is a password with an identifier, allowing passwords to be rotated.
+// The ID is embedded in the seal so Unseal can pick the matching password
+type IronPassword struct {
+	ID     string
+	Secret string
+}
+
+// IronSeal serializes the input value to JSON and seals it in the same way as [@hapi/iron].
+// If ttl is positive, the seal expires after ttl.
+//
+// [@hapi/iron]: https://github.com/hapijs/iron
+func IronSeal(value interface{}, password IronPassword, ttl time.Duration) (string, error) {
+	if len(password.Secret) < ironMinPasswordLength {
+		return "", errIronPasswordTooShort
+	}
+	if !ironPasswordIDPattern.MatchString(password.ID) {
+		return "", errors.New("invalid password id")
+	}
+
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	encryptionSalt, err := ironRandomSalt()
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, ironIVBytes)
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	encrypted, err := ironEncrypt(ironDeriveKey(password.Secret, encryptionSalt), iv, plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	expiration := ""
+	if ttl > 0 {
+		expiration = strconv.FormatInt(unixMilli(timeNow().Add(ttl)), 10)
+	}
+
+	macBaseString := strings.Join([]string{
+		ironMacPrefix,
+		password.ID,
+		encryptionSalt,
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(encrypted),
+		expiration,
+	}, "*")
+
+	hmacSalt, err := ironRandomSalt()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s*%s*%s", macBaseString, hmacSalt, ironHMAC(password.Secret, hmacSalt, macBaseString)), nil
+}
+
+// IronUnseal verifies and decrypts a seal created by [@hapi/iron] and unmarshals its JSON content into value.
+// The passwords map is keyed by password ID. Seals without a password ID are opened with the "default" entry.
+//
+// [@hapi/iron]: https://github.com/hapijs/iron
+func IronUnseal(sealed string, passwords map[string]string, value interface{}) error {
+	parts := strings.Split(sealed, "*")
+	if len(parts) != 8 {
+		return errIronInvalidSeal
+	}
+
+	if parts[0] != ironMacPrefix {
+		return errIronWrongPrefix
+	}
+
+	if expiration := parts[5]; expiration != "" {
+		exp, err := strconv.ParseInt(expiration, 10, 64)
+		if err != nil || exp < 0 {
+			return errIronInvalidExp
+		}
+		if exp <= unixMilli(timeNow().Add(-ironTimestampSkew)) {
+			return ErrSealExpired
+		}
+	}
+
+	passwordID := parts[1]
+	if passwordID == "" {
+		passwordID = ironDefaultPasswordKey
+	}
+	password, ok := passwords[passwordID]
+	if !ok {
+		return fmt.Errorf("cannot find password: %s", parts[1])
+	}
+	if len(password) < ironMinPasswordLength {
+		return errIronPasswordTooShort
+	}
+
+	macBaseString := strings.Join(parts[:6], "*")
+	if !EqualString(ironHMAC(password, parts[6], macBaseString), parts[7]) {
+		return errIronBadHMAC
+	}
+
+	iv, err := base64.RawURLEncoding.DecodeString(parts[3])
+	if err != nil {
+		return err
+	}
+	encrypted, err := base64.RawURLEncoding.DecodeString(parts[4])
+	if err != nil {
+		return err
+	}
+	plaintext, err := ironDecrypt(ironDeriveKey(password, parts[2]), iv, encrypted)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(plaintext, value)
+}
+
+// SealIronSession seals the session value into a cookie compatible with [iron-session] v8.
+// The password with the highest numeric ID is used to seal.
+//
+// [iron-session]: https://github.com/vvo/iron-session
+func SealIronSession(value interface{}, passwords map[string]string, ttl time.Duration) (string, error) {
+	if len(passwords) == 0 {
+		return "", errors.New("password must be provided")
+	}
+
+	latestID := -1
+	for id := range passwords {
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			return "", fmt.Errorf("password id must be numeric, got: %s", id)
+		}
+		if n > latestID {
+			latestID = n
+		}
+	}
+
+	id := strconv.Itoa(latestID)
+	sealed, err := IronSeal(value, IronPassword{ID: id, Secret: passwords[id]}, ttl)
+	if err != nil {
+		return "", err
+	}
+	return sealed + ironSessionDelimiter + ironSessionVersion, nil
+}
+
+// UnsealIronSession opens a cookie sealed by [iron-session] and unmarshals the session into value.
+// Seals created by iron-session versions before v6 store the session in the "persistent" field, which is unwrapped transparently.
+//
+// [iron-session]: https://github.com/vvo/iron-session
+func UnsealIronSession(sealed string, passwords map[string]string, value interface{}) error {
+	seal, version := sealed, ""
+	if i := strings.LastIndex(sealed, ironSessionDelimiter); i >= 0 {
+		seal, version = sealed[:i], sealed[i+1:]
+	}
+
+	if version == ironSessionVersion {
+		return IronUnseal(seal, passwords, value)
+	}
+
+	var legacy struct {
+		Persistent json.RawMessage `json:"persistent"`
+	}
+	if err := IronUnseal(seal, passwords, &legacy); err != nil {
+		return err
+	}
+	if len(legacy.Persistent) == 0 {
+		return nil
+	}
+	return json.Unmarshal(legacy.Persistent, value)
+}
+
+// iron derives keys from the hex encoded salt string rather than from the raw salt bytes
+func ironDeriveKey(password string, salt string) []byte {
+	return pbkdf2.Key([]byte(password), []byte(salt), 1, ironKeyBytes, sha1.New)
+}
+
+func ironHMAC(password string, salt string, input string) string {
+	mac := hmac.New(sha256.New, ironDeriveKey(password, salt))
+	_, _ = mac.Write([]byte(input))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func ironRandomSalt() (string, error) {
+	salt := make([]byte, ironSaltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(salt), nil
+}
+
+func ironEncrypt(key []byte, iv []byte, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	padded := append(plaintext, bytes.Repeat([]byte{byte(padding)}, padding)...)
+	encrypted := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, padded)
+	return encrypted, nil
+}
+
+func ironDecrypt(key []byte, iv []byte, encrypted []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize || len(encrypted) == 0 || len(encrypted)%aes.BlockSize != 0 {
+		return nil, errIronInvalidPadding
+	}
+
+	decrypted := make([]byte, len(encrypted))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, encrypted)
+
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, errIronInvalidPadding
+	}
+	for _, b := range decrypted[len(decrypted)-padding:] {
+		if int(b) != padding {
+			return nil, errIronInvalidPadding
+		}
+	}
+	return decrypted[:len(decrypted)-padding], nil
+}
+
+// unixMilli is time.UnixMilli, which is not available in go 1.16
+func unixMilli(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,93 @@
+package cookiesignature
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const ironTestPassword = "some_not_random_password_that_is_also_long_enough"
+
+func TestIronUnseal(t *testing.T) {
+	// sealed by @hapi/iron with the default options
+	ticket := "Fe26.2**0cdd607945dd1dffb7da0b0bf5f1a7daa6218cbae14cac51dcbd91fb077aeb5b*aOZLCKLhCt0D5IU1qLTtYw*g0ilNDlQ3TsdFUqJCqAm9iL7Wa60H7eYcHL_5oP136TOJREkS3BzheDC1dlxz5oJ**05b8943049af490e913bbc3a2485bee2aaf7b823f4c41d0ff0b7c168371a3772*R8yscVdTBRMdsoVbdDiFmUL8zb-c3PQLGJn4Y8C-AqI"
+
+	var result map[string]interface{}
+	if err := IronUnseal(ticket, map[string]string{"default": ironTestPassword}, &result); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	expected := map[string]interface{}{
+		"a": float64(1),
+		"b": float64(2),
+		"c": []interface{}{float64(3), float64(4), float64(5)},
+		"d": map[string]interface{}{"e": "f"},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Fatalf("expected: %v, got: %v", expected, result)
+	}
+
+	if err := IronUnseal(ticket, map[string]string{"default": ironTestPassword + "x"}, &result); err != errIronBadHMAC {
+		t.Fatalf("expected error: %s, got: %s", errIronBadHMAC, err)
+	}
+	if err := IronUnseal("Fe26.2**a*b*c*d*e", nil, &result); err != errIronInvalidSeal {
+		t.Fatalf("expected error: %s, got: %s", errIronInvalidSeal, err)
+	}
+}
+
+func TestIronSeal(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+
+	if _, err := IronSeal("hello", IronPassword{Secret: "short"}, 0); err != errIronPasswordTooShort {
+		t.Fatalf("expected error: %s, got: %s", errIronPasswordTooShort, err)
+	}
+
+	sealed, err := IronSeal(map[string]string{"user": "tobi"}, IronPassword{ID: "2", Secret: ironTestPassword}, time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	var result map[string]string
+	if err := IronUnseal(sealed, map[string]string{"1": "x", "2": ironTestPassword}, &result); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	assertEqual(t, "tobi", result["user"], nil)
+
+	if err := IronUnseal(sealed, map[string]string{"1": ironTestPassword}, &result); err == nil || err.Error() != "cannot find password: 2" {
+		t.Fatalf("expected error: cannot find password: 2, got: %s", err)
+	}
+
+	timeNow = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if err := IronUnseal(sealed, map[string]string{"2": ironTestPassword}, &result); err != ErrSealExpired {
+		t.Fatalf("expected error: %s, got: %s", ErrSealExpired, err)
+	}
+}
+
+func TestIronSession(t *testing.T) {
+	passwords := map[string]string{"1": ironTestPassword, "2": ironTestPassword + "_rotated"}
+	sealed, err := SealIronSession(map[string]int{"userId": 42}, passwords, 14*24*time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	var session struct {
+		UserID int `json:"userId"`
+	}
+	if err := UnsealIronSession(sealed, passwords, &session); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if session.UserID != 42 {
+		t.Fatalf("expected: 42, got: %d", session.UserID)
+	}
+
+	// legacy seals store the session in the persistent field
+	legacy, err := IronSeal(map[string]interface{}{"persistent": map[string]int{"userId": 7}}, IronPassword{ID: "1", Secret: ironTestPassword}, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if err := UnsealIronSession(legacy, passwords, &session); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if session.UserID != 7 {
+		t.Fatalf("expected: 7, got: %d", session.UserID)
+	}
+}
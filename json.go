@@ -0,0 +1,159 @@
+package cookiesignature
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+const (
+	fieldTagName      = "cookiesignature"
+	fieldTagEncrypted = "encrypted"
+)
+
+// SignJSON serializes the value to JSON and signs it, in the canonical form of RFC 8785 if WithCanonicalJSON is set.
+// The JSON payload stays inspectable, except for top-level struct fields tagged with `cookiesignature:"encrypted"`,
+// whose values are encrypted with Encrypt, so PII can be protected without encrypting the whole value
+func (cs CookieSignature) SignJSON(value interface{}) (string, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	fields := encryptedJSONFields(reflect.TypeOf(value))
+	if len(fields) > 0 {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(payload, &object); err != nil {
+			return "", err
+		}
+		for _, name := range fields {
+			fieldValue, ok := object[name]
+			if !ok {
+				continue
+			}
+			encrypted, err := cs.Encrypt(fieldValue)
+			if err != nil {
+				return "", err
+			}
+			if object[name], err = json.Marshal(encrypted); err != nil {
+				return "", err
+			}
+		}
+		if payload, err = json.Marshal(object); err != nil {
+			return "", err
+		}
+	}
+	if cs.opts.canonicalJSON {
+		if payload, err = canonicalizeJSON(payload); err != nil {
+			return "", err
+		}
+	}
+
+	return cs.SignBase64(string(payload))
+}
+
+// UnsignJSON verifies the value signed by SignJSON, decrypts the encrypted fields and unmarshals the result into value
+func (cs CookieSignature) UnsignJSON(input string, value interface{}) error {
+	return cs.unsignJSON(context.Background(), input, value)
+}
+
+func (cs CookieSignature) unsignJSON(ctx context.Context, input string, value interface{}) error {
+	payload, err := cs.unsignBase64(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	fields := encryptedJSONFields(reflect.TypeOf(value))
+	if len(fields) > 0 {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(payload, &object); err != nil {
+			return err
+		}
+		for _, name := range fields {
+			fieldValue, ok := object[name]
+			if !ok {
+				continue
+			}
+			var encrypted string
+			if err := json.Unmarshal(fieldValue, &encrypted); err != nil {
+				return err
+			}
+			if object[name], err = cs.Decrypt(encrypted); err != nil {
+				return err
+			}
+		}
+		if payload, err = json.Marshal(object); err != nil {
+			return err
+		}
+	}
+
+	return json.Unmarshal(payload, value)
+}
+
+// signPurposeJSON signs the JSON of the value with the purpose in the MAC, so the tokens of a purpose
+// can't be used for another one nor pass for values signed by SignJSON
+func (cs CookieSignature) signPurposeJSON(purpose string, value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	hashBytes, err := cs.signingMAC(encodeValues([]string{purpose, payload}))
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + encodeBase64(cs.opts.codec(), hashBytes), nil
+}
+
+// unsignPurposeJSON verifies the token signed by signPurposeJSON for the purpose and deserializes its JSON into the value
+func (cs CookieSignature) unsignPurposeJSON(purpose string, input string, value interface{}) error {
+	index := strings.LastIndex(input, ".")
+	if index < 0 {
+		return errInvalidSignature
+	}
+	payload := input[:index]
+	if _, err := cs.unsign(encodeValues([]string{purpose, payload}) + input[index:]); err != nil {
+		return err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return errInvalidSignature
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return errInvalidSignature
+	}
+	return nil
+}
+
+// encryptedJSONFields returns the JSON names of the struct fields tagged with `cookiesignature:"encrypted"`
+func encryptedJSONFields(rt reflect.Type) []string {
+	for rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []string
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" || field.Tag.Get(fieldTagName) != fieldTagEncrypted {
+			continue
+		}
+
+		name := field.Name
+		if jsonTag := field.Tag.Get("json"); jsonTag != "" {
+			jsonName := strings.Split(jsonTag, ",")[0]
+			if jsonName == "-" {
+				continue
+			}
+			if jsonName != "" {
+				name = jsonName
+			}
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
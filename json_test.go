@@ -0,0 +1,54 @@
+package cookiesignature
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+type testJSONPayload struct {
+	UserID int    `json:"user_id"`
+	Email  string `json:"email" cookiesignature:"encrypted"`
+	Phone  string `json:"phone,omitempty" cookiesignature:"encrypted"`
+}
+
+func TestSignJSON(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	signed, err := cs.SignJSON(testJSONPayload{UserID: 42, Email: "tobi@example.com"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(signed[:strings.LastIndex(signed, ".")])
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if !strings.Contains(string(payload), `"user_id":42`) || strings.Contains(string(payload), "tobi@example.com") {
+		t.Fatalf("expected the email to be encrypted, got: %s", payload)
+	}
+
+	var result testJSONPayload
+	if err := cs.UnsignJSON(signed, &result); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if result.UserID != 42 || result.Email != "tobi@example.com" || result.Phone != "" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	plain, err := cs.SignJSON(map[string]int{"user_id": 42})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	var plainResult map[string]int
+	if err := cs.UnsignJSON(plain, &plainResult); err != nil || plainResult["user_id"] != 42 {
+		t.Fatalf("unexpected result: %v, error: %s", plainResult, err)
+	}
+
+	if err := cs.UnsignJSON(plain+"x", &plainResult); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+}
@@ -0,0 +1,119 @@
+package cookiesignature
+
+import (
+	"fmt"
+	"time"
+)
+
+// keyEventBuffer is the capacity of the channels returned by Events
+const keyEventBuffer = 64
+
+// KeyEventType is the type of change of a KeyEvent
+type KeyEventType int
+
+const (
+	// KeyAdded is emitted when a key is added to the key ring
+	KeyAdded KeyEventType = iota
+	// KeyPromoted is emitted when a key becomes the active key
+	KeyPromoted
+	// KeyDemoted is emitted when a key becomes verify-only
+	KeyDemoted
+	// KeyRetiredEvent is emitted when a key is retired
+	KeyRetiredEvent
+	// KeyRemoved is emitted when a key is removed from the key ring by Replace
+	KeyRemoved
+)
+
+var keyEventTypeNames = []string{"added", "promoted", "demoted", "retired", "removed"}
+
+// String returns the name of the event type
+func (t KeyEventType) String() string {
+	if t < 0 || int(t) >= len(keyEventTypeNames) {
+		return fmt.Sprintf("KeyEventType(%d)", int(t))
+	}
+	return keyEventTypeNames[t]
+}
+
+// KeyEvent is a change of the keys of a KeyRing
+type KeyEvent struct {
+	Type  KeyEventType
+	KeyID string
+	At    time.Time
+}
+
+// Events subscribes to the changes of the key ring, so applications can coordinate dependent actions,
+// e.g. cache flushes or notifications, with the rotations. Every call returns a new buffered channel.
+// Events are dropped rather than blocking the key ring when the subscriber doesn't keep up.
+// Call Unsubscribe to release the channel
+func (kr *KeyRing) Events() <-chan KeyEvent {
+	events := make(chan KeyEvent, keyEventBuffer)
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	kr.subscribers = append(kr.subscribers, events)
+	return events
+}
+
+// Unsubscribe stops sending events to the channel returned by Events and closes it
+func (kr *KeyRing) Unsubscribe(events <-chan KeyEvent) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	for i, subscriber := range kr.subscribers {
+		if subscriber == events {
+			kr.subscribers = append(kr.subscribers[:i], kr.subscribers[i+1:]...)
+			close(subscriber)
+			return
+		}
+	}
+}
+
+// publish sends the changes between the keys to the subscribers. The caller must hold the write lock
+func (kr *KeyRing) publish(previous []Key, at time.Time) {
+	if len(kr.subscribers) == 0 {
+		return
+	}
+	for _, event := range keyEvents(previous, kr.keys, at) {
+		for _, subscriber := range kr.subscribers {
+			select {
+			case subscriber <- event:
+			default:
+			}
+		}
+	}
+}
+
+// keyEvents returns the events turning the previous keys into the current ones
+func keyEvents(previous []Key, current []Key, at time.Time) []KeyEvent {
+	states := make(map[string]KeyState, len(previous))
+	for _, key := range previous {
+		states[key.ID] = key.State
+	}
+
+	var events []KeyEvent
+	for _, key := range current {
+		state, ok := states[key.ID]
+		if !ok {
+			events = append(events, KeyEvent{Type: KeyAdded, KeyID: key.ID, At: at})
+			state = KeyPending
+		}
+		delete(states, key.ID)
+		if key.State == state {
+			continue
+		}
+		switch key.State {
+		case KeyActive:
+			events = append(events, KeyEvent{Type: KeyPromoted, KeyID: key.ID, At: at})
+		case KeyVerifyOnly:
+			events = append(events, KeyEvent{Type: KeyDemoted, KeyID: key.ID, At: at})
+		case KeyRetired:
+			events = append(events, KeyEvent{Type: KeyRetiredEvent, KeyID: key.ID, At: at})
+		}
+	}
+	for _, key := range previous {
+		if _, ok := states[key.ID]; ok {
+			events = append(events, KeyEvent{Type: KeyRemoved, KeyID: key.ID, At: at})
+		}
+	}
+	return events
+}
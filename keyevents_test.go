@@ -0,0 +1,74 @@
+package cookiesignature
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyRingEvents(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	kr, _ := NewKeyRing(Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive})
+	events := kr.Events()
+
+	expectEvents := func(expected ...KeyEvent) {
+		t.Helper()
+		for _, e := range expected {
+			select {
+			case event := <-events:
+				if event != e {
+					t.Fatalf("expected event: %+v, got: %+v", e, event)
+				}
+			default:
+				t.Fatalf("expected event: %+v, got none", e)
+			}
+		}
+		select {
+		case event := <-events:
+			t.Fatalf("unexpected event: %+v", event)
+		default:
+		}
+	}
+
+	if err := kr.Add(Key{ID: "k1", Secret: []byte("n3wsecr3t")}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	expectEvents(KeyEvent{Type: KeyAdded, KeyID: "k1", At: now})
+
+	if err := kr.Promote("k1"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	expectEvents(KeyEvent{Type: KeyDemoted, KeyID: "k0", At: now}, KeyEvent{Type: KeyPromoted, KeyID: "k1", At: now})
+
+	if err := kr.Retire("k0"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	expectEvents(KeyEvent{Type: KeyRetiredEvent, KeyID: "k0", At: now})
+
+	// failed changes emit nothing
+	if err := kr.Retire("k1"); err == nil {
+		t.Fatal("expected an error retiring the active key")
+	}
+	expectEvents()
+
+	if err := kr.Replace([]Key{{ID: "k2", Secret: []byte("s3cr3t"), State: KeyActive}, {ID: "k1", Secret: []byte("n3wsecr3t"), State: KeyVerifyOnly}}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	expectEvents(
+		KeyEvent{Type: KeyAdded, KeyID: "k2", At: now},
+		KeyEvent{Type: KeyPromoted, KeyID: "k2", At: now},
+		KeyEvent{Type: KeyDemoted, KeyID: "k1", At: now},
+		KeyEvent{Type: KeyRemoved, KeyID: "k0", At: now},
+	)
+
+	kr.Unsubscribe(events)
+	if _, ok := <-events; ok {
+		t.Fatal("expected the channel to be closed")
+	}
+	if err := kr.Promote("k1"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	assertEqual(t, "retired", KeyRetiredEvent.String(), nil)
+}
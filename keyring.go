@@ -0,0 +1,324 @@
+package cookiesignature
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const generatedKeyLength = 32
+
+var (
+	errKeyNotFound      = errors.New("key not found")
+	errNoActiveKey      = errors.New("key ring must have exactly one active key")
+	errActiveKeyRemoval = errors.New("the active key can't be demoted or retired, promote another key instead")
+)
+
+// KeyState is the lifecycle state of a key in a KeyRing
+type KeyState int
+
+const (
+	// KeyPending keys verify but don't sign yet, so every instance learns a new key before it is promoted
+	KeyPending KeyState = iota
+	// KeyActive is the single key that signs, it also verifies
+	KeyActive
+	// KeyVerifyOnly keys only verify, they drain the cookies signed before the rotation
+	KeyVerifyOnly
+	// KeyRetired keys neither sign nor verify
+	KeyRetired
+)
+
+var keyStateNames = []string{"pending", "active", "verify-only", "retired"}
+
+// String returns the name of the state
+func (s KeyState) String() string {
+	if s < 0 || int(s) >= len(keyStateNames) {
+		return fmt.Sprintf("KeyState(%d)", int(s))
+	}
+	return keyStateNames[s]
+}
+
+// MarshalText encodes the state to its name
+func (s KeyState) MarshalText() ([]byte, error) {
+	if s < 0 || int(s) >= len(keyStateNames) {
+		return nil, fmt.Errorf("invalid key state: %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes the state from its name
+func (s *KeyState) UnmarshalText(text []byte) error {
+	for i, name := range keyStateNames {
+		if name == string(text) {
+			*s = KeyState(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid key state: %s", text)
+}
+
+// Key is a secret of a KeyRing with its lifecycle metadata
+type Key struct {
+	ID         string    `json:"id"`
+	Secret     []byte    `json:"secret"`
+	State      KeyState  `json:"state"`
+	CreatedAt  time.Time `json:"created_at"`
+	PromotedAt time.Time `json:"promoted_at,omitempty"`
+	DemotedAt  time.Time `json:"demoted_at,omitempty"`
+	// Scrypt holds the parameters of a key derived from a passphrase, nil otherwise
+	Scrypt *ScryptParams `json:"scrypt,omitempty"`
+}
+
+// GenerateKey generates a pending key with a random secret and ID
+func GenerateKey() (Key, error) {
+	secret := make([]byte, generatedKeyLength)
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, err
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Key{}, err
+	}
+	return Key{
+		ID:        hex.EncodeToString(id),
+		Secret:    secret,
+		State:     KeyPending,
+		CreatedAt: timeNow(),
+	}, nil
+}
+
+// KeyRing holds the keys of a CookieSignature with their lifecycle states.
+// It always has exactly one active key. It is safe for concurrent use,
+// and a CookieSignature created by NewCookieSignatureFromKeyRing sees every change immediately
+type KeyRing struct {
+	mu          sync.RWMutex
+	keys        []Key
+	subscribers []chan KeyEvent
+}
+
+// NewKeyRing creates a new KeyRing instance. Exactly one key must be active
+func NewKeyRing(keys ...Key) (*KeyRing, error) {
+	kr := &KeyRing{}
+	if err := kr.Replace(keys); err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
+// Keys returns a copy of the keys
+func (kr *KeyRing) Keys() []Key {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	return copyKeys(kr.keys)
+}
+
+// Active returns the active key
+func (kr *KeyRing) Active() Key {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	return kr.keys[kr.activeIndex()]
+}
+
+// Replace atomically replaces every key of the key ring. Exactly one key must be active
+func (kr *KeyRing) Replace(keys []Key) error {
+	if err := validateKeys(keys); err != nil {
+		return err
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	previous := kr.keys
+	kr.keys = copyKeys(keys)
+	kr.publish(previous, timeNow())
+	return nil
+}
+
+// Add adds a new key. Added keys can't be active, promote them instead
+func (kr *KeyRing) Add(key Key) error {
+	if key.State == KeyActive {
+		return errors.New("added keys can't be active, promote them instead")
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	keys := append(copyKeys(kr.keys), key)
+	if err := validateKeys(keys); err != nil {
+		return err
+	}
+	previous := kr.keys
+	kr.keys = keys
+	kr.publish(previous, timeNow())
+	return nil
+}
+
+// Promote makes the key active. The previously active key becomes verify-only
+func (kr *KeyRing) Promote(id string) error {
+	return kr.promote(id, timeNow())
+}
+
+// Demote makes the key verify-only. The active key can't be demoted
+func (kr *KeyRing) Demote(id string) error {
+	return kr.setState(id, KeyVerifyOnly, timeNow())
+}
+
+// Retire stops using the key for verification. The active key can't be retired
+func (kr *KeyRing) Retire(id string) error {
+	return kr.setState(id, KeyRetired, timeNow())
+}
+
+func (kr *KeyRing) promote(id string, at time.Time) error {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	index := kr.indexOf(id)
+	if index < 0 {
+		return errKeyNotFound
+	}
+	if kr.keys[index].State == KeyActive {
+		return nil
+	}
+
+	previous := copyKeys(kr.keys)
+	active := kr.activeIndex()
+	kr.keys[active].State = KeyVerifyOnly
+	kr.keys[active].DemotedAt = at
+	kr.keys[index].State = KeyActive
+	kr.keys[index].PromotedAt = at
+	kr.publish(previous, at)
+	return nil
+}
+
+func (kr *KeyRing) setState(id string, state KeyState, at time.Time) error {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	index := kr.indexOf(id)
+	if index < 0 {
+		return errKeyNotFound
+	}
+	if kr.keys[index].State == KeyActive {
+		return errActiveKeyRemoval
+	}
+	previous := copyKeys(kr.keys)
+	if state == KeyVerifyOnly && kr.keys[index].State != KeyVerifyOnly {
+		kr.keys[index].DemotedAt = at
+	}
+	kr.keys[index].State = state
+	kr.publish(previous, at)
+	return nil
+}
+
+// verificationKeys returns the keys that verify, the active key first
+func (kr *KeyRing) verificationKeys() []verificationKey {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	active := kr.activeIndex()
+	keys := []verificationKey{{id: kr.keys[active].ID, secret: kr.keys[active].Secret}}
+	for i, key := range kr.keys {
+		if i != active && (key.State == KeyPending || key.State == KeyVerifyOnly) {
+			keys = append(keys, verificationKey{id: key.ID, secret: key.Secret})
+		}
+	}
+	return keys
+}
+
+func (kr *KeyRing) indexOf(id string) int {
+	for i, key := range kr.keys {
+		if key.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (kr *KeyRing) activeIndex() int {
+	for i, key := range kr.keys {
+		if key.State == KeyActive {
+			return i
+		}
+	}
+	// unreachable, the key ring is validated on every change
+	panic(errNoActiveKey)
+}
+
+func validateKeys(keys []Key) error {
+	ids := make(map[string]bool, len(keys))
+	activeKeys := 0
+	for i, key := range keys {
+		if key.ID == "" {
+			return fmt.Errorf("key id at index %d must not be empty", i)
+		}
+		if ids[key.ID] {
+			return fmt.Errorf("duplicated key id: %s", key.ID)
+		}
+		ids[key.ID] = true
+		if len(key.Secret) == 0 {
+			return fmt.Errorf("secret key %s must not be empty", key.ID)
+		}
+		if key.State < KeyPending || key.State > KeyRetired {
+			return fmt.Errorf("invalid state of key %s: %d", key.ID, int(key.State))
+		}
+		if key.State == KeyActive {
+			activeKeys++
+		}
+	}
+	if activeKeys != 1 {
+		return errNoActiveKey
+	}
+	return nil
+}
+
+// encodeKeys encodes the keys written by the key stores to JSON. The secrets of passphrase keys are omitted,
+// only their scrypt parameters are stored
+func encodeKeys(keys []Key) ([]byte, error) {
+	stored := copyKeys(keys)
+	for i := range stored {
+		if stored[i].Scrypt != nil {
+			stored[i].Secret = nil
+		}
+	}
+	return json.Marshal(stored)
+}
+
+// decodeKeys decodes the JSON keys written by the key stores
+func decodeKeys(data []byte) ([]Key, error) {
+	var keys []Key
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func copyKeys(keys []Key) []Key {
+	result := make([]Key, len(keys))
+	copy(result, keys)
+	return result
+}
+
+// NewCookieSignatureFromKeyRing creates a new CookieSignature instance that signs with the active key of the key ring
+// and verifies with its active, pending and verify-only keys. Changes of the key ring apply immediately
+func NewCookieSignatureFromKeyRing(keyRing *KeyRing, opts ...Option) (*CookieSignature, error) {
+	if keyRing == nil {
+		return nil, errors.New("key ring must be provided")
+	}
+
+	result := CookieSignature{keyRing: keyRing, usage: &keyUsage{}}
+	for _, opt := range opts {
+		opt(&result.opts)
+	}
+	if result.opts.err != nil {
+		return nil, result.opts.err
+	}
+	if err := result.opts.validateHash(); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
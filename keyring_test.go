@@ -0,0 +1,99 @@
+package cookiesignature
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestKeyRing(t *testing.T) {
+	_, err := NewKeyRing(Key{ID: "a", Secret: []byte("tobiiscool"), State: KeyPending})
+	if err != errNoActiveKey {
+		t.Fatalf("expected error: %s, got: %s", errNoActiveKey, err)
+	}
+	_, err = NewKeyRing(Key{ID: "a", Secret: []byte("tobiiscool"), State: KeyActive}, Key{ID: "a", Secret: []byte("luna")})
+	if err == nil || err.Error() != "duplicated key id: a" {
+		t.Fatalf("expected error: duplicated key id: a, got: %s", err)
+	}
+
+	kr, err := NewKeyRing(Key{ID: "old", Secret: []byte("tobiiscool"), State: KeyActive})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	cs, err := NewCookieSignatureFromKeyRing(kr)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	oldSigned, err := cs.Sign("hello")
+	assertEqual(t, "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", oldSigned, err)
+
+	if err := kr.Add(Key{ID: "new", Secret: []byte("luna"), State: KeyActive}); err == nil {
+		t.Fatalf("expected adding an active key to fail")
+	}
+	if err := kr.Add(Key{ID: "new", Secret: []byte("luna"), State: KeyPending}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	// pending keys verify but don't sign
+	pendingCS, _ := NewCookieSignature([]string{"luna"})
+	newSigned, _ := pendingCS.Sign("hello")
+	result, err := cs.Unsign(newSigned)
+	assertEqual(t, "hello", result, err)
+	signed, err := cs.Sign("hello")
+	assertEqual(t, oldSigned, signed, err)
+
+	if err := kr.Retire("old"); err != errActiveKeyRemoval {
+		t.Fatalf("expected error: %s, got: %s", errActiveKeyRemoval, err)
+	}
+	if err := kr.Promote("new"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	signed, err = cs.Sign("hello")
+	assertEqual(t, newSigned, signed, err)
+	result, err = cs.Unsign(oldSigned)
+	assertEqual(t, "hello", result, err)
+
+	if err := kr.Retire("old"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if _, err := cs.Unsign(oldSigned); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+	if err := kr.Promote("missing"); err != errKeyNotFound {
+		t.Fatalf("expected error: %s, got: %s", errKeyNotFound, err)
+	}
+
+	keys := kr.Keys()
+	if len(keys) != 2 || keys[0].State != KeyRetired || keys[1].State != KeyActive || keys[0].DemotedAt.IsZero() {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+}
+
+func TestKeyStateJSON(t *testing.T) {
+	encoded, err := json.Marshal(Key{ID: "a", Secret: []byte("luna"), State: KeyVerifyOnly, CreatedAt: time.Unix(0, 0).UTC()})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	var key Key
+	if err := json.Unmarshal(encoded, &key); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if key.State != KeyVerifyOnly || string(key.Secret) != "luna" {
+		t.Fatalf("unexpected key: %+v", key)
+	}
+	if err := json.Unmarshal([]byte(`{"state":"unknown"}`), &key); err == nil {
+		t.Fatalf("expected an invalid state error")
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+	a, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	b, _ := GenerateKey()
+	if a.ID == b.ID || len(a.Secret) != generatedKeyLength || a.State != KeyPending {
+		t.Fatalf("unexpected keys: %+v, %+v", a, b)
+	}
+}
@@ -0,0 +1,79 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KubernetesSecretGetter reads the data of a Kubernetes Secret.
+// It keeps this package free of a client-go dependency, an adapter of CoreV1().Secrets(namespace).Get only takes a few lines
+type KubernetesSecretGetter interface {
+	GetSecret(ctx context.Context, namespace string, name string) (map[string][]byte, error)
+}
+
+// KubernetesSecretProvider is a SecretProvider that reads the keys from a Kubernetes Secret,
+// either mounted as a volume or through the API. Every data item of the Secret is a key, named by its data key.
+// Data keys are sorted in natural order and the last one is the active key, e.g. rotate by adding cookie-key-3 next to cookie-key-2.
+// Use WatchSecretProvider to pick up the updates of the Secret
+type KubernetesSecretProvider struct {
+	// Dir is the mount path of the Secret volume. The API is used if empty
+	Dir string
+
+	Client    KubernetesSecretGetter
+	Namespace string
+	Name      string
+}
+
+// Keys reads the keys from the mounted volume or the API
+func (p KubernetesSecretProvider) Keys(ctx context.Context) ([]Key, error) {
+	if p.Dir != "" {
+		secrets, err := readSecretVolume(p.Dir)
+		if err != nil {
+			return nil, err
+		}
+		return keysFromSecrets(secrets)
+	}
+
+	if p.Client == nil {
+		return nil, errors.New("either the mount directory or the client of the secret must be provided")
+	}
+	secrets, err := p.Client.GetSecret(ctx, p.Namespace, p.Name)
+	if err != nil {
+		return nil, err
+	}
+	return keysFromSecrets(secrets)
+}
+
+// readSecretVolume reads the files of a Secret volume.
+// The kubelet swaps the hidden ..data directory atomically on update, the visible entries are symlinks into it
+func readSecretVolume(dir string) (map[string][]byte, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		secrets[entry.Name()] = data
+	}
+	return secrets, nil
+}
@@ -0,0 +1,58 @@
+package cookiesignature
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testKubernetesClient map[string]map[string][]byte
+
+func (c testKubernetesClient) GetSecret(_ context.Context, namespace string, name string) (map[string][]byte, error) {
+	return c[namespace+"/"+name], nil
+}
+
+func TestKubernetesSecretProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cookiesignature")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// mimic the layout of the kubelet, the visible files are symlinks into the hidden ..data directory
+	dataDir := filepath.Join(dir, "..data")
+	if err := os.Mkdir(dataDir, 0700); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	for name, value := range map[string]string{"cookie-key-1": "tobiiscool", "cookie-key-2": "luna\n"} {
+		if err := ioutil.WriteFile(filepath.Join(dataDir, name), []byte(value), 0600); err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		if err := os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)); err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+	}
+
+	keys, err := KubernetesSecretProvider{Dir: dir}.Keys(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(keys) != 2 || keys[1].ID != "cookie-key-2" || keys[1].State != KeyActive || string(keys[1].Secret) != "luna" {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+
+	client := testKubernetesClient{"default/cookie-keys": {"cookie-key-1": []byte("tobiiscool")}}
+	keys, err = KubernetesSecretProvider{Client: client, Namespace: "default", Name: "cookie-keys"}.Keys(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(keys) != 1 || keys[0].State != KeyActive || string(keys[0].Secret) != "tobiiscool" {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+
+	if _, err := (KubernetesSecretProvider{}).Keys(context.Background()); err == nil {
+		t.Fatalf("expected a missing source error")
+	}
+}
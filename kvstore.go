@@ -0,0 +1,153 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+const defaultKVKeyStoreKey = "cookiesignature/keys"
+
+// ErrKeyStoreConflict is returned by KVKeyStore.Save when the keys changed since they were last loaded,
+// e.g. when another instance rotated them first
+var ErrKeyStoreConflict = errors.New("keys were changed concurrently")
+
+var errKeysNotFound = errors.New("keys not found")
+
+// KVEntry is a value of a KVBackend with its revision
+type KVEntry struct {
+	Value []byte
+	// Revision increases on every change of the key, e.g. the ModRevision of etcd or the ModifyIndex of Consul
+	Revision uint64
+}
+
+// KVBackend is the subset of a coordination store such as etcd or Consul KV used by KVKeyStore.
+// It keeps this package free of their client dependencies
+type KVBackend interface {
+	// Get returns the entry of the key, with a zero revision if the key doesn't exist
+	Get(ctx context.Context, key string) (KVEntry, error)
+	// CompareAndSwap sets the value if the revision of the key still matches, a zero revision meaning that it doesn't exist.
+	// It reports whether the value was set, with the revision of the change, e.g. the revision of the etcd transaction
+	// or the ModifyIndex of the Consul CAS response. The revision must come from the swap itself, not from a later read,
+	// which could return the revision of another writer
+	CompareAndSwap(ctx context.Context, key string, value []byte, revision uint64) (newRevision uint64, swapped bool, err error)
+	// Watch returns the entries of every change of the key until the context is done
+	Watch(ctx context.Context, key string) (<-chan KVEntry, error)
+}
+
+// KVKeyStore is a KeyStore backed by etcd, Consul KV or another KVBackend.
+// Saves are atomic swaps against the revision last loaded or watched,
+// so two instances rotating at once never overwrite each other's keys
+type KVKeyStore struct {
+	Backend KVBackend
+	// Key of the keys in the backend. Defaults to "cookiesignature/keys"
+	Key string
+	// Passphrase derives the secrets of the passphrase keys on load and watch, which are stored without them.
+	// They are loaded without secrets if empty, see DerivePassphraseKeys
+	Passphrase string
+
+	mu       sync.Mutex
+	revision uint64
+}
+
+// Load reads the keys from the backend
+func (s *KVKeyStore) Load(ctx context.Context) ([]Key, error) {
+	entry, err := s.Backend.Get(ctx, s.key())
+	if err != nil {
+		return nil, err
+	}
+	if entry.Revision == 0 {
+		return nil, errKeysNotFound
+	}
+
+	keys, err := s.decodeKeys(entry.Value)
+	if err != nil {
+		return nil, err
+	}
+	s.setRevision(entry.Revision)
+	return keys, nil
+}
+
+// Save writes the keys if they didn't change since they were last loaded or watched, it returns ErrKeyStoreConflict otherwise
+func (s *KVKeyStore) Save(ctx context.Context, keys []Key) error {
+	value, err := encodeKeys(keys)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	revision := s.revision
+	s.mu.Unlock()
+
+	newRevision, ok, err := s.Backend.CompareAndSwap(ctx, s.key(), value, revision)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrKeyStoreConflict
+	}
+	s.setRevision(newRevision)
+	return nil
+}
+
+// Watch replaces the keys of the key ring with every change of the backend, until the context is done.
+// Invalid changes are reported to onError, if not nil, and skipped
+func (s *KVKeyStore) Watch(ctx context.Context, keyRing *KeyRing, onError func(err error)) error {
+	entries, err := s.Backend.Watch(ctx, s.key())
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case entry, ok := <-entries:
+			if !ok {
+				return errors.New("watch closed")
+			}
+			if err := s.apply(keyRing, entry); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+func (s *KVKeyStore) apply(keyRing *KeyRing, entry KVEntry) error {
+	if len(entry.Value) == 0 {
+		return errors.New("keys were deleted")
+	}
+	keys, err := s.decodeKeys(entry.Value)
+	if err != nil {
+		return err
+	}
+	if err := keyRing.Replace(keys); err != nil {
+		return err
+	}
+	s.setRevision(entry.Revision)
+	return nil
+}
+
+// decodeKeys decodes the keys of the backend and derives the secrets of the passphrase keys
+func (s *KVKeyStore) decodeKeys(data []byte) ([]Key, error) {
+	keys, err := decodeKeys(data)
+	if err != nil {
+		return nil, err
+	}
+	return derivePassphraseKeys(keys, s.Passphrase)
+}
+
+func (s *KVKeyStore) setRevision(revision uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if revision > s.revision {
+		s.revision = revision
+	}
+}
+
+func (s *KVKeyStore) key() string {
+	if s.Key == "" {
+		return defaultKVKeyStoreKey
+	}
+	return s.Key
+}
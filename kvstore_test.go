@@ -0,0 +1,152 @@
+package cookiesignature
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testKVBackend struct {
+	mu       sync.Mutex
+	entries  map[string]KVEntry
+	revision uint64
+	watchers []chan KVEntry
+	// afterSwap runs after a swap, before it returns, e.g. to commit a concurrent write
+	afterSwap func()
+}
+
+func (kv *testKVBackend) Get(_ context.Context, key string) (KVEntry, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	return kv.entries[key], nil
+}
+
+func (kv *testKVBackend) CompareAndSwap(_ context.Context, key string, value []byte, revision uint64) (uint64, bool, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	if kv.entries == nil {
+		kv.entries = make(map[string]KVEntry)
+	}
+	if kv.entries[key].Revision != revision {
+		return 0, false, nil
+	}
+	kv.revision++
+	entry := KVEntry{Value: value, Revision: kv.revision}
+	kv.entries[key] = entry
+	for _, watcher := range kv.watchers {
+		watcher <- entry
+	}
+	if kv.afterSwap != nil {
+		kv.afterSwap()
+	}
+	return entry.Revision, true, nil
+}
+
+func (kv *testKVBackend) Watch(_ context.Context, _ string) (<-chan KVEntry, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	watcher := make(chan KVEntry, 10)
+	kv.watchers = append(kv.watchers, watcher)
+	return watcher, nil
+}
+
+func TestKVKeyStore(t *testing.T) {
+	ctx := context.Background()
+	backend := &testKVBackend{}
+	first := &KVKeyStore{Backend: backend}
+	second := &KVKeyStore{Backend: backend}
+
+	if _, err := first.Load(ctx); err == nil {
+		t.Fatalf("expected a not found error")
+	}
+
+	keys := []Key{{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive}}
+	if err := first.Save(ctx, keys); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if err := second.Save(ctx, keys); err != ErrKeyStoreConflict {
+		t.Fatalf("expected error: %s, got: %s", ErrKeyStoreConflict, err)
+	}
+	if _, err := second.Load(ctx); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	keyRing, _ := NewKeyRing(keys...)
+	watchCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() { done <- second.Watch(watchCtx, keyRing, nil) }()
+	for {
+		backend.mu.Lock()
+		watching := len(backend.watchers) > 0
+		backend.mu.Unlock()
+		if watching {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	rotated := []Key{
+		{ID: "k0", Secret: []byte("tobiiscool"), State: KeyVerifyOnly},
+		{ID: "k1", Secret: []byte("luna"), State: KeyActive},
+	}
+	if err := first.Save(ctx, rotated); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for keyRing.Active().ID != "k1" {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the watched key ring to be swapped")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected error: %s, got: %s", context.Canceled, err)
+	}
+
+	// the watched revision is used by the next save
+	if err := second.Save(ctx, rotated); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+}
+
+func TestKVKeyStoreConcurrentSave(t *testing.T) {
+	ctx := context.Background()
+	backend := &testKVBackend{}
+	first := &KVKeyStore{Backend: backend}
+	second := &KVKeyStore{Backend: backend}
+
+	keys := []Key{{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive}}
+	if err := first.Save(ctx, keys); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if _, err := second.Load(ctx); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	// the second store commits right after the swap of the first one
+	backend.afterSwap = func() {
+		backend.afterSwap = nil
+		backend.mu.Unlock()
+		defer backend.mu.Lock()
+		if err := second.Save(ctx, append(keys, Key{ID: "k2", Secret: []byte("luna"), State: KeyPending})); err != ErrKeyStoreConflict {
+			t.Errorf("expected error: %s, got: %v", ErrKeyStoreConflict, err)
+		}
+		if _, err := second.Load(ctx); err != nil {
+			t.Errorf("expected no error, got: %s", err)
+		}
+		if err := second.Save(ctx, append(keys, Key{ID: "k2", Secret: []byte("luna"), State: KeyPending})); err != nil {
+			t.Errorf("expected no error, got: %s", err)
+		}
+	}
+	if err := first.Save(ctx, append(keys, Key{ID: "k1", Secret: []byte("n3wsecr3t"), State: KeyPending})); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	// the first store didn't load the change of the second one, it can't overwrite it
+	if err := first.Save(ctx, keys); err != ErrKeyStoreConflict {
+		t.Fatalf("expected error: %s, got: %v", ErrKeyStoreConflict, err)
+	}
+}
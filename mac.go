@@ -0,0 +1,39 @@
+package cookiesignature
+
+import "errors"
+
+// MACProvider computes the MAC of the inputs with a key it holds, e.g. on an HSM that never exports it.
+// Values stay interoperable with node-cookie-signature if the MAC is HMAC-SHA256
+type MACProvider interface {
+	MAC(input []byte) ([]byte, error)
+}
+
+// NewCookieSignatureFromMAC creates a new CookieSignature instance whose MACs are computed by the providers.
+// The first provider signs and every one verifies, like the secrets of NewCookieSignature.
+// Features that need the secret itself, i.e. Encrypt, WithSubkey and WithEnvironment, aren't available
+func NewCookieSignatureFromMAC(providers []MACProvider, opts ...Option) (*CookieSignature, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("MAC provider must be provided")
+	}
+
+	result := CookieSignature{usage: &keyUsage{}}
+	for _, opt := range opts {
+		opt(&result.opts)
+	}
+	if result.opts.err != nil {
+		return nil, result.opts.err
+	}
+	if result.opts.environment != "" || len(result.opts.subkeyLabels) > 0 {
+		return nil, errors.New("keys of MAC providers can't be derived, derive them on the device instead")
+	}
+	if result.opts.hash != 0 || result.opts.keyedBLAKE2b {
+		return nil, errors.New("the MAC of MAC providers is chosen by the device, WithHash and WithKeyedBLAKE2b don't apply")
+	}
+	for _, provider := range providers {
+		if provider == nil {
+			return nil, errors.New("MAC provider must not be nil")
+		}
+	}
+	result.macProviders = append([]MACProvider(nil), providers...)
+	return &result, nil
+}
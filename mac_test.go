@@ -0,0 +1,52 @@
+package cookiesignature
+
+import (
+	"errors"
+	"testing"
+)
+
+type testMACProvider []byte
+
+func (p testMACProvider) MAC(input []byte) ([]byte, error) {
+	return computeHMAC256(string(input), p)
+}
+
+func TestMACProvider(t *testing.T) {
+	cs, err := NewCookieSignatureFromMAC([]MACProvider{testMACProvider("n3wsecr3t"), testMACProvider("tobiiscool")})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	old, _ := NewCookieSignature([]string{"tobiiscool"})
+	oldSigned, _ := old.Sign("hello")
+	result, err := cs.Unsign(oldSigned)
+	assertEqual(t, "hello", result, err)
+
+	signed, err := cs.Sign("hello")
+	assertEqual(t, "hello.fJDsH8b7iNvcQdwtuhE29LZUFMorBk6MOzotVfMoiOc", signed, err)
+	if usage := cs.KeyUsage(); usage["1"] != 1 {
+		t.Fatalf("unexpected usage: %v", usage)
+	}
+
+	if _, err := cs.Encrypt([]byte("hello")); err != errEncryptionUnsupported {
+		t.Fatalf("expected error: %s, got: %s", errEncryptionUnsupported, err)
+	}
+	assertEqual(t, "", cs.Fingerprint(), nil)
+
+	failing, _ := NewCookieSignatureFromMAC([]MACProvider{macProviderFunc(func([]byte) ([]byte, error) {
+		return nil, errors.New("device unavailable")
+	})})
+	if _, err := failing.Sign("hello"); err == nil || err.Error() != "device unavailable" {
+		t.Fatalf("expected error: device unavailable, got: %s", err)
+	}
+
+	if _, err := NewCookieSignatureFromMAC([]MACProvider{testMACProvider("n3wsecr3t")}, WithEnvironment("prod")); err == nil {
+		t.Fatalf("expected an underivable key error")
+	}
+}
+
+type macProviderFunc func(input []byte) ([]byte, error)
+
+func (f macProviderFunc) MAC(input []byte) ([]byte, error) {
+	return f(input)
+}
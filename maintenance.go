@@ -0,0 +1,178 @@
+package cookiesignature
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const maintenancePurpose = "maintenance-bypass"
+
+// MaintenanceBypassParam is the query parameter of the links handing out maintenance bypass tokens
+const MaintenanceBypassParam = "maintenance_bypass"
+
+// ErrBypassNotAllowed is returned when a valid maintenance bypass token is used from outside of its network
+// or with a role the site doesn't let through
+var ErrBypassNotAllowed = errors.New("maintenance bypass is not allowed")
+
+// MaintenanceBypass lets an operator with a role access a site in maintenance mode, optionally from a network only
+type MaintenanceBypass struct {
+	Role string
+	// Network restricts the client addresses of the bypass, any address is allowed if nil
+	Network   *net.IPNet
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type maintenanceClaims struct {
+	Role      string `json:"role"`
+	Network   string `json:"net,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// SignMaintenanceBypass signs a maintenance bypass token of the role valid for ttl.
+// The network is a CIDR, e.g. 10.0.0.0/8, the token is only honored from; any address is allowed if empty
+func (cs CookieSignature) SignMaintenanceBypass(role string, network string, ttl time.Duration) (string, error) {
+	if role == "" {
+		return "", errors.New("maintenance bypass role must not be empty")
+	}
+	if ttl <= 0 {
+		return "", errors.New("maintenance bypass ttl must be positive")
+	}
+	if network != "" {
+		_, ipNet, err := net.ParseCIDR(network)
+		if err != nil {
+			return "", err
+		}
+		network = ipNet.String()
+	}
+
+	now := timeNow()
+	claims, err := json.Marshal(maintenanceClaims{Role: role, Network: network, IssuedAt: now.Unix(), ExpiresAt: now.Add(ttl).Unix()})
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+	hashBytes, err := cs.signingMAC(encodeValues([]string{maintenancePurpose, payload}))
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + hashBase64(hashBytes), nil
+}
+
+// UnsignMaintenanceBypass verifies the token signed by SignMaintenanceBypass, checks that it didn't expire
+// and that the client address belongs to its network, or returns ErrBypassNotAllowed
+func (cs CookieSignature) UnsignMaintenanceBypass(input string, clientIP net.IP) (MaintenanceBypass, error) {
+	index := strings.LastIndex(input, ".")
+	if index < 0 {
+		return MaintenanceBypass{}, errInvalidSignature
+	}
+	payload := input[:index]
+	if _, err := cs.unsign(encodeValues([]string{maintenancePurpose, payload}) + input[index:]); err != nil {
+		return MaintenanceBypass{}, err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return MaintenanceBypass{}, errInvalidSignature
+	}
+	var claims maintenanceClaims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return MaintenanceBypass{}, errInvalidSignature
+	}
+	if err := cs.validateClaims(Claims{IssuedAt: claims.IssuedAt, ExpiresAt: claims.ExpiresAt}, verifyOptions{}); err != nil {
+		return MaintenanceBypass{}, err
+	}
+
+	bypass := MaintenanceBypass{
+		Role:      claims.Role,
+		IssuedAt:  time.Unix(claims.IssuedAt, 0),
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}
+	if claims.Network != "" {
+		if _, bypass.Network, err = net.ParseCIDR(claims.Network); err != nil {
+			return MaintenanceBypass{}, errInvalidSignature
+		}
+		if clientIP == nil || !bypass.Network.Contains(clientIP) {
+			return MaintenanceBypass{}, ErrBypassNotAllowed
+		}
+	}
+	return bypass, nil
+}
+
+// MaintenanceMiddleware serves the maintenance handler, or 503 if nil, while enabled returns true,
+// except to the requests carrying a valid bypass token of one of the roles, any role if none.
+// A token in the maintenance_bypass query parameter is stored in the cookie of the template until it expires,
+// so an operator only follows a link once; the cookie is deleted when its token is no longer valid.
+// The request is then redirected to its URL without the parameter, keeping the token out of access logs and Referer headers.
+// The client address is read from RemoteAddr, so behind a proxy it must be rewritten from the forwarded headers first
+func (cs CookieSignature) MaintenanceMiddleware(enabled func() bool, template http.Cookie, roles []string, maintenance http.Handler) func(http.Handler) http.Handler {
+	if maintenance == nil {
+		maintenance = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		})
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, fromQuery := r.URL.Query().Get(MaintenanceBypassParam), true
+			if token == "" {
+				fromQuery = false
+				if cookie, err := r.Cookie(template.Name); err == nil {
+					token = cookie.Value
+				}
+			}
+			if token == "" {
+				maintenance.ServeHTTP(w, r)
+				return
+			}
+
+			bypass, err := cs.UnsignMaintenanceBypass(token, remoteIP(r))
+			if err == nil && len(roles) > 0 && !containsString(roles, bypass.Role) {
+				err = ErrBypassNotAllowed
+			}
+			if err != nil {
+				if !fromQuery {
+					deleted := template
+					deleted.Value = ""
+					deleted.MaxAge = -1
+					http.SetCookie(w, &deleted)
+				}
+				maintenance.ServeHTTP(w, r)
+				return
+			}
+
+			if fromQuery {
+				cookie := template
+				cookie.Value = token
+				cookie.Expires = bypass.ExpiresAt
+				http.SetCookie(w, &cookie)
+
+				target := *r.URL
+				query := target.Query()
+				query.Del(MaintenanceBypassParam)
+				target.RawQuery = query.Encode()
+				http.Redirect(w, r, target.RequestURI(), http.StatusFound)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// remoteIP returns the IP address of RemoteAddr, or nil if it has none
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
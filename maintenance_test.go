@@ -0,0 +1,108 @@
+package cookiesignature
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceBypass(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return start }
+	defer func() { timeNow = time.Now }()
+
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	token, err := cs.SignMaintenanceBypass("ops", "192.0.2.17/24", time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	bypass, err := cs.UnsignMaintenanceBypass(token, net.ParseIP("192.0.2.200"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if bypass.Role != "ops" || bypass.Network.String() != "192.0.2.0/24" || !bypass.ExpiresAt.Equal(start.Add(time.Hour)) {
+		t.Fatalf("unexpected bypass: %+v", bypass)
+	}
+	for _, ip := range []net.IP{net.ParseIP("198.51.100.1"), nil} {
+		if _, err := cs.UnsignMaintenanceBypass(token, ip); err != ErrBypassNotAllowed {
+			t.Fatalf("expected error: %s for %s, got: %v", ErrBypassNotAllowed, ip, err)
+		}
+	}
+
+	anywhere, _ := cs.SignMaintenanceBypass("support", "", time.Hour)
+	if _, err := cs.UnsignMaintenanceBypass(anywhere, nil); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if _, err := cs.SignMaintenanceBypass("ops", "192.0.2.17", time.Hour); err == nil {
+		t.Fatal("expected an error for an invalid network")
+	}
+	forged := token[:strings.LastIndex(token, ".")] + anywhere[strings.LastIndex(anywhere, "."):]
+	if _, err := cs.UnsignMaintenanceBypass(forged, net.ParseIP("192.0.2.200")); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+
+	timeNow = func() time.Time { return start.Add(2 * time.Hour) }
+	if _, err := cs.UnsignMaintenanceBypass(token, net.ParseIP("192.0.2.200")); err != ErrTokenExpired {
+		t.Fatalf("expected error: %s, got: %v", ErrTokenExpired, err)
+	}
+}
+
+func TestMaintenanceMiddleware(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return start }
+	defer func() { timeNow = time.Now }()
+
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	// httptest requests come from 192.0.2.1
+	token, _ := cs.SignMaintenanceBypass("ops", "192.0.2.0/24", time.Hour)
+	support, _ := cs.SignMaintenanceBypass("support", "", time.Hour)
+
+	enabled := true
+	handler := cs.MaintenanceMiddleware(func() bool { return enabled }, http.Cookie{Name: "bypass", Path: "/"}, []string{"ops"}, nil)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}))
+
+	serve := func(target string, cookie string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, target, nil)
+		if cookie != "" {
+			request.AddCookie(&http.Cookie{Name: "bypass", Value: cookie})
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if recorder := serve("/", ""); recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status: %d, got: %d", http.StatusServiceUnavailable, recorder.Code)
+	}
+
+	recorder := serve("/status?page=2&"+MaintenanceBypassParam+"="+url.QueryEscape(token), "")
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value != token || !cookies[0].Expires.Equal(start.Add(time.Hour)) {
+		t.Fatalf("expected the bypass cookie to be set, got: %v", cookies)
+	}
+	if recorder.Code != http.StatusFound || recorder.Header().Get("Location") != "/status?page=2" {
+		t.Fatalf("expected a redirect without the token, got: %d %s", recorder.Code, recorder.Header().Get("Location"))
+	}
+	if recorder := serve("/", token); recorder.Body.String() != "ok" {
+		t.Fatalf("expected the bypass cookie to be honored, got: %d", recorder.Code)
+	}
+
+	// the role isn't let through
+	recorder = serve("/", support)
+	cookies = recorder.Result().Cookies()
+	if recorder.Code != http.StatusServiceUnavailable || len(cookies) != 1 || cookies[0].MaxAge != -1 {
+		t.Fatalf("expected the bypass cookie to be deleted, got: %d %v", recorder.Code, cookies)
+	}
+
+	enabled = false
+	if recorder := serve("/", ""); recorder.Body.String() != "ok" {
+		t.Fatalf("expected the site to be served, got: %d", recorder.Code)
+	}
+}
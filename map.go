@@ -0,0 +1,37 @@
+package cookiesignature
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// SignMap encodes the key/value map into a single signed value.
+// Keys are sorted and both keys and values are escaped, so the encoding is deterministic and unambiguous
+func (cs CookieSignature) SignMap(values map[string]string) (string, error) {
+	query := url.Values{}
+	for key, value := range values {
+		query.Set(key, value)
+	}
+	return cs.Sign(query.Encode())
+}
+
+// UnsignMap verifies the signed value created by SignMap and decodes the key/value map
+func (cs CookieSignature) UnsignMap(input string) (map[string]string, error) {
+	rawResult, err := cs.Unsign(input)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := url.ParseQuery(rawResult)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(query))
+	for key, values := range query {
+		if len(values) != 1 {
+			return nil, fmt.Errorf("duplicated key: %s", key)
+		}
+		result[key] = values[0]
+	}
+	return result, nil
+}
@@ -0,0 +1,41 @@
+package cookiesignature
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSignMap(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	if _, err := cs.SignMap(map[string]string{}); err != errEmptyUnsignedValue {
+		t.Fatalf("expected error: %s, got: %s", errEmptyUnsignedValue, err)
+	}
+
+	values := map[string]string{"user": "tobi", "role": "admin&owner", "tenant": "a=b"}
+	signed, err := cs.SignMap(values)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	signed2, err := cs.SignMap(map[string]string{"tenant": "a=b", "role": "admin&owner", "user": "tobi"})
+	assertEqual(t, signed, signed2, err)
+
+	result, err := cs.UnsignMap(signed)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if !reflect.DeepEqual(values, result) {
+		t.Fatalf("expected: %v, got: %v", values, result)
+	}
+
+	duplicated, err := cs.Sign("user=tobi&user=admin")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if _, err := cs.UnsignMap(duplicated); err == nil || err.Error() != "duplicated key: user" {
+		t.Fatalf("expected error: duplicated key: user, got: %s", err)
+	}
+}
@@ -0,0 +1,108 @@
+package cookiesignature
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// MarshalText encodes the raw signed value, e.g. to store it in a config file. Unlike MarshalJSON, it doesn't redact the value
+func (v SignedValue) MarshalText() ([]byte, error) {
+	return []byte(v.raw), nil
+}
+
+// UnmarshalText parses the signed value with ParseSignedValue, empty texts give the zero value
+func (v *SignedValue) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*v = SignedValue{}
+		return nil
+	}
+	parsed, err := ParseSignedValue(string(text))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// UnmarshalJSON parses the signed value of a JSON string, so JSON APIs can accept signed values in their requests.
+// JSON outputs stay redacted, respond with a RawSignedValue instead
+func (v *SignedValue) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return v.UnmarshalText([]byte(raw))
+}
+
+// RawSignedValue is a SignedValue that serializes to JSON as the raw signed value, so it round-trips
+// through the requests and responses of JSON APIs. It still formats and logs as a truncated hash
+type RawSignedValue struct {
+	SignedValue
+}
+
+// MarshalJSON encodes the raw signed value as a JSON string
+func (v RawSignedValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.raw)
+}
+
+// Value implements driver.Valuer, the raw signed value is stored, NULL if empty
+func (v SignedValue) Value() (driver.Value, error) {
+	if v.raw == "" {
+		return nil, nil
+	}
+	return v.raw, nil
+}
+
+// Scan implements sql.Scanner, NULL gives the zero value
+func (v *SignedValue) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		return v.UnmarshalText(nil)
+	case string:
+		return v.UnmarshalText([]byte(src))
+	case []byte:
+		return v.UnmarshalText(src)
+	default:
+		return fmt.Errorf("can't scan %T into a signed value", src)
+	}
+}
+
+// claimsJSON encodes Claims as a JSON object, without going through their text methods
+type claimsJSON Claims
+
+// MarshalJSON encodes the claims as a JSON object
+func (c Claims) MarshalJSON() ([]byte, error) {
+	return json.Marshal(claimsJSON(c))
+}
+
+// UnmarshalJSON decodes the claims from a JSON object
+func (c *Claims) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, (*claimsJSON)(c))
+}
+
+// MarshalText encodes the claims as their JSON object, e.g. to store them in a config file or a text column.
+// Claims can't implement driver.Valuer because of their Value field, pass the text to the database instead
+func (c Claims) MarshalText() ([]byte, error) {
+	return c.MarshalJSON()
+}
+
+// UnmarshalText decodes the claims from their JSON object
+func (c *Claims) UnmarshalText(text []byte) error {
+	return c.UnmarshalJSON(text)
+}
+
+// Scan implements sql.Scanner for claims stored as JSON, e.g. in a jsonb column. NULL gives the zero claims
+func (c *Claims) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*c = Claims{}
+		return nil
+	case string:
+		return c.UnmarshalText([]byte(src))
+	case []byte:
+		return c.UnmarshalText(src)
+	default:
+		return fmt.Errorf("can't scan %T into claims", src)
+	}
+}
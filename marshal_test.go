@@ -0,0 +1,97 @@
+package cookiesignature
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var (
+	_ driver.Valuer = SignedValue{}
+	_ sql.Scanner   = &SignedValue{}
+	_ sql.Scanner   = &Claims{}
+)
+
+func TestSignedValueText(t *testing.T) {
+	raw := "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"
+	text, err := NewSignedValue(raw).MarshalText()
+	assertEqual(t, raw, string(text), err)
+
+	var request struct {
+		Session SignedValue `json:"session"`
+	}
+	if err := json.Unmarshal([]byte(`{"session":"`+raw+`"}`), &request); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if request.Session.Raw() != raw || request.Session.Payload() != "hello" {
+		t.Fatalf("unexpected signed value: %s", request.Session.Raw())
+	}
+	encoded, _ := json.Marshal(request)
+	if strings.Contains(string(encoded), "hello") {
+		t.Fatalf("expected the value to be redacted, got: %s", encoded)
+	}
+	// raw signed values round-trip through JSON
+	response := struct {
+		Session RawSignedValue `json:"session"`
+	}{RawSignedValue{request.Session}}
+	encoded, _ = json.Marshal(response)
+	assertEqual(t, `{"session":"`+raw+`"}`, string(encoded), nil)
+	response.Session = RawSignedValue{}
+	if err := json.Unmarshal(encoded, &response); err != nil || response.Session.Raw() != raw || response.Session.Payload() != "hello" {
+		t.Fatalf("unexpected signed value: %s, %v", response.Session.Raw(), err)
+	}
+	if strings.Contains(fmt.Sprintf("%v %+v", response.Session, response), "hello") {
+		t.Fatalf("expected the value to be redacted when formatted")
+	}
+
+	if err := json.Unmarshal([]byte(`{"session":"hello"}`), &request); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+
+	value, err := request.Session.Value()
+	if err != nil || value != raw {
+		t.Fatalf("expected %s, got: %v, %v", raw, value, err)
+	}
+	if value, err := (SignedValue{}).Value(); err != nil || value != nil {
+		t.Fatalf("expected NULL, got: %v, %v", value, err)
+	}
+	var scanned SignedValue
+	for _, src := range []interface{}{raw, []byte(raw)} {
+		if err := scanned.Scan(src); err != nil || scanned.Raw() != raw {
+			t.Fatalf("expected %s, got: %s, %v", raw, scanned.Raw(), err)
+		}
+	}
+	if err := scanned.Scan(nil); err != nil || scanned.Raw() != "" {
+		t.Fatalf("expected the zero value, got: %s, %v", scanned.Raw(), err)
+	}
+	if err := scanned.Scan(42); err == nil {
+		t.Fatalf("expected an error scanning an int")
+	}
+}
+
+func TestClaimsText(t *testing.T) {
+	claims := Claims{Value: "hello", IssuedAt: 1600000000, AuthMethods: []string{"pwd"}}
+	encoded, err := json.Marshal(claims)
+	assertEqual(t, `{"val":"hello","iat":1600000000,"amr":["pwd"]}`, string(encoded), err)
+	text, err := claims.MarshalText()
+	assertEqual(t, string(encoded), string(text), err)
+
+	var scanned Claims
+	for _, src := range []interface{}{string(text), text} {
+		if err := scanned.Scan(src); err != nil || scanned.Value != "hello" || scanned.IssuedAt != 1600000000 {
+			t.Fatalf("unexpected claims: %+v, %v", scanned, err)
+		}
+	}
+	if err := scanned.Scan(nil); err != nil || scanned.Value != "" {
+		t.Fatalf("expected the zero claims, got: %+v, %v", scanned, err)
+	}
+	if err := scanned.Scan(42); err == nil {
+		t.Fatalf("expected an error scanning an int")
+	}
+	if err := scanned.UnmarshalText([]byte("hello")); err == nil {
+		t.Fatalf("expected an error decoding invalid JSON")
+	}
+}
@@ -0,0 +1,39 @@
+package cookiesignature
+
+// MustSign is like Sign but panics if the input can't be signed.
+// It simplifies the initialization of constants and test fixtures
+func (cs CookieSignature) MustSign(input string) string {
+	result, err := cs.Sign(input)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// MustUnsign is like Unsign but panics if the input can't be unsigned.
+// It simplifies the initialization of constants and test fixtures
+func (cs CookieSignature) MustUnsign(input string) string {
+	result, err := cs.Unsign(input)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// MustSign is like Sign but panics if the input can't be signed
+func MustSign(input string, secret []byte) string {
+	result, err := Sign(input, secret)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// MustUnsign is like Unsign but panics if the input can't be unsigned
+func MustUnsign(input string, secret []byte) string {
+	result, err := Unsign(input, secret)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
@@ -0,0 +1,30 @@
+package cookiesignature
+
+import "testing"
+
+func TestMust(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	signed := cs.MustSign("hello")
+	assertEqual(t, "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", signed, nil)
+	assertEqual(t, "hello", cs.MustUnsign(signed), nil)
+	assertEqual(t, signed, MustSign("hello", []byte("tobiiscool")), nil)
+	assertEqual(t, "hello", MustUnsign(signed, []byte("tobiiscool")), nil)
+
+	assertPanic(t, errEmptyUnsignedValue, func() { cs.MustSign("") })
+	assertPanic(t, errInvalidSignature, func() { cs.MustUnsign("hello") })
+	assertPanic(t, errInvalidSignature, func() { MustUnsign(signed, []byte("wrongsecret")) })
+}
+
+func assertPanic(t *testing.T, expected error, fn func()) {
+	t.Helper()
+	defer func() {
+		if got := recover(); got != expected {
+			t.Fatalf("expected panic: %s, got: %v", expected, got)
+		}
+	}()
+	fn()
+}
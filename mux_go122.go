@@ -0,0 +1,95 @@
+//go:build go1.22
+// +build go1.22
+
+package cookiesignature
+
+import (
+	"context"
+	"net/http"
+)
+
+type routeCookiesKey struct{}
+
+// CookiePolicy declares the signed cookies of the routes of a Mux
+type CookiePolicy struct {
+	// Signer verifies the cookies of the routes, e.g. with the key ring of a tenant. Defaults to the signer of the Mux
+	Signer *CookieSignature
+	// Required are the cookies that must be present and valid, requests without them are rejected with 401
+	Required []string
+	// Optional are the cookies verified when present, invalid ones are ignored
+	Optional []string
+}
+
+// Mux registers handlers on an http.ServeMux with the signed cookies their route patterns require,
+// so applications declare their cookie policies next to their routes. Patterns use the syntax of the Go 1.22 ServeMux,
+// e.g. "GET /orders/{id}", which needs a go.mod declaring go 1.22 or later, or GODEBUG=httpmuxgo121=0.
+// The verified values are read with SignedCookieFromContext
+type Mux struct {
+	mux    *http.ServeMux
+	signer *CookieSignature
+}
+
+// NewMux creates a new Mux whose policies verify with the signer unless they set their own
+func NewMux(signer *CookieSignature) *Mux {
+	return &Mux{mux: http.NewServeMux(), signer: signer}
+}
+
+// Handle registers the handler for the pattern behind the policy.
+// It panics like http.ServeMux.Handle if the pattern is invalid or already registered, or if the policy has no signer
+func (m *Mux) Handle(pattern string, handler http.Handler, policy CookiePolicy) {
+	if policy.Signer == nil {
+		policy.Signer = m.signer
+	}
+	if policy.Signer == nil && len(policy.Required)+len(policy.Optional) > 0 {
+		panic("cookiesignature: cookie policy of " + pattern + " has no signer")
+	}
+	m.mux.Handle(pattern, policy.middleware(handler))
+}
+
+// HandleFunc registers the handler function for the pattern behind the policy
+func (m *Mux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request), policy CookiePolicy) {
+	m.Handle(pattern, http.HandlerFunc(handler), policy)
+}
+
+// ServeHTTP dispatches the request to the handler whose pattern matches
+func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mux.ServeHTTP(w, r)
+}
+
+func (policy CookiePolicy) middleware(next http.Handler) http.Handler {
+	if len(policy.Required)+len(policy.Optional) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values := make(map[string]string, len(policy.Required)+len(policy.Optional))
+		for _, name := range policy.Required {
+			value, err := policy.verify(r, name)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			values[name] = value
+		}
+		for _, name := range policy.Optional {
+			if value, err := policy.verify(r, name); err == nil {
+				values[name] = value
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeCookiesKey{}, values)))
+	})
+}
+
+func (policy CookiePolicy) verify(r *http.Request, name string) (string, error) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return policy.Signer.UnsignContext(r.Context(), cookie.Value)
+}
+
+// SignedCookieFromContext returns the value of the named cookie verified by the policy of the Mux route
+func SignedCookieFromContext(ctx context.Context, name string) (string, bool) {
+	values, _ := ctx.Value(routeCookiesKey{}).(map[string]string)
+	value, ok := values[name]
+	return value, ok
+}
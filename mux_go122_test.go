@@ -0,0 +1,76 @@
+//go:build go1.22
+// +build go1.22
+
+//go:debug httpmuxgo121=0
+
+package cookiesignature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMux(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	tenant, _ := NewCookieSignature([]string{"n3wsecr3t"})
+
+	mux := NewMux(cs)
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		session, _ := SignedCookieFromContext(r.Context(), "session")
+		theme, ok := SignedCookieFromContext(r.Context(), "theme")
+		if !ok {
+			theme = "none"
+		}
+		w.Write([]byte(r.PathValue("id") + ":" + session + ":" + theme))
+	}, CookiePolicy{Required: []string{"session"}, Optional: []string{"theme"}})
+	mux.HandleFunc("GET /tenant/", func(w http.ResponseWriter, r *http.Request) {
+		session, _ := SignedCookieFromContext(r.Context(), "session")
+		w.Write([]byte(session))
+	}, CookiePolicy{Signer: tenant, Required: []string{"session"}})
+	mux.HandleFunc("GET /public", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("public"))
+	}, CookiePolicy{})
+
+	serve := func(method string, path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, nil)
+		for _, cookie := range cookies {
+			request.AddCookie(cookie)
+		}
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	session := &http.Cookie{Name: "session", Value: "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"}
+	if recorder := serve(http.MethodGet, "/orders/42", session, &http.Cookie{Name: "theme", Value: "dark.invalid"}); recorder.Code != http.StatusOK || recorder.Body.String() != "42:hello:none" {
+		t.Fatalf("unexpected response: %d %s", recorder.Code, recorder.Body)
+	}
+	if recorder := serve(http.MethodGet, "/orders/42", session, &http.Cookie{Name: "theme", Value: cs.MustSign("dark")}); recorder.Body.String() != "42:hello:dark" {
+		t.Fatalf("unexpected response: %s", recorder.Body)
+	}
+	if recorder := serve(http.MethodGet, "/orders/42"); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status: %d, got: %d", http.StatusUnauthorized, recorder.Code)
+	}
+	if recorder := serve(http.MethodPost, "/orders/42", session); recorder.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status: %d, got: %d", http.StatusMethodNotAllowed, recorder.Code)
+	}
+
+	// the tenant routes only accept the cookies of the tenant key ring
+	if recorder := serve(http.MethodGet, "/tenant/a", session); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status: %d, got: %d", http.StatusUnauthorized, recorder.Code)
+	}
+	if recorder := serve(http.MethodGet, "/tenant/a", &http.Cookie{Name: "session", Value: "hello.fJDsH8b7iNvcQdwtuhE29LZUFMorBk6MOzotVfMoiOc"}); recorder.Body.String() != "hello" {
+		t.Fatalf("unexpected response: %s", recorder.Body)
+	}
+	if recorder := serve(http.MethodGet, "/public"); recorder.Body.String() != "public" {
+		t.Fatalf("unexpected response: %s", recorder.Body)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a policy without signer")
+		}
+	}()
+	NewMux(nil).Handle("/", http.NotFoundHandler(), CookiePolicy{Required: []string{"session"}})
+}
@@ -0,0 +1,83 @@
+package cookiesignature
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// WithNegativeCache caches up to size inputs that failed to verify for ttl, so bots replaying the same invalid cookie
+// don't cost a full pass of MACs over every key each time. A cached input keeps failing until it expires,
+// even if a key able to verify it is added meanwhile, so keep the ttl short
+func WithNegativeCache(size int, ttl time.Duration) Option {
+	return func(o *options) {
+		if size <= 0 || ttl <= 0 {
+			o.negativeCache = nil
+			return
+		}
+		o.negativeCache = &negativeCache{
+			size:    size,
+			ttl:     ttl,
+			entries: make(map[[sha256.Size]byte]*list.Element, size),
+			order:   list.New(),
+		}
+	}
+}
+
+// negativeCache is a bounded cache of failed inputs, evicting the oldest entry when full
+type negativeCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[[sha256.Size]byte]*list.Element
+	order   *list.List
+}
+
+type negativeCacheEntry struct {
+	key       [sha256.Size]byte
+	err       error
+	expiresAt time.Time
+}
+
+// get returns the cached error of the input, nil if none
+func (c *negativeCache) get(input string) error {
+	if c == nil {
+		return nil
+	}
+	key := sha256.Sum256([]byte(input))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := element.Value.(*negativeCacheEntry)
+	if !timeNow().Before(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil
+	}
+	return entry.err
+}
+
+func (c *negativeCache) add(input string, err error) {
+	if c == nil {
+		return
+	}
+	key := sha256.Sum256([]byte(input))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+	for c.order.Len() >= c.size {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*negativeCacheEntry).key)
+	}
+	c.entries[key] = c.order.PushBack(&negativeCacheEntry{key: key, err: err, expiresAt: timeNow().Add(c.ttl)})
+}
@@ -0,0 +1,52 @@
+package cookiesignature
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNegativeCache(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	computed := 0
+	provider := macProviderFunc(func(input []byte) ([]byte, error) {
+		computed++
+		return computeHMAC256(string(input), []byte("tobiiscool"))
+	})
+	cs, _ := NewCookieSignatureFromMAC([]MACProvider{provider}, WithNegativeCache(2, time.Minute))
+
+	for i := 0; i < 3; i++ {
+		if _, err := cs.Unsign("hello.invalid"); err != errInvalidSignature {
+			t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+		}
+	}
+	if computed != 1 {
+		t.Fatalf("expected a single MAC computation, got: %d", computed)
+	}
+
+	// the oldest entry is evicted when the cache is full
+	_, _ = cs.Unsign("foo.invalid")
+	_, _ = cs.Unsign("bar.invalid")
+	computed = 0
+	_, _ = cs.Unsign("hello.invalid")
+	_, _ = cs.Unsign("bar.invalid")
+	if computed != 1 {
+		t.Fatalf("expected a single MAC computation, got: %d", computed)
+	}
+
+	// entries expire
+	now = now.Add(time.Minute)
+	computed = 0
+	_, _ = cs.Unsign("bar.invalid")
+	if computed != 1 {
+		t.Fatalf("expected a single MAC computation, got: %d", computed)
+	}
+
+	signed, _ := cs.Sign("hello")
+	for i := 0; i < 2; i++ {
+		result, err := cs.Unsign(signed)
+		assertEqual(t, "hello", result, err)
+	}
+}
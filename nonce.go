@@ -0,0 +1,89 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTokenUsed is returned when a single-use token is used again
+var ErrTokenUsed = errors.New("token was already used")
+
+// NonceStore remembers the nonces of single-use tokens until they expire
+type NonceStore interface {
+	// Use marks the nonce as used until expiresAt and reports whether it was unused, atomically
+	Use(ctx context.Context, nonce string, expiresAt time.Time) (bool, error)
+}
+
+// MemoryNonceStore is an in-memory NonceStore for single instance deployments
+type MemoryNonceStore struct {
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+// NewMemoryNonceStore creates a new MemoryNonceStore instance
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{used: make(map[string]time.Time)}
+}
+
+// Use marks the nonce as used until expiresAt and reports whether it was unused. Entries are forgotten after they expire
+func (ns *MemoryNonceStore) Use(_ context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	now := timeNow()
+	for key, exp := range ns.used {
+		if !exp.After(now) {
+			delete(ns.used, key)
+		}
+	}
+	if _, ok := ns.used[nonce]; ok {
+		return false, nil
+	}
+	ns.used[nonce] = expiresAt
+	return true, nil
+}
+
+// RedisNonceClient is the Redis command used by RedisNonceStore
+type RedisNonceClient interface {
+	// SetNX sets the key to the value with an expiration if it doesn't exist, and reports whether it was set
+	SetNX(ctx context.Context, key string, value string, expiration time.Duration) (bool, error)
+}
+
+// RedisNonceStore is a NonceStore backed by Redis, shared by every instance of a deployment
+type RedisNonceStore struct {
+	Client RedisNonceClient
+	// Prefix of the Redis keys. Defaults to "nonce:"
+	Prefix string
+}
+
+// Use marks the nonce as used until expiresAt and reports whether it was unused
+func (ns RedisNonceStore) Use(ctx context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	ttl := expiresAt.Sub(timeNow())
+	if ttl <= 0 {
+		// expired tokens are rejected before their nonce is used
+		ttl = time.Second
+	}
+	prefix := ns.Prefix
+	if prefix == "" {
+		prefix = "nonce:"
+	}
+	return ns.Client.SetNX(ctx, prefix+nonce, "1", ttl)
+}
+
+// useNonce consumes the nonce of a single-use token, if a store is provided.
+// The nonce is kept as long as the token verifies, until its expiration plus the leeway
+func (cs CookieSignature) useNonce(ctx context.Context, nonces NonceStore, nonce string, expiresAt int64) error {
+	if nonces == nil {
+		return nil
+	}
+	unused, err := nonces.Use(ctx, nonce, time.Unix(expiresAt, 0).Add(cs.opts.leeway))
+	if err != nil {
+		return err
+	}
+	if !unused {
+		return ErrTokenUsed
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package cookiesignature
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type testRedisNonceClient struct {
+	keys map[string]time.Duration
+}
+
+func (c *testRedisNonceClient) SetNX(_ context.Context, key string, _ string, expiration time.Duration) (bool, error) {
+	if _, ok := c.keys[key]; ok {
+		return false, nil
+	}
+	c.keys[key] = expiration
+	return true, nil
+}
+
+func TestNonceStores(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	client := &testRedisNonceClient{keys: map[string]time.Duration{}}
+	for _, store := range []NonceStore{NewMemoryNonceStore(), RedisNonceStore{Client: client}} {
+		for i, expected := range []bool{true, false} {
+			unused, err := store.Use(context.Background(), "n1", now.Add(time.Minute))
+			if err != nil || unused != expected {
+				t.Fatalf("%T use %d: expected: %t, got: %t, %v", store, i, expected, unused, err)
+			}
+		}
+	}
+	if client.keys["nonce:n1"] != time.Minute {
+		t.Fatalf("expected the nonce to expire with the token, got: %v", client.keys)
+	}
+
+	memory := NewMemoryNonceStore()
+	_, _ = memory.Use(context.Background(), "n1", now.Add(time.Minute))
+	now = now.Add(time.Minute)
+	if unused, _ := memory.Use(context.Background(), "n2", now.Add(time.Minute)); !unused || len(memory.used) != 1 {
+		t.Fatalf("expected the expired nonce to be forgotten, got: %v", memory.used)
+	}
+}
@@ -0,0 +1,84 @@
+package cookiesignature
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	oauthNonceLength  = 16
+	oauthStatePurpose = "oauth-state"
+)
+
+// ErrNonceMismatch is returned when an OAuth state comes back to another browser than the one that started the flow
+var ErrNonceMismatch = errors.New("state nonce mismatch")
+
+var errUnsafeRedirect = errors.New("redirect target must be a local path")
+
+// OAuthState is the payload of a signed OAuth state parameter. Times are unix timestamps in seconds
+type OAuthState struct {
+	// RedirectTo is the local path the user returns to once the flow completes, e.g. /settings
+	RedirectTo string `json:"rt,omitempty"`
+	// Nonce binds the state to the browser that started the flow
+	Nonce     string `json:"nnc"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// SignOAuthState signs a state parameter for an authorization request, with a random nonce and the redirect target.
+// The nonce must be kept in the session of the browser, e.g. in a cookie, and given back to UnsignOAuthState,
+// so a state issued to an attacker can't be replayed by a victim (login CSRF).
+// The redirect target must be a local path, so the state can't be turned into an open redirect
+func (cs CookieSignature) SignOAuthState(redirectTo string, ttl time.Duration) (state string, nonce string, err error) {
+	if !isLocalPath(redirectTo) {
+		return "", "", errUnsafeRedirect
+	}
+	if ttl <= 0 {
+		return "", "", errors.New("state ttl must be positive")
+	}
+	nonce, err = randomBase64(oauthNonceLength)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := timeNow()
+	state, err = cs.signPurposeJSON(oauthStatePurpose, OAuthState{
+		RedirectTo: redirectTo,
+		Nonce:      nonce,
+		IssuedAt:   now.Unix(),
+		ExpiresAt:  now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return state, nonce, nil
+}
+
+// UnsignOAuthState verifies the state parameter received by the callback, checks that it didn't expire
+// and that its nonce matches the nonce kept in the session of the browser
+func (cs CookieSignature) UnsignOAuthState(state string, nonce string) (OAuthState, error) {
+	var result OAuthState
+	if err := cs.unsignPurposeJSON(oauthStatePurpose, state, &result); err != nil {
+		return OAuthState{}, err
+	}
+	if err := cs.validateClaims(Claims{IssuedAt: result.IssuedAt, ExpiresAt: result.ExpiresAt}, verifyOptions{}); err != nil {
+		return OAuthState{}, err
+	}
+	if nonce == "" || !EqualString(result.Nonce, nonce) {
+		return OAuthState{}, ErrNonceMismatch
+	}
+	if !isLocalPath(result.RedirectTo) {
+		return OAuthState{}, errUnsafeRedirect
+	}
+	return result, nil
+}
+
+// isLocalPath reports whether the target is empty or an absolute path of the same host.
+// Scheme-relative (//host) and backslash (/\host) targets are rejected, browsers treat both as other hosts
+func isLocalPath(target string) bool {
+	if target == "" {
+		return true
+	}
+	return strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "//") && !strings.HasPrefix(target, `/\`)
+}
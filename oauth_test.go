@@ -0,0 +1,52 @@
+package cookiesignature
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOAuthState(t *testing.T) {
+	timeNow = func() time.Time { return time.Unix(1600000000, 0) }
+	defer func() { timeNow = time.Now }()
+
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	for _, target := range []string{"https://evil.example.com", "//evil.example.com", `/\evil.example.com`, "settings"} {
+		if _, _, err := cs.SignOAuthState(target, time.Minute); err != errUnsafeRedirect {
+			t.Fatalf("%s: expected error: %s, got: %v", target, errUnsafeRedirect, err)
+		}
+	}
+
+	state, nonce, err := cs.SignOAuthState("/settings?tab=profile", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	other, otherNonce, _ := cs.SignOAuthState("/", 10*time.Minute)
+	if other == state || otherNonce == nonce {
+		t.Fatalf("expected random nonces")
+	}
+
+	result, err := cs.UnsignOAuthState(state, nonce)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if result.RedirectTo != "/settings?tab=profile" || result.ExpiresAt != 1600000600 {
+		t.Fatalf("unexpected state: %+v", result)
+	}
+	forged, _ := cs.SignJSON(OAuthState{RedirectTo: "/", Nonce: nonce, IssuedAt: 1600000000, ExpiresAt: 1600000600})
+	if _, err := cs.UnsignOAuthState(forged, nonce); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	for _, n := range []string{otherNonce, ""} {
+		if _, err := cs.UnsignOAuthState(state, n); err != ErrNonceMismatch {
+			t.Fatalf("expected error: %s, got: %v", ErrNonceMismatch, err)
+		}
+	}
+	if _, err := cs.UnsignOAuthState(state+"x", nonce); err == nil {
+		t.Fatalf("expected an invalid signature error")
+	}
+
+	timeNow = func() time.Time { return time.Unix(1600000600, 0) }
+	if _, err := cs.UnsignOAuthState(state, nonce); err != ErrTokenExpired {
+		t.Fatalf("expected error: %s, got: %v", ErrTokenExpired, err)
+	}
+}
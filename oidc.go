@@ -0,0 +1,125 @@
+package cookiesignature
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultAuthFlowCookieName = "auth_flow"
+	authFlowPurpose           = "auth-flow"
+	// pkceVerifierLength encodes to 43 characters, the minimum length of a code verifier
+	pkceVerifierLength = 32
+)
+
+// AuthFlow holds the secrets of an OpenID Connect authorization code flow with PKCE
+type AuthFlow struct {
+	// State is the signed state parameter of the authorization request
+	State string
+	// Nonce is the nonce parameter of the authorization request, to check against the nonce claim of the ID token
+	Nonce string
+	// CodeVerifier is sent with the token request, its challenge with the authorization request
+	CodeVerifier string
+	// RedirectTo is the local path the user returns to once the flow completes
+	RedirectTo string
+}
+
+type authFlowCookie struct {
+	StateNonce   string `json:"sn"`
+	Nonce        string `json:"nnc"`
+	CodeVerifier string `json:"cv"`
+	ExpiresAt    int64  `json:"exp"`
+}
+
+// CodeChallenge returns the S256 code challenge of the code verifier, sent with code_challenge_method=S256
+func (f AuthFlow) CodeChallenge() string {
+	sum := sha256.Sum256([]byte(f.CodeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// StartAuthFlow generates the state, nonce and code verifier of a new flow, and keeps them in a signed cookie
+// that expires after ttl, so the callback can only complete the flow in the browser that started it.
+// The cookie attributes are copied from the template, its name defaults to "auth_flow" and its SameSite mode to lax,
+// which lets the cookie through the top-level redirect back from the provider.
+// Use SameSite none with the form_post response mode, browsers don't send lax cookies with cross-site POSTs
+func (cs CookieSignature) StartAuthFlow(w http.ResponseWriter, template http.Cookie, redirectTo string, ttl time.Duration) (AuthFlow, error) {
+	state, stateNonce, err := cs.SignOAuthState(redirectTo, ttl)
+	if err != nil {
+		return AuthFlow{}, err
+	}
+	nonce, err := randomBase64(oauthNonceLength)
+	if err != nil {
+		return AuthFlow{}, err
+	}
+	verifier, err := randomBase64(pkceVerifierLength)
+	if err != nil {
+		return AuthFlow{}, err
+	}
+
+	value, err := cs.signPurposeJSON(authFlowPurpose, authFlowCookie{
+		StateNonce:   stateNonce,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    timeNow().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return AuthFlow{}, err
+	}
+	cookie := authFlowCookieOf(template)
+	cookie.Value = value
+	cookie.MaxAge = int(ttl / time.Second)
+	http.SetCookie(w, &cookie)
+
+	return AuthFlow{State: state, Nonce: nonce, CodeVerifier: verifier, RedirectTo: redirectTo}, nil
+}
+
+// ConsumeAuthFlow verifies the state parameter of the callback request against the flow cookie and returns the flow.
+// The cookie is cleared in every case, so a flow can only be completed once
+func (cs CookieSignature) ConsumeAuthFlow(w http.ResponseWriter, r *http.Request, template http.Cookie) (AuthFlow, error) {
+	cookie := authFlowCookieOf(template)
+	received, err := r.Cookie(cookie.Name)
+
+	cookie.Expires = time.Unix(0, 0)
+	cookie.MaxAge = -1
+	http.SetCookie(w, &cookie)
+	if err != nil {
+		return AuthFlow{}, err
+	}
+
+	var flow authFlowCookie
+	if err := cs.unsignPurposeJSON(authFlowPurpose, received.Value, &flow); err != nil {
+		return AuthFlow{}, err
+	}
+	if err := cs.validateClaims(Claims{ExpiresAt: flow.ExpiresAt}, verifyOptions{}); err != nil {
+		return AuthFlow{}, err
+	}
+
+	// form_post callbacks send the state in the body
+	state := r.FormValue("state")
+	if state == "" {
+		return AuthFlow{}, errors.New("state parameter is missing")
+	}
+	result, err := cs.UnsignOAuthState(state, flow.StateNonce)
+	if err != nil {
+		return AuthFlow{}, err
+	}
+	return AuthFlow{State: state, Nonce: flow.Nonce, CodeVerifier: flow.CodeVerifier, RedirectTo: result.RedirectTo}, nil
+}
+
+func authFlowCookieOf(template http.Cookie) http.Cookie {
+	cookie := template
+	if cookie.Name == "" {
+		cookie.Name = defaultAuthFlowCookieName
+	}
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if cookie.SameSite == 0 {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	cookie.HttpOnly = true
+	return cookie
+}
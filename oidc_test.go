@@ -0,0 +1,67 @@
+package cookiesignature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestAuthFlow(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	template := http.Cookie{Secure: true}
+
+	w := httptest.NewRecorder()
+	flow, err := cs.StartAuthFlow(w, template, "/settings", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(flow.CodeVerifier) != 43 || flow.Nonce == "" || flow.State == "" {
+		t.Fatalf("unexpected flow: %+v", flow)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "auth_flow" || !cookies[0].HttpOnly || !cookies[0].Secure || cookies[0].MaxAge != 600 || cookies[0].SameSite != http.SameSiteLaxMode {
+		t.Fatalf("unexpected cookies: %+v", cookies)
+	}
+
+	challenge := AuthFlow{CodeVerifier: "dBjftJeZ4CVP-mJ0kSZQ6y8AL4rD6X4bMPMNSzVpdDk"}.CodeChallenge()
+	if challenge != "fXva0MIaZzetUzXJ1CpME_QtmOrXaF4UvVmH0SXK4BQ" {
+		t.Fatalf("expected: fXva0MIaZzetUzXJ1CpME_QtmOrXaF4UvVmH0SXK4BQ, got: %s", challenge)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/callback?code=abc&state="+url.QueryEscape(flow.State), nil)
+	r.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	consumed, err := cs.ConsumeAuthFlow(w, r, template)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if consumed != flow {
+		t.Fatalf("expected: %+v, got: %+v", flow, consumed)
+	}
+	if cleared := w.Result().Cookies(); len(cleared) != 1 || cleared[0].MaxAge != -1 {
+		t.Fatalf("expected the cookie to be cleared, got: %+v", cleared)
+	}
+
+	// a state started in another browser
+	other, _ := cs.StartAuthFlow(httptest.NewRecorder(), template, "/", 10*time.Minute)
+	r = httptest.NewRequest(http.MethodGet, "/callback?code=abc&state="+url.QueryEscape(other.State), nil)
+	r.AddCookie(cookies[0])
+	if _, err := cs.ConsumeAuthFlow(httptest.NewRecorder(), r, template); err != ErrNonceMismatch {
+		t.Fatalf("expected error: %s, got: %v", ErrNonceMismatch, err)
+	}
+
+	// another JSON token with the fields of the flow cookie
+	forged, _ := cs.SignJSON(authFlowCookie{StateNonce: "x", Nonce: "y", CodeVerifier: "z", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	r = httptest.NewRequest(http.MethodGet, "/callback?code=abc&state="+url.QueryEscape(flow.State), nil)
+	r.AddCookie(&http.Cookie{Name: "auth_flow", Value: forged})
+	if _, err := cs.ConsumeAuthFlow(httptest.NewRecorder(), r, template); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/callback?code=abc&state="+url.QueryEscape(flow.State), nil)
+	if _, err := cs.ConsumeAuthFlow(httptest.NewRecorder(), r, template); err != http.ErrNoCookie {
+		t.Fatalf("expected error: %s, got: %v", http.ErrNoCookie, err)
+	}
+}
@@ -0,0 +1,93 @@
+package cookiesignature
+
+import (
+	"crypto"
+	"time"
+)
+
+// Option configures optional behaviors of CookieSignature
+type Option func(*options)
+
+type options struct {
+	leeway            time.Duration
+	revocationChecker RevocationChecker
+	parseMode         ParseMode
+	urlEncoding       bool
+	hooks             []Hook
+	trimSecrets       bool
+	secretEncoding    SecretEncoding
+	environment       string
+	subkeyLabels      []string
+	negativeCache     *negativeCache
+	revokedSignatures RevocationChecker
+	signCache         *signCache
+	noBufferPool      bool
+	base64Codec       Base64Codec
+	safeValues        bool
+	algorithmTag      bool
+	allowedAlgorithms map[Algorithm]bool
+	canonicalJSON     bool
+	hash              crypto.Hash
+	legacySHA1        bool
+	keyedBLAKE2b      bool
+	// err is returned by the constructors, for options that can fail
+	err error
+}
+
+// ParseMode governs how strictly Unsign parses the signature of the input
+type ParseMode int
+
+const (
+	// ParseLenient accepts signatures with or without padding, in either the standard or the url-safe base64 alphabet,
+	// and surrounded by whitespace. It is the default mode
+	ParseLenient ParseMode = iota
+	// ParseStrict only accepts signatures that are byte-identical to the output of Sign
+	ParseStrict
+)
+
+// WithParseMode sets how strictly Unsign parses signatures
+func WithParseMode(mode ParseMode) Option {
+	return func(o *options) {
+		o.parseMode = mode
+	}
+}
+
+// WithLeeway sets the tolerated clock skew applied to the time claims of timed tokens,
+// so tokens minted by peers whose clock is slightly ahead or behind aren't rejected
+func WithLeeway(leeway time.Duration) Option {
+	return func(o *options) {
+		if leeway < 0 {
+			leeway = -leeway
+		}
+		o.leeway = leeway
+	}
+}
+
+// VerifyOption configures the validation of a single timed token
+type VerifyOption func(*verifyOptions)
+
+type verifyOptions struct {
+	maxAge time.Duration
+	minAge time.Duration
+	device *string
+	// minAuthLevel and authMethods are required by step-up checks
+	minAuthLevel int
+	authMethods  []string
+	// generation returns the current session generation of the account
+	generation GenerationFunc
+}
+
+// WithMaxAge requires the timed token to be issued at most maxAge ago,
+// so sensitive endpoints can demand a fresh authentication
+func WithMaxAge(maxAge time.Duration) VerifyOption {
+	return func(o *verifyOptions) {
+		o.maxAge = maxAge
+	}
+}
+
+// WithMinAge requires the timed token to be issued at least minAge ago
+func WithMinAge(minAge time.Duration) VerifyOption {
+	return func(o *verifyOptions) {
+		o.minAge = minAge
+	}
+}
@@ -0,0 +1,110 @@
+package cookiesignature
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	defaultScryptN         = 1 << 15
+	defaultScryptR         = 8
+	defaultScryptP         = 1
+	defaultScryptKeyLength = 32
+	scryptSaltLength       = 16
+)
+
+// ScryptParams are the scrypt parameters deriving a key from a passphrase.
+// They are persisted with the key instead of its secret, the secret is derived again from the passphrase on load
+type ScryptParams struct {
+	Salt      []byte `json:"salt"`
+	N         int    `json:"n"`
+	R         int    `json:"r"`
+	P         int    `json:"p"`
+	KeyLength int    `json:"key_length"`
+}
+
+// NewScryptParams returns the recommended scrypt parameters with a random salt
+func NewScryptParams() (ScryptParams, error) {
+	salt := make([]byte, scryptSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return ScryptParams{}, err
+	}
+	return ScryptParams{
+		Salt:      salt,
+		N:         defaultScryptN,
+		R:         defaultScryptR,
+		P:         defaultScryptP,
+		KeyLength: defaultScryptKeyLength,
+	}, nil
+}
+
+// Derive derives the key from the passphrase
+func (p ScryptParams) Derive(passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase must not be empty")
+	}
+	if len(p.Salt) == 0 {
+		return nil, errors.New("scrypt salt must not be empty")
+	}
+	if p.KeyLength <= 0 {
+		return nil, errors.New("scrypt key length must be positive")
+	}
+	return scrypt.Key([]byte(passphrase), p.Salt, p.N, p.R, p.P, p.KeyLength)
+}
+
+// NewPassphraseKey creates a pending key whose secret is derived from the passphrase with scrypt
+func NewPassphraseKey(id string, passphrase string, params ScryptParams) (Key, error) {
+	secret, err := params.Derive(passphrase)
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{
+		ID:        id,
+		Secret:    secret,
+		State:     KeyPending,
+		CreatedAt: timeNow(),
+		Scrypt:    &params,
+	}, nil
+}
+
+// DerivePassphraseKeys derives the secrets of the passphrase keys loaded from a KeyStore, which only stores their parameters
+func DerivePassphraseKeys(keys []Key, passphrase string) ([]Key, error) {
+	result := copyKeys(keys)
+	for i, key := range result {
+		if key.Scrypt == nil || len(key.Secret) > 0 {
+			continue
+		}
+		secret, err := key.Scrypt.Derive(passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", key.ID, err)
+		}
+		result[i].Secret = secret
+	}
+	return result, nil
+}
+
+// NewCookieSignatureFromPassphrase creates a new CookieSignature instance whose secret is derived from the passphrase,
+// for small deployments that only have a human-memorable secret. The parameters must be persisted to derive the same secret again
+func NewCookieSignatureFromPassphrase(passphrase string, params ScryptParams, opts ...Option) (*CookieSignature, error) {
+	key, err := NewPassphraseKey("passphrase", passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+	key.State = KeyActive
+	keyRing, err := NewKeyRing(key)
+	if err != nil {
+		return nil, err
+	}
+	return NewCookieSignatureFromKeyRing(keyRing, opts...)
+}
+
+// derivePassphraseKeys derives the secrets of the passphrase keys loaded by a key store, if a passphrase is configured
+func derivePassphraseKeys(keys []Key, passphrase string) ([]Key, error) {
+	if passphrase == "" {
+		return keys, nil
+	}
+	return DerivePassphraseKeys(keys, passphrase)
+}
@@ -0,0 +1,113 @@
+package cookiesignature
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestPassphraseKeys(t *testing.T) {
+	// scrypt test vector of RFC 7914
+	params := ScryptParams{Salt: []byte("NaCl"), N: 1024, R: 8, P: 16, KeyLength: 64}
+	derived, err := params.Derive("password")
+	assertEqual(t, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640", hex.EncodeToString(derived), err)
+
+	params = ScryptParams{Salt: []byte("pepper"), N: 16, R: 8, P: 1, KeyLength: 32}
+	cs, err := NewCookieSignatureFromPassphrase("correct horse battery staple", params)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	signed, err := cs.Sign("hello")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	key := cs.keyRing.Active()
+	encoded, err := encodeKeys([]Key{key})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if strings.Contains(string(encoded), `"secret":"`) || !strings.Contains(string(encoded), `"salt":"cGVwcGVy"`) {
+		t.Fatalf("expected the secret to be omitted, got: %s", encoded)
+	}
+
+	keys, err := decodeKeys(encoded)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if _, err := NewKeyRing(keys...); err == nil {
+		t.Fatalf("expected the secret to be missing before the derivation")
+	}
+	keys, err = DerivePassphraseKeys(keys, "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	keyRing, err := NewKeyRing(keys...)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	restored, _ := NewCookieSignatureFromKeyRing(keyRing)
+	result, err := restored.Unsign(signed)
+	assertEqual(t, "hello", result, err)
+
+	if _, err := NewCookieSignatureFromPassphrase("", params); err == nil || err.Error() != "passphrase must not be empty" {
+		t.Fatalf("expected error: passphrase must not be empty, got: %s", err)
+	}
+
+	generated, err := NewScryptParams()
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(generated.Salt) != scryptSaltLength || generated.N != defaultScryptN {
+		t.Fatalf("unexpected params: %+v", generated)
+	}
+}
+
+func TestPassphraseKeyDistribution(t *testing.T) {
+	ctx := context.Background()
+	params := ScryptParams{Salt: []byte("pepper"), N: 16, R: 8, P: 1, KeyLength: 32}
+	key, _ := NewPassphraseKey("k1", "correct horse battery staple", params)
+	key.State = KeyActive
+	keys := []Key{{ID: "k0", Secret: []byte("tobiiscool"), State: KeyVerifyOnly}, key}
+
+	// pub/sub updates carry the derived secrets
+	client := &testRedisPubSub{}
+	messages, _ := client.Subscribe(ctx, defaultKeyDistributionChannel)
+	leaderRing, _ := NewKeyRing(keys...)
+	if err := (&RedisKeyDistributor{Client: client, KeyRing: leaderRing}).Publish(ctx); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	replicaRing, _ := NewKeyRing(Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive})
+	if err := (&RedisKeyDistributor{Client: client, KeyRing: replicaRing}).apply(<-messages); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if active := replicaRing.Active(); active.ID != "k1" || string(active.Secret) != string(key.Secret) {
+		t.Fatalf("unexpected active key: %+v", active)
+	}
+
+	// key stores only keep the scrypt parameters, and derive the secrets with their passphrase
+	backend := &testKVBackend{}
+	if err := (&KVKeyStore{Backend: backend}).Save(ctx, keys); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if stored, _ := backend.Get(ctx, defaultKVKeyStoreKey); strings.Contains(string(stored.Value), `"secret":"`+base64.StdEncoding.EncodeToString(key.Secret)) {
+		t.Fatalf("expected the secret to be omitted, got: %s", stored.Value)
+	}
+	if err := (&KVKeyStore{Backend: backend}).Healthy(ctx); err == nil {
+		t.Fatalf("expected the keys to be unusable without the passphrase")
+	}
+	store := &KVKeyStore{Backend: backend, Passphrase: "correct horse battery staple"}
+	if err := store.Healthy(ctx); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	watchedRing, _ := NewKeyRing(Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive})
+	entry, _ := backend.Get(ctx, defaultKVKeyStoreKey)
+	if err := store.apply(watchedRing, entry); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if active := watchedRing.Active(); active.ID != "k1" || string(active.Secret) != string(key.Secret) {
+		t.Fatalf("unexpected active key: %+v", active)
+	}
+}
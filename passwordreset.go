@@ -0,0 +1,84 @@
+package cookiesignature
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const passwordResetPurpose = "password-reset"
+
+type passwordResetClaims struct {
+	UserID    string `json:"uid"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// SignPasswordReset signs a password reset token for the user, valid for ttl.
+// The password fingerprint is any value that changes with the credentials of the user, e.g. the password hash.
+// It's covered by the MAC without being part of the token, so every outstanding token of the user
+// becomes invalid the moment the password changes, including the token that changed it, with no server-side state
+func (cs CookieSignature) SignPasswordReset(userID string, passwordFingerprint string, ttl time.Duration) (string, error) {
+	if userID == "" {
+		return "", errors.New("user id must not be empty")
+	}
+	if ttl <= 0 {
+		return "", errors.New("token ttl must be positive")
+	}
+
+	now := timeNow()
+	claims, err := json.Marshal(passwordResetClaims{UserID: userID, IssuedAt: now.Unix(), ExpiresAt: now.Add(ttl).Unix()})
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+	hashBytes, err := cs.signingMAC(passwordResetMACInput(payload, passwordFingerprint))
+	if err != nil {
+		return "", err
+	}
+	return cs.encodeOutput(payload + "." + hashBase64(hashBytes)), nil
+}
+
+// UnsignPasswordReset verifies the password reset token against the current password fingerprint of its user,
+// looked up by passwordFingerprint, checks that it didn't expire and returns the user ID
+func (cs CookieSignature) UnsignPasswordReset(ctx context.Context, token string, passwordFingerprint func(ctx context.Context, userID string) (string, error)) (string, error) {
+	if token == "" {
+		return "", errEmptySignedValue
+	}
+	if decoded, ok := cs.decodeInput(token); ok {
+		token = decoded
+	}
+
+	index := strings.LastIndex(token, ".")
+	if index < 0 {
+		return "", errInvalidSignature
+	}
+	payload := token[:index]
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", errInvalidSignature
+	}
+	var claims passwordResetClaims
+	if err := json.Unmarshal(data, &claims); err != nil || claims.UserID == "" {
+		return "", errInvalidSignature
+	}
+
+	fingerprint, err := passwordFingerprint(ctx, claims.UserID)
+	if err != nil {
+		return "", err
+	}
+	if _, err := cs.unsign(passwordResetMACInput(payload, fingerprint) + token[index:]); err != nil {
+		return "", err
+	}
+	if err := cs.validateClaims(Claims{IssuedAt: claims.IssuedAt, ExpiresAt: claims.ExpiresAt}, verifyOptions{}); err != nil {
+		return "", err
+	}
+	return claims.UserID, nil
+}
+
+func passwordResetMACInput(payload string, passwordFingerprint string) string {
+	return encodeValues([]string{passwordResetPurpose, payload, passwordFingerprint})
+}
@@ -0,0 +1,50 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPasswordReset(t *testing.T) {
+	timeNow = func() time.Time { return time.Unix(1600000000, 0) }
+	defer func() { timeNow = time.Now }()
+
+	passwords := map[string]string{"42": "$2a$10$old"}
+	lookup := func(_ context.Context, userID string) (string, error) {
+		hash, ok := passwords[userID]
+		if !ok {
+			return "", errors.New("user not found")
+		}
+		return hash, nil
+	}
+
+	ctx := context.Background()
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	token, err := cs.SignPasswordReset("42", passwords["42"], time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	other, _ := cs.SignPasswordReset("42", passwords["42"], 2*time.Hour)
+
+	userID, err := cs.UnsignPasswordReset(ctx, token, lookup)
+	assertEqual(t, "42", userID, err)
+	if _, err := cs.UnsignPasswordReset(ctx, token[1:], lookup); err == nil {
+		t.Fatalf("expected an invalid token error")
+	}
+
+	// the password change invalidates every outstanding token
+	passwords["42"] = "$2a$10$new"
+	for _, tok := range []string{token, other} {
+		if _, err := cs.UnsignPasswordReset(ctx, tok, lookup); err != errInvalidSignature {
+			t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+		}
+	}
+
+	fresh, _ := cs.SignPasswordReset("42", passwords["42"], time.Hour)
+	timeNow = func() time.Time { return time.Unix(1600003600, 0) }
+	if _, err := cs.UnsignPasswordReset(ctx, fresh, lookup); err != ErrTokenExpired {
+		t.Fatalf("expected error: %s, got: %v", ErrTokenExpired, err)
+	}
+}
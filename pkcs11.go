@@ -0,0 +1,40 @@
+package cookiesignature
+
+import "sync"
+
+// PKCS11MechanismSHA256HMAC is the CKM_SHA256_HMAC mechanism of PKCS #11
+const PKCS11MechanismSHA256HMAC uint = 0x251
+
+// PKCS11Session is the subset of a PKCS #11 session used by PKCS11MAC, bound to a session handle.
+// It keeps this package free of a cgo dependency, an adapter of the SignInit and Sign calls of miekg/pkcs11 only takes a few lines
+type PKCS11Session interface {
+	// SignInit initializes a signing operation with the mechanism and the handle of the key object
+	SignInit(mechanism uint, key uint) error
+	// Sign signs the data in a single part and finishes the operation
+	Sign(data []byte) ([]byte, error)
+}
+
+// PKCS11MAC is a MACProvider computing HMAC-SHA256 on a PKCS #11 token, e.g. an HSM,
+// so the cookie signing key is never exportable. A session runs one operation at a time, so calls are serialized,
+// use one PKCS11MAC per session to sign concurrently
+type PKCS11MAC struct {
+	session PKCS11Session
+	key     uint
+	mu      sync.Mutex
+}
+
+// NewPKCS11MAC creates a new PKCS11MAC instance with the handle of a CKK_GENERIC_SECRET or CKK_SHA256_HMAC key object
+func NewPKCS11MAC(session PKCS11Session, key uint) *PKCS11MAC {
+	return &PKCS11MAC{session: session, key: key}
+}
+
+// MAC computes the HMAC-SHA256 of the input on the token
+func (p *PKCS11MAC) MAC(input []byte) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.session.SignInit(PKCS11MechanismSHA256HMAC, p.key); err != nil {
+		return nil, err
+	}
+	return p.session.Sign(input)
+}
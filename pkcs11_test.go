@@ -0,0 +1,54 @@
+package cookiesignature
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// testPKCS11Session computes HMAC-SHA256 in software, failing on interleaved operations like a real session
+type testPKCS11Session struct {
+	keys        map[uint][]byte
+	key         uint
+	initialized bool
+}
+
+func (s *testPKCS11Session) SignInit(mechanism uint, key uint) error {
+	if mechanism != PKCS11MechanismSHA256HMAC {
+		return errors.New("CKR_MECHANISM_INVALID")
+	}
+	if s.initialized {
+		return errors.New("CKR_OPERATION_ACTIVE")
+	}
+	s.key, s.initialized = key, true
+	return nil
+}
+
+func (s *testPKCS11Session) Sign(data []byte) ([]byte, error) {
+	if !s.initialized {
+		return nil, errors.New("CKR_OPERATION_NOT_INITIALIZED")
+	}
+	s.initialized = false
+	return computeHMAC256(string(data), s.keys[s.key])
+}
+
+func TestPKCS11MAC(t *testing.T) {
+	session := &testPKCS11Session{keys: map[uint][]byte{7: []byte("tobiiscool")}}
+	cs, err := NewCookieSignatureFromMAC([]MACProvider{NewPKCS11MAC(session, 7)})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			signed, err := cs.Sign("hello")
+			if err != nil || signed != "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI" {
+				t.Errorf("unexpected signature: %s, %v", signed, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
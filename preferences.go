@@ -0,0 +1,138 @@
+package cookiesignature
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const preferencePurpose = "preference"
+
+// Names of the preference cookies
+const (
+	LocaleCookieName   = "locale"
+	ThemeCookieName    = "theme"
+	TimeZoneCookieName = "tz"
+)
+
+// ErrInvalidPreference is returned when a preference value isn't allowed
+var ErrInvalidPreference = errors.New("invalid preference value")
+
+// localePattern matches the well-formed BCP 47 language tags, e.g. en, pt-BR or zh-Hant-TW
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// defaultThemes are the themes allowed when PreferenceCookies.Themes is empty
+var defaultThemes = []string{"light", "dark", "system"}
+
+// Preferences are the display preferences of a user
+type Preferences struct {
+	// Locale is a BCP 47 language tag, e.g. en-US
+	Locale string
+	Theme  string
+	// TimeZone is the name of an IANA time zone, e.g. Europe/Paris
+	TimeZone string
+}
+
+// Location returns the time zone of the preferences, UTC if it can't be loaded
+func (p Preferences) Location() *time.Location {
+	if location, err := time.LoadLocation(p.TimeZone); err == nil {
+		return location
+	}
+	return time.UTC
+}
+
+// PreferenceCookies reads and writes the locale, theme and time zone cookies. Their values are signed,
+// bound to the cookie name and checked against the allowed values, so a forged cookie can't inject an arbitrary value
+// into the server-side template logic. Missing or invalid cookies fall back to the defaults
+type PreferenceCookies struct {
+	Signer *CookieSignature
+	// Cookie is the template of the attributes of the cookies, its name is ignored
+	Cookie   http.Cookie
+	Defaults Preferences
+	// Locales are the allowed locales. Any well-formed language tag is allowed if empty
+	Locales []string
+	// Themes are the allowed themes. Defaults to light, dark and system
+	Themes []string
+}
+
+// Read returns the preferences of the request, each preference falls back to its default
+// when its cookie is missing, invalid or not allowed
+func (pc PreferenceCookies) Read(r *http.Request) Preferences {
+	return Preferences{
+		Locale:   pc.read(r, LocaleCookieName, pc.Defaults.Locale),
+		Theme:    pc.read(r, ThemeCookieName, pc.Defaults.Theme),
+		TimeZone: pc.read(r, TimeZoneCookieName, pc.Defaults.TimeZone),
+	}
+}
+
+// Write validates the non-empty preferences and sets their cookies on the response.
+// It returns ErrInvalidPreference without setting any cookie if a preference isn't allowed
+func (pc PreferenceCookies) Write(w http.ResponseWriter, preferences Preferences) error {
+	values := []struct{ name, value string }{
+		{LocaleCookieName, preferences.Locale},
+		{ThemeCookieName, preferences.Theme},
+		{TimeZoneCookieName, preferences.TimeZone},
+	}
+	cookies := make([]http.Cookie, 0, len(values))
+	for _, v := range values {
+		if v.value == "" {
+			continue
+		}
+		if !pc.allowed(v.name, v.value) {
+			return ErrInvalidPreference
+		}
+		hashBytes, err := pc.Signer.signingMAC(encodeValues([]string{preferencePurpose, v.name, v.value}))
+		if err != nil {
+			return err
+		}
+		cookie := pc.Cookie
+		cookie.Name = v.name
+		cookie.Value = v.value + "." + hashBase64(hashBytes)
+		cookies = append(cookies, cookie)
+	}
+	for i := range cookies {
+		http.SetCookie(w, &cookies[i])
+	}
+	return nil
+}
+
+func (pc PreferenceCookies) read(r *http.Request, name string, fallback string) string {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return fallback
+	}
+	index := strings.LastIndex(cookie.Value, ".")
+	if index < 0 {
+		return fallback
+	}
+	value := cookie.Value[:index]
+	if _, err := pc.Signer.unsign(encodeValues([]string{preferencePurpose, name, value}) + cookie.Value[index:]); err != nil {
+		return fallback
+	}
+	// the allowed values may have changed since the cookie was written
+	if !pc.allowed(name, value) {
+		return fallback
+	}
+	return value
+}
+
+func (pc PreferenceCookies) allowed(name string, value string) bool {
+	switch name {
+	case LocaleCookieName:
+		if len(pc.Locales) == 0 {
+			return localePattern.MatchString(value)
+		}
+		return containsString(pc.Locales, value)
+	case ThemeCookieName:
+		if len(pc.Themes) == 0 {
+			return containsString(defaultThemes, value)
+		}
+		return containsString(pc.Themes, value)
+	case TimeZoneCookieName:
+		_, err := time.LoadLocation(value)
+		return err == nil && value != "Local"
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package cookiesignature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPreferenceCookies(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	pc := PreferenceCookies{
+		Signer:   cs,
+		Cookie:   http.Cookie{Path: "/", MaxAge: 3600},
+		Defaults: Preferences{Locale: "en", Theme: "system", TimeZone: "UTC"},
+		Locales:  []string{"en", "fr", "pt-BR"},
+	}
+
+	recorder := httptest.NewRecorder()
+	if err := pc.Write(recorder, Preferences{Locale: "pt-BR", Theme: "dark", TimeZone: "Europe/Paris"}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 3 || cookies[0].Name != LocaleCookieName || cookies[0].MaxAge != 3600 {
+		t.Fatalf("unexpected cookies: %v", cookies)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	preferences := pc.Read(request)
+	if preferences != (Preferences{Locale: "pt-BR", Theme: "dark", TimeZone: "Europe/Paris"}) {
+		t.Fatalf("unexpected preferences: %+v", preferences)
+	}
+	if preferences.Location().String() != "Europe/Paris" {
+		t.Fatalf("unexpected location: %s", preferences.Location())
+	}
+
+	// forged, swapped and unsigned values fall back to the defaults
+	request = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(&http.Cookie{Name: LocaleCookieName, Value: "<script>." + cookies[0].Value[len("pt-BR."):]})
+	request.AddCookie(&http.Cookie{Name: ThemeCookieName, Value: cookies[0].Value})
+	request.AddCookie(&http.Cookie{Name: TimeZoneCookieName, Value: cs.MustSign("Europe/Paris")})
+	assertEqual(t, "en", pc.Read(request).Locale, nil)
+	assertEqual(t, "system", pc.Read(request).Theme, nil)
+	assertEqual(t, "UTC", pc.Read(request).TimeZone, nil)
+
+	// values that are no longer allowed fall back to the defaults
+	pc.Locales = []string{"en"}
+	request = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(cookies[0])
+	assertEqual(t, "en", pc.Read(request).Locale, nil)
+
+	for _, invalid := range []Preferences{{Locale: "de"}, {Theme: "neon"}, {TimeZone: "Mars/Olympus"}, {TimeZone: "Local"}} {
+		recorder := httptest.NewRecorder()
+		if err := pc.Write(recorder, invalid); err != ErrInvalidPreference {
+			t.Fatalf("expected error: %s, got: %v", ErrInvalidPreference, err)
+		}
+		if len(recorder.Result().Cookies()) != 0 {
+			t.Fatal("expected no cookies to be set")
+		}
+	}
+	pc.Locales = nil
+	if err := pc.Write(httptest.NewRecorder(), Preferences{Locale: "zh-Hant-TW"}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+}
@@ -0,0 +1,92 @@
+package cookiesignature
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SecretWarningCode identifies the kind of a SecretWarning
+type SecretWarningCode string
+
+const (
+	// SecretWarningWhitespace reports a secret with leading or trailing whitespace, usually a trailing newline of an env file
+	SecretWarningWhitespace SecretWarningCode = "whitespace"
+	// SecretWarningTrimmed reports a secret whose surrounding whitespace was trimmed by WithTrimSecrets
+	SecretWarningTrimmed SecretWarningCode = "trimmed"
+	// SecretWarningDuplicate reports a secret identical to an earlier one
+	SecretWarningDuplicate SecretWarningCode = "duplicate"
+	// SecretWarningAdjacentDuplicate reports a secret identical to the previous one, usually a rotation that forgot to change the value
+	SecretWarningAdjacentDuplicate SecretWarningCode = "adjacent-duplicate"
+)
+
+// SecretWarning is a suspicious secret detected on construction. It never holds the secret itself
+type SecretWarning struct {
+	Code SecretWarningCode
+	// Index of the secret in the secrets passed to NewCookieSignature
+	Index int
+	// Other is the index of the identical secret of duplicate warnings
+	Other   int
+	Message string
+}
+
+// String returns the message of the warning
+func (w SecretWarning) String() string {
+	return w.Message
+}
+
+// WithTrimSecrets trims the surrounding whitespace of the secrets, e.g. the trailing newline of a secret read from a file.
+// Such a newline silently breaks the interoperability with peers that don't have it, so it is only reported by default
+func WithTrimSecrets() Option {
+	return func(o *options) {
+		o.trimSecrets = true
+	}
+}
+
+// Warnings returns the warnings about the secrets detected by NewCookieSignature
+func (cs CookieSignature) Warnings() []SecretWarning {
+	return append([]SecretWarning(nil), cs.warnings...)
+}
+
+// CheckSecrets returns the warnings about the secrets, e.g. to validate a configuration before deploying it
+func CheckSecrets(secrets []string) []SecretWarning {
+	_, warnings := normalizeSecrets(secrets, false)
+	return warnings
+}
+
+// normalizeSecrets trims the secrets if required and detects the suspicious ones
+func normalizeSecrets(secrets []string, trim bool) ([]string, []SecretWarning) {
+	var warnings []SecretWarning
+	result := make([]string, len(secrets))
+	seen := make(map[string]int, len(secrets))
+
+	for i, secret := range secrets {
+		if trimmed := strings.TrimSpace(secret); trimmed != secret {
+			if trim {
+				secret = trimmed
+				warnings = append(warnings, SecretWarning{
+					Code:    SecretWarningTrimmed,
+					Index:   i,
+					Message: fmt.Sprintf("surrounding whitespace of secret key at index %d was trimmed", i),
+				})
+			} else {
+				warnings = append(warnings, SecretWarning{
+					Code:    SecretWarningWhitespace,
+					Index:   i,
+					Message: fmt.Sprintf("secret key at index %d has surrounding whitespace", i),
+				})
+			}
+		}
+		result[i] = secret
+
+		if other, ok := seen[secret]; ok && secret != "" {
+			code, message := SecretWarningDuplicate, fmt.Sprintf("secret key at index %d duplicates the secret key at index %d", i, other)
+			if other == i-1 {
+				code, message = SecretWarningAdjacentDuplicate, fmt.Sprintf("secret key at index %d is identical to the previous one", i)
+			}
+			warnings = append(warnings, SecretWarning{Code: code, Index: i, Other: other, Message: message})
+		} else {
+			seen[secret] = i
+		}
+	}
+	return result, warnings
+}
@@ -0,0 +1,48 @@
+package cookiesignature
+
+import "testing"
+
+func TestSecretWarnings(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"n3wsecr3t\n", "n3wsecr3t\n", "tobiiscool", "n3wsecr3t\n"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	warnings := cs.Warnings()
+	expected := []SecretWarning{
+		{Code: SecretWarningWhitespace, Index: 0, Message: "secret key at index 0 has surrounding whitespace"},
+		{Code: SecretWarningWhitespace, Index: 1, Message: "secret key at index 1 has surrounding whitespace"},
+		{Code: SecretWarningAdjacentDuplicate, Index: 1, Other: 0, Message: "secret key at index 1 is identical to the previous one"},
+		{Code: SecretWarningWhitespace, Index: 3, Message: "secret key at index 3 has surrounding whitespace"},
+		{Code: SecretWarningDuplicate, Index: 3, Other: 0, Message: "secret key at index 3 duplicates the secret key at index 0"},
+	}
+	if len(warnings) != len(expected) {
+		t.Fatalf("expected warnings: %v, got: %v", expected, warnings)
+	}
+	for i := range expected {
+		if warnings[i] != expected[i] {
+			t.Fatalf("expected warning: %+v, got: %+v", expected[i], warnings[i])
+		}
+	}
+
+	// the secret isn't trimmed by default
+	signed, err := cs.Sign("hello")
+	assertNotEqual(t, "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", signed, err)
+
+	cs, err = NewCookieSignature([]string{"tobiiscool\r\n"}, WithTrimSecrets())
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	signed, err = cs.Sign("hello")
+	assertEqual(t, "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", signed, err)
+	if warnings := cs.Warnings(); len(warnings) != 1 || warnings[0].Code != SecretWarningTrimmed {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+
+	if _, err := NewCookieSignature([]string{"tobiiscool", " \n"}, WithTrimSecrets()); err == nil || err.Error() != "secret key at index 1 must not be empty" {
+		t.Fatalf("expected error: secret key at index 1 must not be empty, got: %s", err)
+	}
+
+	if warnings := CheckSecrets([]string{"n3wsecr3t", "tobiiscool"}); len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+}
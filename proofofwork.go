@@ -0,0 +1,141 @@
+package cookiesignature
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"math/bits"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	proofOfWorkPurpose = "proof-of-work"
+	// maxProofOfWorkDifficulty keeps the challenges solvable by a browser
+	maxProofOfWorkDifficulty = 32
+)
+
+// Headers of the proof-of-work challenges, the challenge is echoed back by the client with its solution
+const (
+	ProofOfWorkChallengeHeader = "Proof-Of-Work-Challenge"
+	ProofOfWorkSolutionHeader  = "Proof-Of-Work-Solution"
+)
+
+// ErrInvalidProofOfWork is returned when the solution of a proof-of-work challenge doesn't meet its difficulty
+var ErrInvalidProofOfWork = errors.New("invalid proof of work")
+
+// SignProofOfWork signs a proof-of-work challenge valid for ttl. Solving it takes about 2^difficulty SHA-256 hashes:
+// the client looks for a solution such that SHA-256(challenge + solution) starts with difficulty zero bits.
+// The server stores nothing, the difficulty and the expiration are part of the signed challenge
+func (cs CookieSignature) SignProofOfWork(difficulty int, ttl time.Duration) (string, error) {
+	if difficulty < 1 || difficulty > maxProofOfWorkDifficulty {
+		return "", errors.New("proof of work difficulty must be between 1 and 32")
+	}
+	if ttl <= 0 {
+		return "", errors.New("challenge ttl must be positive")
+	}
+	nonce, err := randomBase64(12)
+	if err != nil {
+		return "", err
+	}
+	payload := strconv.Itoa(difficulty) + "." + strconv.FormatInt(timeNow().Add(ttl).Unix(), 10) + "." + nonce
+	hashBytes, err := cs.signingMAC(encodeValues([]string{proofOfWorkPurpose, payload}))
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + hashBase64(hashBytes), nil
+}
+
+// VerifyProofOfWork verifies the challenge signed by SignProofOfWork, checks that it didn't expire
+// and that the solution meets its difficulty. The challenge is used up in the nonce store,
+// so a solution can't be replayed. Solutions can be replayed until the challenge expires if nonces is nil
+func (cs CookieSignature) VerifyProofOfWork(ctx context.Context, challenge string, solution string, nonces NonceStore) error {
+	index := strings.LastIndex(challenge, ".")
+	if index < 0 {
+		return errInvalidSignature
+	}
+	payload := challenge[:index]
+	if _, err := cs.unsign(encodeValues([]string{proofOfWorkPurpose, payload}) + challenge[index:]); err != nil {
+		return err
+	}
+	fields := strings.Split(payload, ".")
+	if len(fields) != 3 {
+		return errInvalidSignature
+	}
+	difficulty, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return errInvalidSignature
+	}
+	expiresAt, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return errInvalidSignature
+	}
+
+	if err := cs.validateClaims(Claims{ExpiresAt: expiresAt}, verifyOptions{}); err != nil {
+		return err
+	}
+	if proofOfWorkBits(challenge, solution) < difficulty {
+		return ErrInvalidProofOfWork
+	}
+	return cs.useNonce(ctx, nonces, proofOfWorkPurpose+":"+fields[2], expiresAt)
+}
+
+// SolveProofOfWork finds a solution of the challenge, for Go clients and tests
+func SolveProofOfWork(ctx context.Context, challenge string) (string, error) {
+	fields := strings.Split(challenge, ".")
+	if len(fields) != 4 {
+		return "", errInvalidSignature
+	}
+	difficulty, err := strconv.Atoi(fields[0])
+	if err != nil || difficulty < 1 || difficulty > maxProofOfWorkDifficulty {
+		return "", errInvalidSignature
+	}
+	for counter := uint64(0); ; counter++ {
+		if counter%4096 == 0 && ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		solution := strconv.FormatUint(counter, 36)
+		if proofOfWorkBits(challenge, solution) >= difficulty {
+			return solution, nil
+		}
+	}
+}
+
+// ProofOfWorkMiddleware gates the handler behind a proof of work. Requests without a valid solution are rejected
+// with 429 and a new challenge in the Proof-Of-Work-Challenge header, which the client solves and sends back
+// with its solution in the Proof-Of-Work-Solution header
+func (cs CookieSignature) ProofOfWorkMiddleware(difficulty int, ttl time.Duration, nonces NonceStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			challenge := r.Header.Get(ProofOfWorkChallengeHeader)
+			solution := r.Header.Get(ProofOfWorkSolutionHeader)
+			if challenge != "" && cs.VerifyProofOfWork(r.Context(), challenge, solution, nonces) == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			challenge, err := cs.SignProofOfWork(difficulty, ttl)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set(ProofOfWorkChallengeHeader, challenge)
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		})
+	}
+}
+
+// proofOfWorkBits returns the number of leading zero bits of SHA-256(challenge + solution)
+func proofOfWorkBits(challenge string, solution string) int {
+	sum := sha256.Sum256([]byte(challenge + solution))
+	zeros := 0
+	for _, b := range sum {
+		if b != 0 {
+			return zeros + bits.LeadingZeros8(b)
+		}
+		zeros += 8
+	}
+	return zeros
+}
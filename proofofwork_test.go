@@ -0,0 +1,109 @@
+package cookiesignature
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProofOfWork(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return start }
+	defer func() { timeNow = time.Now }()
+
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	challenge, err := cs.SignProofOfWork(12, time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if !strings.HasPrefix(challenge, "12.1600000060.") {
+		t.Fatalf("unexpected challenge: %s", challenge)
+	}
+
+	solution, err := SolveProofOfWork(context.Background(), challenge)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	nonces := NewMemoryNonceStore()
+	if err := cs.VerifyProofOfWork(context.Background(), challenge, solution, nonces); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if err := cs.VerifyProofOfWork(context.Background(), challenge, solution, nonces); err != ErrTokenUsed {
+		t.Fatalf("expected error: %s, got: %v", ErrTokenUsed, err)
+	}
+
+	// solutions can't be replayed within the leeway
+	lenient, _ := NewCookieSignature([]string{"tobiiscool"}, WithLeeway(10*time.Second))
+	lenientChallenge, _ := lenient.SignProofOfWork(8, time.Minute)
+	lenientSolution, _ := SolveProofOfWork(context.Background(), lenientChallenge)
+	if err := lenient.VerifyProofOfWork(context.Background(), lenientChallenge, lenientSolution, nonces); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	timeNow = func() time.Time { return start.Add(time.Minute + 2*time.Second) }
+	if err := lenient.VerifyProofOfWork(context.Background(), lenientChallenge, lenientSolution, nonces); err != ErrTokenUsed {
+		t.Fatalf("expected error: %s, got: %v", ErrTokenUsed, err)
+	}
+	timeNow = func() time.Time { return start }
+
+	challenge, _ = cs.SignProofOfWork(12, time.Minute)
+	for solution := 0; ; solution++ {
+		if proofOfWorkBits(challenge, string(rune('a'+solution))) < 12 {
+			if err := cs.VerifyProofOfWork(context.Background(), challenge, string(rune('a'+solution)), nil); err != ErrInvalidProofOfWork {
+				t.Fatalf("expected error: %s, got: %v", ErrInvalidProofOfWork, err)
+			}
+			break
+		}
+	}
+	// lowering the difficulty breaks the signature
+	if err := cs.VerifyProofOfWork(context.Background(), "1"+challenge[2:], "x", nil); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	timeNow = func() time.Time { return start.Add(2 * time.Minute) }
+	if err := cs.VerifyProofOfWork(context.Background(), challenge, "x", nil); err != ErrTokenExpired {
+		t.Fatalf("expected error: %s, got: %v", ErrTokenExpired, err)
+	}
+	timeNow = func() time.Time { return start }
+
+	if _, err := cs.SignProofOfWork(40, time.Minute); err == nil {
+		t.Fatal("expected an error for a difficulty above 32")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	hard, _ := cs.SignProofOfWork(32, time.Minute)
+	if _, err := SolveProofOfWork(ctx, hard); err != context.Canceled {
+		t.Fatalf("expected error: %s, got: %v", context.Canceled, err)
+	}
+}
+
+func TestProofOfWorkMiddleware(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	handler := cs.ProofOfWorkMiddleware(8, time.Minute, NewMemoryNonceStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/signup", nil))
+	challenge := recorder.Header().Get(ProofOfWorkChallengeHeader)
+	if recorder.Code != http.StatusTooManyRequests || challenge == "" {
+		t.Fatalf("expected a challenge, got: %d %q", recorder.Code, challenge)
+	}
+
+	solution, _ := SolveProofOfWork(context.Background(), challenge)
+	request := httptest.NewRequest(http.MethodPost, "/signup", nil)
+	request.Header.Set(ProofOfWorkChallengeHeader, challenge)
+	request.Header.Set(ProofOfWorkSolutionHeader, solution)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "ok" {
+		t.Fatalf("unexpected response: %d %s", recorder.Code, recorder.Body)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status: %d, got: %d", http.StatusTooManyRequests, recorder.Code)
+	}
+}
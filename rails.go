@@ -0,0 +1,184 @@
+package cookiesignature
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	defaultRailsEncryptedCookieSalt = "authenticated encrypted cookie"
+	defaultRailsKeyIterations       = 1000
+	railsKeyLength                  = 32
+	railsIVLength                   = 12
+	railsPurposePrefix              = "cookie."
+	railsTimeFormat                 = "2006-01-02T15:04:05.000Z07:00"
+)
+
+var (
+	errRailsInvalidMessage  = errors.New("invalid encrypted cookie")
+	errRailsPurposeMismatch = errors.New("encrypted cookie purpose mismatch")
+	errRailsMessageExpired  = errors.New("encrypted cookie expired")
+)
+
+// RailsOptions configures the key derivation of RailsCookieEncryptor.
+// The zero value matches the defaults of Rails 7.0 and later
+type RailsOptions struct {
+	// Salt of the key derivation. Defaults to "authenticated encrypted cookie"
+	// (config.action_dispatch.authenticated_encrypted_cookie_salt)
+	Salt string
+	// Iterations of the PBKDF2 key derivation. Defaults to 1000
+	Iterations int
+	// Hash of the PBKDF2 key derivation. Defaults to SHA-256
+	// (config.active_support.key_generator_hash_digest_class).
+	// Use sha1.New to read cookies of Rails 5.2 to 6.1 applications
+	Hash func() hash.Hash
+}
+
+// RailsCookieEncryptor encrypts and decrypts cookies of the Rails 5.2+ encrypted cookie jar
+// (authenticated AES-256-GCM), so sessions of Rails applications can be read from Go.
+// Only the JSON cookie serializer is supported
+type RailsCookieEncryptor struct {
+	aead cipher.AEAD
+}
+
+type railsMetadata struct {
+	Rails struct {
+		Message *string         `json:"message,omitempty"`
+		Data    json.RawMessage `json:"data,omitempty"`
+		Exp     *string         `json:"exp"`
+		Pur     string          `json:"pur"`
+	} `json:"_rails"`
+}
+
+// NewRailsCookieEncryptor derives the cookie encryption key from the secret_key_base of the Rails application
+func NewRailsCookieEncryptor(secretKeyBase string, options RailsOptions) (*RailsCookieEncryptor, error) {
+	if secretKeyBase == "" {
+		return nil, errors.New("secret key base must be provided")
+	}
+	if options.Salt == "" {
+		options.Salt = defaultRailsEncryptedCookieSalt
+	}
+	if options.Iterations <= 0 {
+		options.Iterations = defaultRailsKeyIterations
+	}
+	if options.Hash == nil {
+		options.Hash = sha256.New
+	}
+
+	key := pbkdf2.Key([]byte(secretKeyBase), []byte(options.Salt), options.Iterations, railsKeyLength, options.Hash)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &RailsCookieEncryptor{aead: aead}, nil
+}
+
+// Encrypt serializes the value to JSON and encrypts it for the cookie name.
+// The result is URL-escaped like Rails does when writing cookies.
+// If expiresAt is not zero, Rails rejects the cookie after that time
+func (re RailsCookieEncryptor) Encrypt(name string, value interface{}, expiresAt time.Time) (string, error) {
+	message, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	var metadata railsMetadata
+	encodedMessage := base64.StdEncoding.EncodeToString(message)
+	metadata.Rails.Message = &encodedMessage
+	metadata.Rails.Pur = railsPurposePrefix + name
+	if !expiresAt.IsZero() {
+		exp := expiresAt.UTC().Format(railsTimeFormat)
+		metadata.Rails.Exp = &exp
+	}
+	plaintext, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, railsIVLength)
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	sealed := re.aead.Seal(nil, iv, plaintext, nil)
+	tagOffset := len(sealed) - re.aead.Overhead()
+
+	return url.QueryEscape(strings.Join([]string{
+		base64.StdEncoding.EncodeToString(sealed[:tagOffset]),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(sealed[tagOffset:]),
+	}, "--")), nil
+}
+
+// Decrypt verifies and decrypts the encrypted cookie value, then unmarshals its JSON content into value.
+// The cookie name must match the name the cookie was written with
+func (re RailsCookieEncryptor) Decrypt(name string, cookieValue string, value interface{}) error {
+	if unescaped, err := url.PathUnescape(cookieValue); err == nil {
+		cookieValue = unescaped
+	}
+
+	parts := strings.Split(cookieValue, "--")
+	if len(parts) != 3 {
+		return errRailsInvalidMessage
+	}
+	var decoded [3][]byte
+	for i, part := range parts {
+		bs, err := base64.StdEncoding.DecodeString(part)
+		if err != nil {
+			return errRailsInvalidMessage
+		}
+		decoded[i] = bs
+	}
+	encrypted, iv, authTag := decoded[0], decoded[1], decoded[2]
+	if len(iv) != railsIVLength || len(authTag) != re.aead.Overhead() {
+		return errRailsInvalidMessage
+	}
+
+	plaintext, err := re.aead.Open(nil, iv, append(encrypted, authTag...), nil)
+	if err != nil {
+		return errRailsInvalidMessage
+	}
+
+	var metadata railsMetadata
+	if err := json.Unmarshal(plaintext, &metadata); err != nil || (metadata.Rails.Message == nil && metadata.Rails.Data == nil) {
+		// cookies written without metadata only contain the serialized value
+		return json.Unmarshal(plaintext, value)
+	}
+
+	if metadata.Rails.Pur != railsPurposePrefix+name {
+		return errRailsPurposeMismatch
+	}
+	if metadata.Rails.Exp != nil {
+		exp, err := time.Parse(time.RFC3339, *metadata.Rails.Exp)
+		if err != nil {
+			return errRailsInvalidMessage
+		}
+		if !timeNow().Before(exp) {
+			return errRailsMessageExpired
+		}
+	}
+
+	// Rails 7.1 embeds the value in the metadata when use_message_serializer_for_metadata is enabled
+	if metadata.Rails.Message == nil {
+		return json.Unmarshal(metadata.Rails.Data, value)
+	}
+	message, err := base64.StdEncoding.DecodeString(*metadata.Rails.Message)
+	if err != nil {
+		return errRailsInvalidMessage
+	}
+	return json.Unmarshal(message, value)
+}
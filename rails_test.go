@@ -0,0 +1,60 @@
+package cookiesignature
+
+import (
+	"crypto/sha1"
+	"testing"
+	"time"
+)
+
+func TestRailsCookieEncryptor(t *testing.T) {
+	if _, err := NewRailsCookieEncryptor("", RailsOptions{}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	// encrypted by a Rails 6.1 application with the JSON cookie serializer
+	cookie := "T8vrlzu1GCnRhv%2Fi9J%2Fu6mbiuW6yFRZ5R4bcpNh%2Fw80HSPAdUHp8zcVxJG8%2Br2DABcWUSSHxHyliM1R%2BVKP6JNpNzyPWL0OsVYPBnaFJtUyWCwFI--AAECAwQFBgcICQoL--tK8VPmZOJds9zE2ZwzkPWA%3D%3D"
+	legacy, err := NewRailsCookieEncryptor("secret_key_base_for_tests", RailsOptions{Hash: sha1.New})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	var session struct {
+		UserID int `json:"user_id"`
+	}
+	if err := legacy.Decrypt("_app_session", cookie, &session); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if session.UserID != 42 {
+		t.Fatalf("expected: 42, got: %d", session.UserID)
+	}
+	if err := legacy.Decrypt("other", cookie, &session); err != errRailsPurposeMismatch {
+		t.Fatalf("expected error: %s, got: %s", errRailsPurposeMismatch, err)
+	}
+
+	re, err := NewRailsCookieEncryptor("secret_key_base_for_tests", RailsOptions{})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if err := re.Decrypt("_app_session", cookie, &session); err != errRailsInvalidMessage {
+		t.Fatalf("expected error: %s, got: %s", errRailsInvalidMessage, err)
+	}
+
+	encrypted, err := re.Encrypt("_app_session", map[string]int{"user_id": 7}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if err := re.Decrypt("_app_session", encrypted, &session); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if session.UserID != 7 {
+		t.Fatalf("expected: 7, got: %d", session.UserID)
+	}
+
+	expired, err := re.Encrypt("_app_session", map[string]int{"user_id": 7}, time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if err := re.Decrypt("_app_session", expired, &session); err != errRailsMessageExpired {
+		t.Fatalf("expected error: %s, got: %s", errRailsMessageExpired, err)
+	}
+}
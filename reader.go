@@ -0,0 +1,59 @@
+package cookiesignature
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+const defaultMaxSecretSize = 64 << 10
+
+// ReaderOptions configures how NewFromReader reads the secrets
+type ReaderOptions struct {
+	// MaxSize of the input in bytes, larger inputs are rejected rather than truncated. Defaults to 64 KiB
+	MaxSize int64
+	// Lines reads one secret per line, the first one signing, and skips the blank lines.
+	// The whole input is a single secret otherwise
+	Lines bool
+	// TrimSpace trims the whitespace around each secret. Only the trailing newline is trimmed otherwise
+	TrimSpace bool
+}
+
+// NewFromReader creates a new CookieSignature instance with the secrets read from r,
+// so keys piped from stdin, process substitution or a secret-fetching sidecar are loaded without temporary files
+func NewFromReader(r io.Reader, ro ReaderOptions, opts ...Option) (*CookieSignature, error) {
+	maxSize := ro.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxSecretSize
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("secret input exceeds %d bytes", maxSize)
+	}
+
+	inputs := []string{string(data)}
+	if ro.Lines {
+		inputs = strings.Split(string(data), "\n")
+	}
+	var secrets []string
+	for _, secret := range inputs {
+		if ro.TrimSpace {
+			secret = strings.TrimSpace(secret)
+		} else {
+			secret = strings.TrimSuffix(strings.TrimSuffix(secret, "\n"), "\r")
+		}
+		if ro.Lines && strings.TrimSpace(secret) == "" {
+			continue
+		}
+		secrets = append(secrets, secret)
+	}
+	if len(secrets) == 0 {
+		return nil, errors.New("secret key must be provided")
+	}
+	return NewCookieSignature(secrets, opts...)
+}
@@ -0,0 +1,43 @@
+package cookiesignature
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewFromReader(t *testing.T) {
+	cs, err := NewFromReader(strings.NewReader("tobiiscool\n"), ReaderOptions{})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	val, err := cs.Sign("hello")
+	assertEqual(t, "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", val, err)
+
+	cs, err = NewFromReader(strings.NewReader("n3wsecr3t\r\n\n  tobiiscool \n"), ReaderOptions{Lines: true, TrimSpace: true})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	val, err = cs.Sign("hello")
+	assertEqual(t, "hello.fJDsH8b7iNvcQdwtuhE29LZUFMorBk6MOzotVfMoiOc", val, err)
+	result, err := cs.Unsign("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI")
+	assertEqual(t, "hello", result, err)
+
+	// the whitespace is part of the secret unless trimmed
+	cs, _ = NewFromReader(strings.NewReader(" tobiiscool\n"), ReaderOptions{})
+	if _, err := cs.Unsign("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+
+	if _, err := NewFromReader(strings.NewReader("tobiiscool"), ReaderOptions{MaxSize: 4}); err == nil || err.Error() != "secret input exceeds 4 bytes" {
+		t.Fatalf("expected error: secret input exceeds 4 bytes, got: %v", err)
+	}
+	if _, err := NewFromReader(strings.NewReader("\n \n"), ReaderOptions{Lines: true}); err == nil || err.Error() != "secret key must be provided" {
+		t.Fatalf("expected error: secret key must be provided, got: %v", err)
+	}
+	if _, err := NewFromReader(strings.NewReader("\n"), ReaderOptions{}); err == nil {
+		t.Fatalf("expected an error for an empty secret")
+	}
+	if _, err := NewFromReader(failingReader{}, ReaderOptions{}); err == nil || err.Error() != "read failed" {
+		t.Fatalf("expected error: read failed, got: %v", err)
+	}
+}
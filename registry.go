@@ -0,0 +1,99 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrSignerNotFound is returned when no signer is registered under the name
+var ErrSignerNotFound = errors.New("signer not found")
+
+// DefaultRegistry is the process-wide registry of Register and Lookup
+var DefaultRegistry = &Registry{}
+
+// Registry holds configured signers by name, e.g. "session", "csrf" or "tenant:acme",
+// so libraries and middleware can share instances without plumbing constructors through every layer.
+// The zero value is an empty registry. It is safe for concurrent use
+type Registry struct {
+	mu      sync.RWMutex
+	signers map[string]*CookieSignature
+}
+
+type registryContextKey struct{}
+
+// Register adds the signer under the name. A name can't be registered twice, Unregister it first
+func (r *Registry) Register(name string, signer *CookieSignature) error {
+	if name == "" {
+		return errors.New("signer name must not be empty")
+	}
+	if signer == nil {
+		return fmt.Errorf("signer %s must not be nil", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.signers[name]; ok {
+		return fmt.Errorf("signer %s is already registered", name)
+	}
+	if r.signers == nil {
+		r.signers = make(map[string]*CookieSignature)
+	}
+	r.signers[name] = signer
+	return nil
+}
+
+// Unregister removes the signer of the name, if any
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.signers, name)
+}
+
+// Lookup returns the signer registered under the name
+func (r *Registry) Lookup(name string) (*CookieSignature, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	signer, ok := r.signers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSignerNotFound, name)
+	}
+	return signer, nil
+}
+
+// Names returns the sorted names of the registered signers
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.signers))
+	for name := range r.signers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Register adds the signer under the name to the DefaultRegistry
+func Register(name string, signer *CookieSignature) error {
+	return DefaultRegistry.Register(name, signer)
+}
+
+// Lookup returns the signer registered under the name in the DefaultRegistry
+func Lookup(name string) (*CookieSignature, error) {
+	return DefaultRegistry.Lookup(name)
+}
+
+// ContextWithRegistry returns a copy of the context carrying the registry, e.g. to inject a registry per test or per tenant
+func ContextWithRegistry(ctx context.Context, r *Registry) context.Context {
+	return context.WithValue(ctx, registryContextKey{}, r)
+}
+
+// RegistryFromContext returns the registry carried by the context, the DefaultRegistry if none
+func RegistryFromContext(ctx context.Context) *Registry {
+	if r, ok := ctx.Value(registryContextKey{}).(*Registry); ok && r != nil {
+		return r
+	}
+	return DefaultRegistry
+}
@@ -0,0 +1,69 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	session, _ := NewCookieSignature([]string{"tobiiscool"})
+	csrf, _ := NewCookieSignature([]string{"n3wsecr3t"})
+
+	var registry Registry
+	if _, err := registry.Lookup("session"); !errors.Is(err, ErrSignerNotFound) {
+		t.Fatalf("expected error: %s, got: %v", ErrSignerNotFound, err)
+	}
+	if err := registry.Register("", session); err == nil {
+		t.Fatalf("expected an error for an empty name")
+	}
+	if err := registry.Register("session", nil); err == nil {
+		t.Fatalf("expected an error for a nil signer")
+	}
+	if err := registry.Register("session", session); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if err := registry.Register("session", csrf); err == nil || err.Error() != "signer session is already registered" {
+		t.Fatalf("expected error: signer session is already registered, got: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"csrf", "tenant:acme"} {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			if err := registry.Register(name, csrf); err != nil {
+				t.Errorf("expected no error, got: %s", err)
+			}
+		}(name)
+	}
+	wg.Wait()
+	if names := registry.Names(); len(names) != 3 || names[0] != "csrf" || names[1] != "session" || names[2] != "tenant:acme" {
+		t.Fatalf("unexpected names: %v", names)
+	}
+	if signer, err := registry.Lookup("session"); err != nil || signer != session {
+		t.Fatalf("expected the session signer, got: %v", err)
+	}
+
+	registry.Unregister("session")
+	if _, err := registry.Lookup("session"); !errors.Is(err, ErrSignerNotFound) {
+		t.Fatalf("expected error: %s, got: %v", ErrSignerNotFound, err)
+	}
+
+	if RegistryFromContext(context.Background()) != DefaultRegistry {
+		t.Fatalf("expected the default registry")
+	}
+	ctx := ContextWithRegistry(context.Background(), &registry)
+	if signer, err := RegistryFromContext(ctx).Lookup("csrf"); err != nil || signer != csrf {
+		t.Fatalf("expected the csrf signer, got: %v", err)
+	}
+
+	if err := Register("registry-test", session); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	defer DefaultRegistry.Unregister("registry-test")
+	if signer, err := Lookup("registry-test"); err != nil || signer != session {
+		t.Fatalf("expected the session signer, got: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// SessionIterator walks the signed values of a session store
+type SessionIterator interface {
+	// Next returns the key and the signed value of the next session, and io.EOF once every session was returned
+	Next(ctx context.Context) (key string, value string, err error)
+}
+
+// SessionUpdater replaces the signed value of a session of a store
+type SessionUpdater interface {
+	Update(ctx context.Context, key string, value string) error
+}
+
+// ResignStats reports the outcome of a Resigner run
+type ResignStats struct {
+	Total int
+	// Resigned values were verified by the old signer and signed again by the new one
+	Resigned int
+	// Current values were already signed by the new signer
+	Current int
+	// Invalid values were verified by neither signer
+	Invalid int
+	// Failed values couldn't be signed again or updated
+	Failed int
+}
+
+// Resigner migrates the signed values of a session store from an old signer to a new one,
+// e.g. to retire a secret without logging out millions of sessions
+type Resigner struct {
+	From *CookieSignature
+	To   *CookieSignature
+	// DryRun only counts the values to migrate, without updating the store
+	DryRun bool
+	// Chain re-signs the values with ResignChain, so they keep the lineage of their signatures.
+	// The values must have been signed by SignChain
+	Chain bool
+	// OnError is called with the key of every invalid or failed value, if not nil
+	OnError func(key string, err error)
+}
+
+// Run walks the sessions of the iterator, verifies each value with the old signer and updates it with the value signed by the new signer.
+// It stops on the first error of the iterator or when the context is done, returning the statistics so far
+func (r Resigner) Run(ctx context.Context, sessions SessionIterator, updater SessionUpdater) (ResignStats, error) {
+	var stats ResignStats
+	if r.From == nil || r.To == nil {
+		return stats, errors.New("both the old and the new signers must be provided")
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		key, value, err := sessions.Next(ctx)
+		if err == io.EOF {
+			return stats, nil
+		}
+		if err != nil {
+			return stats, err
+		}
+		stats.Total++
+
+		unsigned, err := r.unsign(r.From, value)
+		if err != nil {
+			if _, currentErr := r.unsign(r.To, value); currentErr == nil {
+				stats.Current++
+			} else {
+				stats.Invalid++
+				r.reportError(key, err)
+			}
+			continue
+		}
+
+		resigned, err := r.resign(value, unsigned)
+		if err != nil {
+			stats.Failed++
+			r.reportError(key, err)
+			continue
+		}
+		if resigned == value {
+			stats.Current++
+			continue
+		}
+		if !r.DryRun {
+			if err := updater.Update(ctx, key, resigned); err != nil {
+				stats.Failed++
+				r.reportError(key, err)
+				continue
+			}
+		}
+		stats.Resigned++
+	}
+}
+
+func (r Resigner) unsign(cs *CookieSignature, value string) (string, error) {
+	if r.Chain {
+		unsigned, _, err := cs.UnsignChain(value)
+		return unsigned, err
+	}
+	return cs.Unsign(value)
+}
+
+func (r Resigner) resign(value string, unsigned string) (string, error) {
+	if r.Chain {
+		return r.To.ResignChain(value, r.From)
+	}
+	return r.To.Sign(unsigned)
+}
+
+func (r Resigner) reportError(key string, err error) {
+	if r.OnError != nil {
+		r.OnError(key, err)
+	}
+}
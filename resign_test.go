@@ -0,0 +1,98 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+type testSessionStore struct {
+	keys   []string
+	values map[string]string
+	next   int
+}
+
+func (s *testSessionStore) Next(context.Context) (string, string, error) {
+	if s.next >= len(s.keys) {
+		return "", "", io.EOF
+	}
+	key := s.keys[s.next]
+	s.next++
+	return key, s.values[key], nil
+}
+
+func (s *testSessionStore) Update(_ context.Context, key string, value string) error {
+	if key == "readonly" {
+		return errors.New("read-only session")
+	}
+	s.values[key] = value
+	return nil
+}
+
+func TestResigner(t *testing.T) {
+	old, _ := NewCookieSignature([]string{"tobiiscool"})
+	current, _ := NewCookieSignature([]string{"n3wsecr3t"})
+	oldValue, _ := old.Sign("hello")
+	currentValue, _ := current.Sign("world")
+
+	store := &testSessionStore{
+		keys:   []string{"a", "b", "c", "readonly"},
+		values: map[string]string{"a": oldValue, "b": currentValue, "c": "hello.invalid", "readonly": oldValue},
+	}
+
+	var errorKeys []string
+	resigner := Resigner{From: old, To: current, OnError: func(key string, err error) {
+		errorKeys = append(errorKeys, key)
+	}}
+	stats, err := resigner.Run(context.Background(), store, store)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if stats != (ResignStats{Total: 4, Resigned: 1, Current: 1, Invalid: 1, Failed: 1}) {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if len(errorKeys) != 2 || errorKeys[0] != "c" || errorKeys[1] != "readonly" {
+		t.Fatalf("unexpected error keys: %v", errorKeys)
+	}
+	result, err := current.Unsign(store.values["a"])
+	assertEqual(t, "hello", result, err)
+
+	store.next = 0
+	stats, _ = Resigner{From: old, To: current, DryRun: true}.Run(context.Background(), store, store)
+	if stats.Resigned != 1 || stats.Current != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if store.values["readonly"] != oldValue {
+		t.Fatalf("expected the dry run not to update the store")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	store.next = 0
+	if _, err := resigner.Run(ctx, store, store); err != context.Canceled {
+		t.Fatalf("expected error: %s, got: %s", context.Canceled, err)
+	}
+}
+
+func TestResignerChain(t *testing.T) {
+	old, _ := NewCookieSignature([]string{"tobiiscool"})
+	current, _ := NewCookieSignature([]string{"n3wsecr3t"})
+	oldValue, _ := old.SignChain("hello")
+	currentValue, _ := current.SignChain("world")
+
+	store := &testSessionStore{
+		keys:   []string{"a", "b", "c"},
+		values: map[string]string{"a": oldValue, "b": currentValue, "c": "hello.0~invalid"},
+	}
+	stats, err := Resigner{From: old, To: current, Chain: true}.Run(context.Background(), store, store)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if stats != (ResignStats{Total: 3, Resigned: 1, Current: 1, Invalid: 1}) {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if lineage, err := AuditChain(store.values["a"], old, current); err != nil || len(lineage) != 2 || lineage[1] != 1 {
+		t.Fatalf("unexpected lineage: %v, %v", lineage, err)
+	}
+}
@@ -0,0 +1,117 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTokenRevoked is returned when the ID of a timed token has been revoked
+var ErrTokenRevoked = errors.New("token is revoked")
+
+// RevocationChecker reports whether a token ID has been revoked.
+// It is consulted by UnsignClaims for tokens with an ID when configured with WithRevocationChecker
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, id string) (bool, error)
+}
+
+// WithRevocationChecker sets the checker consulted by UnsignClaims, so a logout can invalidate an otherwise valid token
+func WithRevocationChecker(checker RevocationChecker) Option {
+	return func(o *options) {
+		o.revocationChecker = checker
+	}
+}
+
+// MemoryRevocationList is an in-memory RevocationChecker for single instance deployments
+type MemoryRevocationList struct {
+	// Leeway extends the revocations past the expiration of the tokens, it should match the WithLeeway of the signers
+	Leeway time.Duration
+
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+// NewMemoryRevocationList creates a new MemoryRevocationList instance
+func NewMemoryRevocationList() *MemoryRevocationList {
+	return &MemoryRevocationList{revoked: make(map[string]time.Time)}
+}
+
+// Revoke revokes the token ID until expiresAt plus the leeway, expiresAt should be the expiration time of the token.
+// The token ID is revoked forever if expiresAt is zero or the unix epoch, the expiration of tokens that never expire.
+// Entries are forgotten after they expire
+func (rl *MemoryRevocationList) Revoke(_ context.Context, id string, expiresAt time.Time) error {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := timeNow()
+	for key, exp := range rl.revoked {
+		if !exp.IsZero() && !exp.After(now) {
+			delete(rl.revoked, key)
+		}
+	}
+	if neverExpires(expiresAt) {
+		rl.revoked[id] = time.Time{}
+		return nil
+	}
+	rl.revoked[id] = expiresAt.Add(rl.Leeway)
+	return nil
+}
+
+// IsRevoked reports whether the token ID is revoked
+func (rl *MemoryRevocationList) IsRevoked(_ context.Context, id string) (bool, error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	exp, ok := rl.revoked[id]
+	return ok && (exp.IsZero() || exp.After(timeNow())), nil
+}
+
+// RedisClient is the subset of Redis commands used by the Redis-backed implementations of this package.
+// It keeps this package free of a Redis driver dependency, an adapter for go-redis or redigo only takes a few lines
+type RedisClient interface {
+	// Set sets the key to the value with an expiration. The key never expires if expiration is zero
+	Set(ctx context.Context, key string, value string, expiration time.Duration) error
+	// Exists reports whether the key exists
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// RedisRevocationList is a RevocationChecker backed by Redis, shared by every instance of a deployment
+type RedisRevocationList struct {
+	Client RedisClient
+	// Prefix of the Redis keys. Defaults to "revoked:"
+	Prefix string
+	// Leeway extends the revocations past the expiration of the tokens, it should match the WithLeeway of the signers
+	Leeway time.Duration
+}
+
+// Revoke revokes the token ID until expiresAt plus the leeway, expiresAt should be the expiration time of the token.
+// The token ID is revoked forever if expiresAt is zero or the unix epoch, the expiration of tokens that never expire
+func (rl RedisRevocationList) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	if neverExpires(expiresAt) {
+		return rl.Client.Set(ctx, rl.key(id), "1", 0)
+	}
+	ttl := expiresAt.Add(rl.Leeway).Sub(timeNow())
+	if ttl <= 0 {
+		return nil
+	}
+	return rl.Client.Set(ctx, rl.key(id), "1", ttl)
+}
+
+// IsRevoked reports whether the token ID is revoked
+func (rl RedisRevocationList) IsRevoked(ctx context.Context, id string) (bool, error) {
+	return rl.Client.Exists(ctx, rl.key(id))
+}
+
+// neverExpires reports whether expiresAt is the expiration of a token that never expires,
+// the zero time or time.Unix(0, 0) of a zero ExpiresAt claim
+func neverExpires(expiresAt time.Time) bool {
+	return expiresAt.IsZero() || expiresAt.Unix() == 0
+}
+
+func (rl RedisRevocationList) key(id string) string {
+	if rl.Prefix == "" {
+		return "revoked:" + id
+	}
+	return rl.Prefix + id
+}
@@ -0,0 +1,127 @@
+package cookiesignature
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type testRedisClient struct {
+	values      map[string]string
+	expirations map[string]time.Duration
+}
+
+func (c *testRedisClient) Set(_ context.Context, key string, value string, expiration time.Duration) error {
+	c.values[key] = value
+	if c.expirations != nil {
+		c.expirations[key] = expiration
+	}
+	return nil
+}
+
+func (c *testRedisClient) Exists(_ context.Context, key string) (bool, error) {
+	_, ok := c.values[key]
+	return ok, nil
+}
+
+func TestRevocation(t *testing.T) {
+	redisList := RedisRevocationList{Client: &testRedisClient{values: map[string]string{}}}
+	for _, list := range []interface {
+		RevocationChecker
+		Revoke(ctx context.Context, id string, expiresAt time.Time) error
+	}{NewMemoryRevocationList(), redisList} {
+		cs, err := NewCookieSignature([]string{"tobiiscool"}, WithRevocationChecker(list))
+		if err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+
+		expiresAt := time.Now().Add(time.Hour)
+		signed, err := cs.SignClaims(Claims{ID: "session-1", Value: "hello", ExpiresAt: expiresAt.Unix()})
+		if err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		claims, err := cs.UnsignClaims(signed)
+		assertEqual(t, "hello", claims.Value, err)
+
+		if err := list.Revoke(context.Background(), "session-1", expiresAt); err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		if _, err := cs.UnsignClaims(signed); err != ErrTokenRevoked {
+			t.Fatalf("expected error: %s, got: %s", ErrTokenRevoked, err)
+		}
+
+		other, err := cs.SignClaims(Claims{ID: "session-2", Value: "hello"})
+		if err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		claims, err = cs.UnsignClaims(other)
+		assertEqual(t, "hello", claims.Value, err)
+	}
+}
+
+func TestMemoryRevocationListExpiry(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+
+	list := NewMemoryRevocationList()
+	if err := list.Revoke(context.Background(), "session-1", time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	timeNow = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if revoked, _ := list.IsRevoked(context.Background(), "session-1"); revoked {
+		t.Fatal("expected expired revocation to be forgotten")
+	}
+	if err := list.Revoke(context.Background(), "session-2", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(list.revoked) != 1 {
+		t.Fatalf("expected 1 entry, got: %d", len(list.revoked))
+	}
+}
+
+func TestRevocationExpiry(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return start }
+	defer func() { timeNow = time.Now }()
+
+	client := &testRedisClient{values: map[string]string{}, expirations: map[string]time.Duration{}}
+	redisList := RedisRevocationList{Client: client, Leeway: 10 * time.Second}
+	memoryList := NewMemoryRevocationList()
+	memoryList.Leeway = 10 * time.Second
+
+	for _, list := range []interface {
+		RevocationChecker
+		Revoke(ctx context.Context, id string, expiresAt time.Time) error
+	}{memoryList, redisList} {
+		cs, _ := NewCookieSignature([]string{"tobiiscool"}, WithRevocationChecker(list), WithLeeway(10*time.Second))
+
+		// tokens that never expire are revoked forever
+		forever, _ := cs.SignClaims(Claims{ID: "forever", Value: "hello"})
+		if err := list.Revoke(context.Background(), "forever", time.Unix(0, 0)); err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		timeNow = func() time.Time { return start.Add(24 * 365 * time.Hour) }
+		if _, err := cs.UnsignClaims(forever); err != ErrTokenRevoked {
+			t.Fatalf("expected error: %s, got: %v", ErrTokenRevoked, err)
+		}
+
+		// revocations last as long as the leeway accepts the token
+		timeNow = func() time.Time { return start }
+		expiring, _ := cs.SignClaims(Claims{ID: "expiring", Value: "hello", ExpiresAt: start.Add(time.Minute).Unix()})
+		if err := list.Revoke(context.Background(), "expiring", start.Add(time.Minute)); err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		timeNow = func() time.Time { return start.Add(time.Minute + 2*time.Second) }
+		if _, err := cs.UnsignClaims(expiring); err != ErrTokenRevoked {
+			t.Fatalf("expected error: %s, got: %v", ErrTokenRevoked, err)
+		}
+		timeNow = func() time.Time { return start }
+	}
+
+	if expiration := client.expirations["revoked:forever"]; expiration != 0 {
+		t.Fatalf("expected no expiration, got: %s", expiration)
+	}
+	if expiration := client.expirations["revoked:expiring"]; expiration != 70*time.Second {
+		t.Fatalf("expected expiration: %s, got: %s", 70*time.Second, expiration)
+	}
+}
@@ -0,0 +1,64 @@
+package cookiesignature
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrSignatureRevoked is returned when the signature of a valid value has been revoked
+var ErrSignatureRevoked = errors.New("signature is revoked")
+
+// WithRevokedSignatures sets the set of revoked signatures consulted before a value is reported valid,
+// e.g. to reject the session cookies of a forced logout. MemoryRevocationList and RedisRevocationList,
+// with a prefix distinct from the one of the token IDs, give O(1) lookups. Revoke the values by their SignatureID
+func WithRevokedSignatures(checker RevocationChecker) Option {
+	return func(o *options) {
+		o.revokedSignatures = checker
+	}
+}
+
+// SignatureID returns the canonical form of the signature of a signed value, the ID revoking it.
+// Percent-encoded values, every signature encoding accepted by Unsign and the algorithm tag
+// added by WithAlgorithmTag give the same ID
+func SignatureID(signed string) (string, error) {
+	if strings.Contains(signed, "%") {
+		if decoded, err := url.PathUnescape(signed); err == nil {
+			signed = decoded
+		}
+	}
+	index := strings.LastIndex(signed, ".")
+	if index < 0 {
+		return "", errInvalidSignature
+	}
+	encoded := signed[index+1:]
+	if end := strings.IndexByte(encoded, algorithmTagEnd); end >= 0 {
+		encoded = encoded[end+1:]
+	}
+	signature, err := decodeSignature(base64.RawStdEncoding, encoded)
+	if err != nil || len(signature) == 0 {
+		return "", errInvalidSignature
+	}
+	return hashBase64(signature), nil
+}
+
+// checkRevokedSignature returns ErrSignatureRevoked if the signature of the verified input is revoked
+func (cs CookieSignature) checkRevokedSignature(ctx context.Context, input string) error {
+	if cs.opts.revokedSignatures == nil {
+		return nil
+	}
+	id, err := SignatureID(input)
+	if err != nil {
+		return err
+	}
+	revoked, err := cs.opts.revokedSignatures.IsRevoked(ctx, id)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrSignatureRevoked
+	}
+	return nil
+}
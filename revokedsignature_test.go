@@ -0,0 +1,63 @@
+package cookiesignature
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRevokedSignatures(t *testing.T) {
+	revoked := NewMemoryRevocationList()
+	cs, _ := NewCookieSignature([]string{"tobiiscool"}, WithRevokedSignatures(revoked))
+
+	signed, _ := cs.Sign("hello")
+	other, _ := cs.Sign("world")
+	sessionID, _ := cs.SignSessionID("sid")
+
+	for _, input := range []string{signed, signed + "==", url.QueryEscape(signed), strings.NewReplacer("+", "-", "/", "_").Replace(signed)} {
+		id, err := SignatureID(input)
+		assertEqual(t, "DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", id, err)
+	}
+
+	id, _ := SignatureID(signed)
+	_ = revoked.Revoke(context.Background(), id, time.Now().Add(time.Hour))
+	sessionSignatureID, _ := SignatureID(sessionID)
+	_ = revoked.Revoke(context.Background(), sessionSignatureID, time.Now().Add(time.Hour))
+
+	if _, err := cs.Unsign(signed); err != ErrSignatureRevoked {
+		t.Fatalf("expected error: %s, got: %s", ErrSignatureRevoked, err)
+	}
+	if _, err := cs.Unsign(url.QueryEscape(signed)); err != ErrSignatureRevoked {
+		t.Fatalf("expected error: %s, got: %s", ErrSignatureRevoked, err)
+	}
+	if _, err := cs.UnsignSessionID(sessionID); err != ErrSignatureRevoked {
+		t.Fatalf("expected error: %s, got: %s", ErrSignatureRevoked, err)
+	}
+	result, err := cs.Unsign(other)
+	assertEqual(t, "world", result, err)
+
+	if _, err := SignatureID("hello"); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+
+	// the algorithm tag isn't part of the ID
+	tagged, _ := NewCookieSignature([]string{"tobiiscool"}, WithAlgorithmTag(), WithRevokedSignatures(revoked))
+	taggedSigned, _ := tagged.Sign("world")
+	result, err = tagged.Unsign(taggedSigned)
+	assertEqual(t, "world", result, err)
+	taggedID, err := SignatureID(taggedSigned)
+	assertEqual(t, taggedSigned[strings.LastIndex(taggedSigned, ":")+1:], taggedID, err)
+	_ = revoked.Revoke(context.Background(), taggedID, time.Now().Add(time.Hour))
+	if _, err := tagged.Unsign(taggedSigned); err != ErrSignatureRevoked {
+		t.Fatalf("expected error: %s, got: %s", ErrSignatureRevoked, err)
+	}
+
+	redis := RedisRevocationList{Client: &testRedisClient{values: map[string]string{}}, Prefix: "revoked-signature:"}
+	cs, _ = NewCookieSignature([]string{"tobiiscool"}, WithRevokedSignatures(redis))
+	_ = redis.Revoke(context.Background(), id, time.Now().Add(time.Hour))
+	if _, err := cs.Unsign(signed); err != ErrSignatureRevoked {
+		t.Fatalf("expected error: %s, got: %s", ErrSignatureRevoked, err)
+	}
+}
@@ -0,0 +1,187 @@
+package cookiesignature
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxRotationPasses bounds Apply, a full rotation is a generate, a promote and a retire
+const maxRotationPasses = 3
+
+// RotationActionType is the kind of a key ring transition
+type RotationActionType int
+
+const (
+	// RotationGenerate adds a new pending key
+	RotationGenerate RotationActionType = iota
+	// RotationPromote makes a pending key active and demotes the active key to verify-only
+	RotationPromote
+	// RotationRetire stops verifying with a verify-only key
+	RotationRetire
+)
+
+var rotationActionTypeNames = []string{"generate", "promote", "retire"}
+
+// String returns the name of the action type
+func (t RotationActionType) String() string {
+	if t < 0 || int(t) >= len(rotationActionTypeNames) {
+		return fmt.Sprintf("RotationActionType(%d)", int(t))
+	}
+	return rotationActionTypeNames[t]
+}
+
+// RotationAction is a key ring transition that is due.
+// KeyID is empty for planned RotationGenerate actions, Apply sets it to the ID of the generated key
+type RotationAction struct {
+	Type  RotationActionType
+	KeyID string
+}
+
+// RotationPolicy tells when the keys of a KeyRing are generated, promoted and retired
+type RotationPolicy struct {
+	// MaxKeyAge is how long a key stays active. Zero disables generation and promotion
+	MaxKeyAge time.Duration
+	// Overlap is how long a new key is pending before it's promoted,
+	// so every instance verifies with it before any instance signs with it
+	Overlap time.Duration
+	// VerifyOnlyPeriod is how long a demoted key keeps verifying, usually the max age of the cookies
+	VerifyOnlyPeriod time.Duration
+	// GenerateKey generates new keys. GenerateKey of the package is used if nil
+	GenerateKey func() (Key, error)
+}
+
+// Plan returns the transitions of the key ring that are due at now, without applying them
+func (p RotationPolicy) Plan(keyRing *KeyRing, now time.Time) []RotationAction {
+	keys := keyRing.Keys()
+	var actions []RotationAction
+
+	if p.MaxKeyAge > 0 {
+		active := activeKey(keys)
+		rotateAt := keyActiveSince(active).Add(p.MaxKeyAge)
+		pending, ok := newestPendingKey(keys)
+		switch {
+		case !ok && !now.Before(rotateAt.Add(-p.Overlap)):
+			actions = append(actions, RotationAction{Type: RotationGenerate})
+		case ok && !now.Before(rotateAt) && !now.Before(pending.CreatedAt.Add(p.Overlap)):
+			actions = append(actions, RotationAction{Type: RotationPromote, KeyID: pending.ID})
+		}
+	}
+
+	for _, key := range keys {
+		if key.State == KeyVerifyOnly && !now.Before(keyDemotedAt(key).Add(p.VerifyOnlyPeriod)) {
+			actions = append(actions, RotationAction{Type: RotationRetire, KeyID: key.ID})
+		}
+	}
+	return actions
+}
+
+// Apply applies the transitions of the key ring that are due at now and returns them.
+// Transitions that become due because of an applied one are applied too,
+// e.g. a key generated with a zero Overlap is promoted at once
+func (p RotationPolicy) Apply(keyRing *KeyRing, now time.Time) ([]RotationAction, error) {
+	var applied []RotationAction
+	for i := 0; i < maxRotationPasses; i++ {
+		actions := p.Plan(keyRing, now)
+		if len(actions) == 0 {
+			break
+		}
+		for _, action := range actions {
+			var err error
+			switch action.Type {
+			case RotationGenerate:
+				action.KeyID, err = p.generate(keyRing, now)
+			case RotationPromote:
+				err = keyRing.promote(action.KeyID, now)
+			case RotationRetire:
+				err = keyRing.setState(action.KeyID, KeyRetired, now)
+			}
+			if err != nil {
+				return applied, fmt.Errorf("%s key %s: %w", action.Type, action.KeyID, err)
+			}
+			applied = append(applied, action)
+		}
+	}
+	return applied, nil
+}
+
+// Next returns the time the next transition of the key ring is due, or zero time if none is scheduled
+func (p RotationPolicy) Next(keyRing *KeyRing) time.Time {
+	keys := keyRing.Keys()
+	var next time.Time
+	schedule := func(at time.Time) {
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+
+	if p.MaxKeyAge > 0 {
+		rotateAt := keyActiveSince(activeKey(keys)).Add(p.MaxKeyAge)
+		if pending, ok := newestPendingKey(keys); ok {
+			promoteAt := pending.CreatedAt.Add(p.Overlap)
+			if promoteAt.Before(rotateAt) {
+				promoteAt = rotateAt
+			}
+			schedule(promoteAt)
+		} else {
+			schedule(rotateAt.Add(-p.Overlap))
+		}
+	}
+	for _, key := range keys {
+		if key.State == KeyVerifyOnly {
+			schedule(keyDemotedAt(key).Add(p.VerifyOnlyPeriod))
+		}
+	}
+	return next
+}
+
+func (p RotationPolicy) generate(keyRing *KeyRing, now time.Time) (string, error) {
+	generateKey := p.GenerateKey
+	if generateKey == nil {
+		generateKey = GenerateKey
+	}
+	key, err := generateKey()
+	if err != nil {
+		return "", err
+	}
+	if key.ID == "" {
+		return "", errors.New("generated key id must not be empty")
+	}
+	key.State = KeyPending
+	key.CreatedAt = now
+	return key.ID, keyRing.Add(key)
+}
+
+func activeKey(keys []Key) Key {
+	for _, key := range keys {
+		if key.State == KeyActive {
+			return key
+		}
+	}
+	return Key{}
+}
+
+func newestPendingKey(keys []Key) (Key, bool) {
+	var result Key
+	found := false
+	for _, key := range keys {
+		if key.State == KeyPending && (!found || key.CreatedAt.After(result.CreatedAt)) {
+			result, found = key, true
+		}
+	}
+	return result, found
+}
+
+func keyActiveSince(key Key) time.Time {
+	if key.PromotedAt.IsZero() {
+		return key.CreatedAt
+	}
+	return key.PromotedAt
+}
+
+func keyDemotedAt(key Key) time.Time {
+	if key.DemotedAt.IsZero() {
+		return keyActiveSince(key)
+	}
+	return key.DemotedAt
+}
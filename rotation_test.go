@@ -0,0 +1,62 @@
+package cookiesignature
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRotationPolicy(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	kr, _ := NewKeyRing(Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive, CreatedAt: start})
+
+	ids := 0
+	policy := RotationPolicy{
+		MaxKeyAge:        30 * 24 * time.Hour,
+		Overlap:          24 * time.Hour,
+		VerifyOnlyPeriod: 7 * 24 * time.Hour,
+		GenerateKey: func() (Key, error) {
+			ids++
+			return Key{ID: fmt.Sprintf("k%d", ids), Secret: []byte(fmt.Sprintf("secret%d", ids))}, nil
+		},
+	}
+
+	expectActions := func(now time.Time, expected string) {
+		t.Helper()
+		actions, err := policy.Apply(kr, now)
+		if err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		if got := fmt.Sprint(actions); got != expected {
+			t.Fatalf("expected actions: %s, got: %s", expected, got)
+		}
+	}
+
+	if next := policy.Next(kr); !next.Equal(start.Add(29 * 24 * time.Hour)) {
+		t.Fatalf("expected the next transition at: %s, got: %s", start.Add(29*24*time.Hour), next)
+	}
+	if actions := policy.Plan(kr, start.Add(28*24*time.Hour)); len(actions) != 0 {
+		t.Fatalf("expected no actions, got: %v", actions)
+	}
+
+	expectActions(start.Add(29*24*time.Hour), "[{generate k1}]")
+	expectActions(start.Add(29*24*time.Hour+time.Hour), "[]")
+	if next := policy.Next(kr); !next.Equal(start.Add(30 * 24 * time.Hour)) {
+		t.Fatalf("expected the next transition at: %s, got: %s", start.Add(30*24*time.Hour), next)
+	}
+
+	expectActions(start.Add(30*24*time.Hour), "[{promote k1}]")
+	if active := kr.Active(); active.ID != "k1" {
+		t.Fatalf("expected active key: k1, got: %s", active.ID)
+	}
+	expectActions(start.Add(37*24*time.Hour), "[{retire k0}]")
+
+	keys := kr.Keys()
+	if keys[0].State != KeyRetired || keys[1].State != KeyActive {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+
+	// with no overlap nor verify-only period, a rotation happens in a single pass
+	policy.Overlap, policy.VerifyOnlyPeriod = 0, 0
+	expectActions(start.Add(60*24*time.Hour), "[{generate k2} {promote k2} {retire k1}]")
+}
@@ -0,0 +1,209 @@
+package cookiesignature
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// KeyStore persists the keys of a KeyRing, so rotated keys survive restarts and are shared by every instance
+type KeyStore interface {
+	Load(ctx context.Context) ([]Key, error)
+	Save(ctx context.Context, keys []Key) error
+}
+
+// FileKeyStore is a KeyStore that stores the keys as JSON in a file, optionally encrypted at rest
+type FileKeyStore struct {
+	Path string
+	// Decrypt decrypts the file on load if not nil. Its signature matches age.Decrypt with the identities bound,
+	// so keys can be read from age-encrypted files checked into an ops repository
+	Decrypt func(src io.Reader) (io.Reader, error)
+	// Encrypt encrypts the file on save if not nil. Its signature matches age.Encrypt with the recipients bound
+	Encrypt func(dst io.Writer) (io.WriteCloser, error)
+	// Passphrase derives the secrets of the passphrase keys on load, which are stored without them.
+	// They are loaded without secrets if empty, see DerivePassphraseKeys
+	Passphrase string
+}
+
+// Load reads the keys from the file
+func (fs FileKeyStore) Load(_ context.Context) ([]Key, error) {
+	file, err := os.Open(fs.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var src io.Reader = file
+	if fs.Decrypt != nil {
+		if src, err = fs.Decrypt(file); err != nil {
+			return nil, err
+		}
+	}
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := decodeKeys(data)
+	if err != nil {
+		return nil, err
+	}
+	return derivePassphraseKeys(keys, fs.Passphrase)
+}
+
+// Save writes the keys to a temporary file readable only by the owner, and renames it over the file,
+// so readers never see a partially written file
+func (fs FileKeyStore) Save(_ context.Context, keys []Key) error {
+	data, err := encodeKeys(keys)
+	if err != nil {
+		return err
+	}
+	if fs.Encrypt != nil {
+		var encrypted bytes.Buffer
+		dst, err := fs.Encrypt(&encrypted)
+		if err != nil {
+			return err
+		}
+		if _, err := dst.Write(data); err != nil {
+			return err
+		}
+		if err := dst.Close(); err != nil {
+			return err
+		}
+		data = encrypted.Bytes()
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(fs.Path), filepath.Base(fs.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), fs.Path)
+}
+
+// Rotator rotates the keys of a KeyRing in the background according to a RotationPolicy.
+// Rotated keys are saved to the Store before the live key ring is updated,
+// so a failed save never leaves the key ring signing with a key that other instances can't load
+type Rotator struct {
+	KeyRing *KeyRing
+	Policy  RotationPolicy
+	// Store persists the rotated keys. Keys are only rotated in memory if nil
+	Store KeyStore
+	// Interval between two checks of the policy. Defaults to one minute
+	Interval time.Duration
+	// OnRotate is called with the applied transitions after each rotation
+	OnRotate func(actions []RotationAction)
+	// OnError is called with the errors of the background rotations
+	OnError func(err error)
+
+	mu sync.Mutex
+}
+
+// Rotate applies the transitions of the policy that are due, saves the keys and updates the key ring
+func (r *Rotator) Rotate(ctx context.Context) ([]RotationAction, error) {
+	var actions []RotationAction
+	err := r.Update(ctx, func(draft *KeyRing) error {
+		var err error
+		actions, err = r.Policy.Apply(draft, timeNow())
+		return err
+	})
+	if err != nil || len(actions) == 0 {
+		return nil, err
+	}
+	if r.OnRotate != nil {
+		r.OnRotate(actions)
+	}
+	return actions, nil
+}
+
+// RotateNow generates a new key and promotes it at once, regardless of the policy, e.g. when the active key leaked.
+// Instances that didn't load the new key yet fail to verify the cookies it signs until they do
+func (r *Rotator) RotateNow(ctx context.Context) ([]RotationAction, error) {
+	var actions []RotationAction
+	err := r.Update(ctx, func(draft *KeyRing) error {
+		now := timeNow()
+		id, err := r.Policy.generate(draft, now)
+		if err != nil {
+			return err
+		}
+		actions = []RotationAction{{Type: RotationGenerate, KeyID: id}, {Type: RotationPromote, KeyID: id}}
+		return draft.promote(id, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if r.OnRotate != nil {
+		r.OnRotate(actions)
+	}
+	return actions, nil
+}
+
+// Update applies the change to a copy of the key ring, saves the keys and updates the key ring.
+// Updates are serialized with the rotations, so a manual change never races a background rotation
+func (r *Rotator) Update(ctx context.Context, change func(draft *KeyRing) error) error {
+	if r.KeyRing == nil {
+		return errors.New("key ring must be provided")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// apply the change to a copy, the live key ring is only updated once the keys are saved
+	draft, err := NewKeyRing(r.KeyRing.Keys()...)
+	if err != nil {
+		return err
+	}
+	if err := change(draft); err != nil {
+		return err
+	}
+
+	keys := draft.Keys()
+	if reflect.DeepEqual(keys, r.KeyRing.Keys()) {
+		return nil
+	}
+	if r.Store != nil {
+		if err := r.Store.Save(ctx, keys); err != nil {
+			return err
+		}
+	}
+	return r.KeyRing.Replace(keys)
+}
+
+// Run rotates the keys at once and then on every tick of the interval, until the context is done
+func (r *Rotator) Run(ctx context.Context) error {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Rotate(ctx); err != nil && r.OnError != nil {
+			r.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package cookiesignature
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type failingKeyStore struct{}
+
+func (failingKeyStore) Load(context.Context) ([]Key, error) { return nil, errors.New("load failed") }
+func (failingKeyStore) Save(context.Context, []Key) error   { return errors.New("save failed") }
+
+func TestRotator(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return start.Add(time.Hour) }
+	defer func() { timeNow = time.Now }()
+
+	dir, err := ioutil.TempDir("", "cookiesignature")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	store := FileKeyStore{Path: filepath.Join(dir, "keys.json")}
+
+	kr, _ := NewKeyRing(Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive, CreatedAt: start})
+	rotator := &Rotator{
+		KeyRing: kr,
+		Policy:  RotationPolicy{MaxKeyAge: time.Hour, VerifyOnlyPeriod: time.Hour},
+		Store:   failingKeyStore{},
+	}
+
+	if _, err := rotator.Rotate(context.Background()); err == nil || err.Error() != "save failed" {
+		t.Fatalf("expected error: save failed, got: %s", err)
+	}
+	if keys := kr.Keys(); len(keys) != 1 || kr.Active().ID != "k0" {
+		t.Fatalf("expected the key ring to be unchanged, got: %+v", keys)
+	}
+
+	rotator.Store = store
+	actions, err := rotator.Rotate(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(actions) != 2 || actions[0].Type != RotationGenerate || actions[1].Type != RotationPromote {
+		t.Fatalf("unexpected actions: %v", actions)
+	}
+	if kr.Active().ID == "k0" {
+		t.Fatalf("expected a new active key")
+	}
+
+	saved, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(saved) != 2 || saved[0].State != KeyVerifyOnly || saved[1].ID != kr.Active().ID || string(saved[1].Secret) != string(kr.Active().Secret) {
+		t.Fatalf("unexpected saved keys: %+v", saved)
+	}
+
+	if actions, err := rotator.Rotate(context.Background()); err != nil || len(actions) != 0 {
+		t.Fatalf("expected no actions, got: %v, %v", actions, err)
+	}
+}
+
+func TestRotatorRun(t *testing.T) {
+	kr, _ := NewKeyRing(Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive, CreatedAt: time.Now().Add(-time.Hour)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rotated := make(chan []RotationAction, 1)
+	rotator := &Rotator{
+		KeyRing:  kr,
+		Policy:   RotationPolicy{MaxKeyAge: time.Minute},
+		Interval: time.Millisecond,
+		OnRotate: func(actions []RotationAction) {
+			select {
+			case rotated <- actions:
+			default:
+			}
+		},
+	}
+
+	done := make(chan error)
+	go func() { done <- rotator.Run(ctx) }()
+
+	select {
+	case <-rotated:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the keys to be rotated")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected error: %s, got: %s", context.Canceled, err)
+	}
+	if kr.Active().ID == "k0" {
+		t.Fatalf("expected a new active key")
+	}
+}
+
+func TestRotatorRotateNow(t *testing.T) {
+	kr, _ := NewKeyRing(Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive, CreatedAt: time.Now()})
+	rotator := &Rotator{KeyRing: kr, Store: failingKeyStore{}}
+
+	if _, err := rotator.RotateNow(context.Background()); err == nil || err.Error() != "save failed" {
+		t.Fatalf("expected error: save failed, got: %v", err)
+	}
+	if kr.Active().ID != "k0" {
+		t.Fatalf("expected the key ring to be left unchanged")
+	}
+
+	rotator.Store = nil
+	actions, err := rotator.RotateNow(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(actions) != 2 || actions[1].Type != RotationPromote || kr.Active().ID != actions[1].KeyID {
+		t.Fatalf("unexpected actions: %+v", actions)
+	}
+
+	if err := rotator.Update(context.Background(), func(draft *KeyRing) error { return draft.Retire("k0") }); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if keys := kr.Keys(); keys[0].State != KeyRetired {
+		t.Fatalf("expected the key to be retired, got: %s", keys[0].State)
+	}
+}
+
+func TestEncryptedFileKeyStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cookiesignature")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// AES-CTR stands in for age, both wrap a reader and a writer
+	block, _ := aes.NewCipher(bytes.Repeat([]byte{1}, 32))
+	iv := make([]byte, aes.BlockSize)
+	store := FileKeyStore{
+		Path: filepath.Join(dir, "keys.json.age"),
+		Decrypt: func(src io.Reader) (io.Reader, error) {
+			return cipher.StreamReader{S: cipher.NewCTR(block, iv), R: src}, nil
+		},
+		Encrypt: func(dst io.Writer) (io.WriteCloser, error) {
+			return cipher.StreamWriter{S: cipher.NewCTR(block, iv), W: dst}, nil
+		},
+	}
+
+	keys := []Key{{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive}}
+	if err := store.Save(context.Background(), keys); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	data, _ := ioutil.ReadFile(store.Path)
+	if bytes.Contains(data, []byte(`"k0"`)) {
+		t.Fatalf("expected the file to be encrypted, got: %s", data)
+	}
+
+	loaded, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(loaded) != 1 || string(loaded[0].Secret) != "tobiiscool" {
+		t.Fatalf("unexpected keys: %+v", loaded)
+	}
+
+	if _, err := (FileKeyStore{Path: store.Path}).Load(context.Background()); err == nil {
+		t.Fatalf("expected an error loading the encrypted file without decryption")
+	}
+}
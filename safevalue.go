@@ -0,0 +1,34 @@
+package cookiesignature
+
+import (
+	"errors"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrUnsafeValue is returned by WithSafeValues for values holding control characters or invalid UTF-8
+var ErrUnsafeValue = errors.New("value contains control characters or invalid UTF-8")
+
+// WithSafeValues rejects values containing control characters, NUL bytes included, or invalid UTF-8 sequences
+// before signing and after unsigning, so header-injection-ish payloads can't ride inside otherwise valid signed cookies.
+// The decoded bytes of UnsignBase64 aren't checked, they're binary by design
+func WithSafeValues() Option {
+	return func(o *options) {
+		o.safeValues = true
+	}
+}
+
+func (o options) checkValue(value string) error {
+	if !o.safeValues {
+		return nil
+	}
+	if !utf8.ValidString(value) {
+		return ErrUnsafeValue
+	}
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			return ErrUnsafeValue
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package cookiesignature
+
+import "testing"
+
+func TestSafeValues(t *testing.T) {
+	plain, _ := NewCookieSignature([]string{"tobiiscool"})
+	cs, _ := NewCookieSignature([]string{"tobiiscool"}, WithSafeValues())
+
+	val, err := cs.Sign("héllo wörld")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	result, err := cs.Unsign(val)
+	assertEqual(t, "héllo wörld", result, err)
+
+	for _, input := range []string{"hello\x00", "hello\r\nSet-Cookie: admin=1", "hello\tworld", "hello\x7f", "hello\u0085", "hello\xff"} {
+		if _, err := cs.Sign(input); err != ErrUnsafeValue {
+			t.Fatalf("expected error: %s for %q, got: %v", ErrUnsafeValue, input, err)
+		}
+		if _, err := cs.SignDetached(input); err != ErrUnsafeValue {
+			t.Fatalf("expected error: %s for %q, got: %v", ErrUnsafeValue, input, err)
+		}
+		signed, err := plain.Sign(input)
+		if err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		if _, err := cs.Unsign(signed); err != ErrUnsafeValue {
+			t.Fatalf("expected error: %s for %q, got: %v", ErrUnsafeValue, input, err)
+		}
+	}
+
+	signed, err := cs.SignBase64("hello\x00\xff")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	decoded, err := cs.UnsignBase64(signed)
+	assertEqual(t, "hello\x00\xff", string(decoded), err)
+}
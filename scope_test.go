@@ -0,0 +1,70 @@
+package cookiesignature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestSignScoped(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	admin := CookieScope{Domain: "example.com", Path: "/admin", SameSite: http.SameSiteStrictMode}
+	signed, err := cs.SignScoped("hello", admin)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	val, err := cs.UnsignScoped(signed, admin)
+	assertEqual(t, "hello", val, err)
+
+	for _, scope := range []CookieScope{
+		{Domain: "example.com", Path: "/", SameSite: http.SameSiteStrictMode},
+		{Domain: "other.com", Path: "/admin", SameSite: http.SameSiteStrictMode},
+		{Domain: "example.com", Path: "/admin", SameSite: http.SameSiteLaxMode},
+	} {
+		if _, err := cs.UnsignScoped(signed, scope); err != errInvalidSignature {
+			t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+		}
+	}
+	if _, err := cs.Unsign(signed); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+
+	// a MAC over the same fields without the scope purpose isn't a scoped signature
+	hashBytes, _ := cs.signingMAC(encodeValues([]string{"hello", "example.com", "/admin", strconv.Itoa(int(http.SameSiteStrictMode))}))
+	if _, err := cs.UnsignScoped("hello."+hashBase64(hashBytes), admin); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+}
+
+func TestScopedCookie(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	cookie := &http.Cookie{Name: "sid", Value: "hello", Domain: ".example.com", Path: "/admin", SameSite: http.SameSiteLaxMode}
+	if err := cs.SetScopedCookie(recorder, cookie); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	written := recorder.Result().Cookies()[0]
+	scope := ScopeOf(cookie)
+
+	request := httptest.NewRequest(http.MethodGet, "http://app.example.com:8080/admin/users", nil)
+	request.AddCookie(written)
+	val, err := cs.ReadScopedCookie(request, "sid", scope)
+	assertEqual(t, "hello", val, err)
+
+	for _, target := range []string{"http://example.org/admin", "http://example.com/administrator", "http://example.com/"} {
+		request := httptest.NewRequest(http.MethodGet, target, nil)
+		request.AddCookie(written)
+		if _, err := cs.ReadScopedCookie(request, "sid", scope); err != ErrScopeMismatch {
+			t.Fatalf("expected error: %s for %s, got: %s", ErrScopeMismatch, target, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package cookiesignature
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// SecretEncoding is the encoding of the secret strings passed to NewCookieSignature
+type SecretEncoding int
+
+const (
+	// SecretEncodingRaw uses the bytes of the secret strings as they are. It is the default
+	SecretEncodingRaw SecretEncoding = iota
+	// SecretEncodingBase64 decodes base64 secrets, in either the standard or the url-safe alphabet, with or without padding
+	SecretEncodingBase64
+	// SecretEncodingHex decodes hex secrets
+	SecretEncodingHex
+)
+
+// WithSecretEncoding declares that the secret strings are encoded binary keys that are decoded before use,
+// so high-entropy keys can be injected through text-only configuration systems
+func WithSecretEncoding(encoding SecretEncoding) Option {
+	return func(o *options) {
+		o.secretEncoding = encoding
+	}
+}
+
+func decodeSecret(secret string, encoding SecretEncoding) ([]byte, error) {
+	switch encoding {
+	case SecretEncodingBase64:
+		return base64.RawStdEncoding.DecodeString(signatureDecoder.Replace(strings.TrimRight(secret, "=")))
+	case SecretEncodingHex:
+		return hex.DecodeString(secret)
+	default:
+		return []byte(secret), nil
+	}
+}
@@ -0,0 +1,34 @@
+package cookiesignature
+
+import "testing"
+
+func TestSecretEncoding(t *testing.T) {
+	for _, test := range []struct {
+		encoding SecretEncoding
+		secret   string
+	}{
+		{SecretEncodingRaw, "tobiiscool"},
+		{SecretEncodingBase64, "dG9iaWlzY29vbA=="},
+		{SecretEncodingBase64, "dG9iaWlzY29vbA"},
+		{SecretEncodingHex, "746f62696973636f6f6c"},
+	} {
+		cs, err := NewCookieSignature([]string{test.secret}, WithSecretEncoding(test.encoding))
+		if err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		signed, err := cs.Sign("hello")
+		assertEqual(t, "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", signed, err)
+	}
+
+	binary, err := NewCookieSignature([]string{"_-8="}, WithSecretEncoding(SecretEncodingBase64))
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if string(binary.signingSecret()) != "\xff\xef" {
+		t.Fatalf("unexpected secret: %x", binary.signingSecret())
+	}
+
+	if _, err := NewCookieSignature([]string{"tobiiscool", "xyz"}, WithSecretEncoding(SecretEncodingHex)); err == nil || err.Error() != "secret key at index 0: encoding/hex: invalid byte: U+0074 't'" {
+		t.Fatalf("expected an invalid hex error, got: %s", err)
+	}
+}
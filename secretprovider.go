@@ -0,0 +1,104 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SecretProvider loads the keys of a KeyRing from an external secret source
+type SecretProvider interface {
+	Keys(ctx context.Context) ([]Key, error)
+}
+
+// WatchSecretProvider polls the provider on every interval and replaces the keys of the key ring when they changed,
+// until the context is done. Errors are reported to onError, if not nil, and the key ring is left unchanged
+func WatchSecretProvider(ctx context.Context, provider SecretProvider, keyRing *KeyRing, interval time.Duration, onError func(err error)) error {
+	if interval <= 0 {
+		return errors.New("watch interval must be positive")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last []Key
+	for {
+		keys, err := provider.Keys(ctx)
+		if err == nil && !reflect.DeepEqual(keys, last) {
+			if err = keyRing.Replace(keys); err == nil {
+				last = keys
+			}
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// keysFromSecrets builds the keys of named secrets, one secret per name.
+// Names are sorted in natural order, e.g. key.2 before key.10, and the last one is the active key, the others verify only.
+// Trailing newlines of the secrets are trimmed, as files written by editors and shells usually end with one
+func keysFromSecrets(secrets map[string][]byte) ([]Key, error) {
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no secret found")
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return naturalLess(names[i], names[j])
+	})
+
+	keys := make([]Key, len(names))
+	for i, name := range names {
+		secret := strings.TrimRight(string(secrets[name]), "\r\n")
+		if secret == "" {
+			return nil, fmt.Errorf("secret %s must not be empty", name)
+		}
+		keys[i] = Key{ID: name, Secret: []byte(secret), State: KeyVerifyOnly}
+	}
+	keys[len(keys)-1].State = KeyActive
+	return keys, nil
+}
+
+// naturalLess compares the strings with their runs of digits compared by numeric value
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		aDigits, bDigits := leadingDigits(a), leadingDigits(b)
+		if aDigits == "" || bDigits == "" {
+			if a[0] != b[0] {
+				return a[0] < b[0]
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		aNumber, bNumber := strings.TrimLeft(aDigits, "0"), strings.TrimLeft(bDigits, "0")
+		if len(aNumber) != len(bNumber) {
+			return len(aNumber) < len(bNumber)
+		}
+		if aNumber != bNumber {
+			return aNumber < bNumber
+		}
+		a, b = a[len(aDigits):], b[len(bDigits):]
+	}
+	return len(a) < len(b)
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
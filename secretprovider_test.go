@@ -0,0 +1,72 @@
+package cookiesignature
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testSecretProvider struct {
+	mu   sync.Mutex
+	keys []Key
+}
+
+func (p *testSecretProvider) Keys(context.Context) ([]Key, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.keys, nil
+}
+
+func TestNaturalLess(t *testing.T) {
+	names := []string{"key.10", "key", "key.2", "key.1", "key.02a", "a"}
+	sort.Slice(names, func(i, j int) bool { return naturalLess(names[i], names[j]) })
+	assertEqual(t, "a,key,key.1,key.2,key.02a,key.10", strings.Join(names, ","), nil)
+}
+
+func TestKeysFromSecrets(t *testing.T) {
+	keys, err := keysFromSecrets(map[string][]byte{"key.10": []byte("luna\n"), "key.9": []byte("tobiiscool")})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(keys) != 2 || keys[0].ID != "key.9" || keys[0].State != KeyVerifyOnly || keys[1].State != KeyActive || string(keys[1].Secret) != "luna" {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+
+	if _, err := keysFromSecrets(map[string][]byte{"key": []byte("\n")}); err == nil || err.Error() != "secret key must not be empty" {
+		t.Fatalf("expected error: secret key must not be empty, got: %s", err)
+	}
+}
+
+func TestWatchSecretProvider(t *testing.T) {
+	provider := &testSecretProvider{keys: []Key{{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive}}}
+	keyRing, _ := NewKeyRing(Key{ID: "initial", Secret: []byte("secret"), State: KeyActive})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- WatchSecretProvider(ctx, provider, keyRing, time.Millisecond, nil) }()
+
+	waitForActiveKey := func(id string) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for keyRing.Active().ID != id {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected active key: %s, got: %s", id, keyRing.Active().ID)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitForActiveKey("k0")
+
+	provider.mu.Lock()
+	provider.keys = []Key{{ID: "k0", Secret: []byte("tobiiscool"), State: KeyVerifyOnly}, {ID: "k1", Secret: []byte("luna"), State: KeyActive}}
+	provider.mu.Unlock()
+	waitForActiveKey("k1")
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected error: %s, got: %s", context.Canceled, err)
+	}
+}
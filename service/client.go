@@ -0,0 +1,232 @@
+package service
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultClientTimeout = 5 * time.Second
+	defaultClientBackoff = 100 * time.Millisecond
+)
+
+// ErrInvalidSignature is returned by Client.Unsign when the service rejects the signed value
+var ErrInvalidSignature = errors.New("invalid signature")
+
+var timeNow = time.Now
+
+// ClientOptions configures a Client
+type ClientOptions struct {
+	// HTTPClient sends the requests. Defaults to http.DefaultClient
+	HTTPClient *http.Client
+	// Timeout of each attempt. Defaults to 5 seconds
+	Timeout time.Duration
+	// Retries is the number of attempts after the first one, on network errors and 5xx responses
+	Retries int
+	// Backoff is the delay before the first retry, doubled before each next one. Defaults to 100ms
+	Backoff time.Duration
+	// CacheSize and CacheTTL cache up to CacheSize verified values for CacheTTL,
+	// so hot cookies are verified once per TTL instead of once per request. The cache is disabled if either is zero.
+	// A cached value stays valid until it expires, even if its key is retired meanwhile, so keep the TTL short
+	CacheSize int
+	CacheTTL  time.Duration
+	// Token is the bearer token authorizing the requests of /sign and /unsign
+	Token string
+}
+
+// Client calls the Sign and Unsign endpoints of a signing service
+type Client struct {
+	baseURL string
+	options ClientOptions
+	cache   *verifyCache
+}
+
+// StatusError is returned when the service answers with an unexpected status
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("signing service responded %d: %s", e.StatusCode, e.Message)
+}
+
+// NewClient creates a new Client instance for the service at baseURL, e.g. http://cookies.internal:8080
+func NewClient(baseURL string, options ClientOptions) (*Client, error) {
+	if baseURL == "" {
+		return nil, errors.New("base url must be provided")
+	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = http.DefaultClient
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = defaultClientTimeout
+	}
+	if options.Backoff <= 0 {
+		options.Backoff = defaultClientBackoff
+	}
+
+	client := &Client{baseURL: strings.TrimRight(baseURL, "/"), options: options}
+	if options.CacheSize > 0 && options.CacheTTL > 0 {
+		client.cache = &verifyCache{
+			size:    options.CacheSize,
+			ttl:     options.CacheTTL,
+			entries: make(map[[sha256.Size]byte]*list.Element, options.CacheSize),
+			order:   list.New(),
+		}
+	}
+	return client, nil
+}
+
+// Sign signs the value with the active key of the service
+func (c *Client) Sign(ctx context.Context, value string) (string, error) {
+	var response signResponse
+	if err := c.call(ctx, "/sign", signRequest{Value: value}, &response); err != nil {
+		return "", err
+	}
+	return response.Signed, nil
+}
+
+// Unsign verifies the signed value with the keys of the service and returns the value.
+// It returns ErrInvalidSignature if the service rejects it
+func (c *Client) Unsign(ctx context.Context, signed string) (string, error) {
+	if value, ok := c.cache.get(signed); ok {
+		return value, nil
+	}
+
+	var response signRequest
+	err := c.call(ctx, "/unsign", signResponse{Signed: signed}, &response)
+	if statusErr, ok := err.(*StatusError); ok && statusErr.StatusCode == http.StatusUnprocessableEntity {
+		return "", ErrInvalidSignature
+	}
+	if err != nil {
+		return "", err
+	}
+	c.cache.add(signed, response.Value)
+	return response.Value, nil
+}
+
+// Healthy verifies that the service is reachable and can sign, so a readiness probe can keep
+// a pod whose signing service is down out of traffic. It isn't retried, probes run periodically anyway
+func (c *Client) Healthy(ctx context.Context) error {
+	var response healthResponse
+	return c.attempt(ctx, http.MethodGet, "/healthz", nil, &response)
+}
+
+// call posts the request and decodes the response, retrying on network errors and 5xx responses
+func (c *Client) call(ctx context.Context, path string, request interface{}, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	backoff := c.options.Backoff
+	for attempt := 0; ; attempt++ {
+		err = c.attempt(ctx, http.MethodPost, path, body, response)
+		statusErr, isStatusErr := err.(*StatusError)
+		if err == nil || attempt >= c.options.Retries || ctx.Err() != nil || (isStatusErr && statusErr.StatusCode < 500) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method string, path string, body []byte, response interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, c.options.Timeout)
+	defer cancel()
+
+	r, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	if c.options.Token != "" {
+		r.Header.Set("Authorization", "Bearer "+c.options.Token)
+	}
+	resp, err := c.options.HTTPClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResponse errorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&errResponse)
+		return &StatusError{StatusCode: resp.StatusCode, Message: errResponse.Error}
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// verifyCache is a bounded cache of verified values, evicting the oldest entry when full
+type verifyCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[[sha256.Size]byte]*list.Element
+	order   *list.List
+}
+
+type verifyCacheEntry struct {
+	key       [sha256.Size]byte
+	value     string
+	expiresAt time.Time
+}
+
+// get returns the cached value of the signed value
+func (c *verifyCache) get(signed string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	key := sha256.Sum256([]byte(signed))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	entry := element.Value.(*verifyCacheEntry)
+	if !timeNow().Before(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return "", false
+	}
+	return entry.value, true
+}
+
+func (c *verifyCache) add(signed string, value string) {
+	if c == nil {
+		return
+	}
+	key := sha256.Sum256([]byte(signed))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+	for c.order.Len() >= c.size {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*verifyCacheEntry).key)
+	}
+	c.entries[key] = c.order.PushBack(&verifyCacheEntry{key: key, value: value, expiresAt: timeNow().Add(c.ttl)})
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cookiesignature "github.com/hgiasac/go-cookie-signature"
+)
+
+func TestClient(t *testing.T) {
+	s, _ := newTestService(t)
+	var calls, failures int32
+	atomic.StoreInt32(&failures, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		s.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	if _, err := NewClient("", ClientOptions{}); err == nil {
+		t.Fatalf("expected a base url error")
+	}
+	client, err := NewClient(server.URL+"/", ClientOptions{Retries: 2, Backoff: time.Millisecond, CacheSize: 10, CacheTTL: time.Minute, Token: "sign-token"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	ctx := context.Background()
+	signed, err := client.Sign(ctx, "hello")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if signed != "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI" || atomic.LoadInt32(&calls) != 3 {
+		t.Fatalf("expected the signed value after 2 retries, got: %s after %d calls", signed, calls)
+	}
+
+	for i := 0; i < 2; i++ {
+		value, err := client.Unsign(ctx, signed)
+		if err != nil || value != "hello" {
+			t.Fatalf("expected: hello, got: %s, %v", value, err)
+		}
+	}
+	if atomic.LoadInt32(&calls) != 4 {
+		t.Fatalf("expected the second verification to be cached, got %d calls", calls)
+	}
+
+	if _, err := client.Unsign(ctx, "hello.invalid"); err != ErrInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", ErrInvalidSignature, err)
+	}
+	if atomic.LoadInt32(&calls) != 5 {
+		t.Fatalf("expected client errors not to be retried, got %d calls", calls)
+	}
+
+	atomic.StoreInt32(&failures, 10)
+	_, err = client.Sign(ctx, "hello")
+	if statusErr, ok := err.(*StatusError); !ok || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected a status error, got: %v", err)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	client, _ := NewClient(server.URL, ClientOptions{Timeout: 10 * time.Millisecond})
+	if _, err := client.Sign(context.Background(), "hello"); err == nil {
+		t.Fatalf("expected a timeout error")
+	}
+}
+
+type unhealthyKeyStore struct {
+	testKeyStore
+}
+
+func (s *unhealthyKeyStore) Healthy(context.Context) error {
+	return errors.New("store is unreachable")
+}
+
+func TestClientHealthy(t *testing.T) {
+	s, rotator := newTestService(t)
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	client, _ := NewClient(server.URL, ClientOptions{})
+	if err := client.Healthy(context.Background()); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	var reported []error
+	s.onHealthError = func(err error) { reported = append(reported, err) }
+	rotator.Store = &unhealthyKeyStore{}
+	err := client.Healthy(context.Background())
+	if statusErr, ok := err.(*StatusError); !ok || statusErr.StatusCode != http.StatusServiceUnavailable || statusErr.Message != "unhealthy" {
+		t.Fatalf("expected a generic status error, got: %v", err)
+	}
+	if len(reported) != 1 || reported[0].Error() != "store is unreachable" {
+		t.Fatalf("expected the error to be reported, got: %v", reported)
+	}
+}
+
+var _ cookiesignature.HealthChecker = &Client{}
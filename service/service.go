@@ -0,0 +1,343 @@
+// Package service serves the signatures of a key ring over HTTP,
+// so applications written in other languages sign and verify cookies with the same keys
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	cookiesignature "github.com/hgiasac/go-cookie-signature"
+)
+
+const (
+	adminKeysPath           = "/admin/keys/"
+	defaultDrainQuietPeriod = 24 * time.Hour
+)
+
+// Config configures a Service
+type Config struct {
+	// AuthorizeSigning authorizes the requests of /sign and /unsign, which mint and verify cookies. It must be provided,
+	// a service open to every client is configured explicitly with a function returning true
+	AuthorizeSigning func(r *http.Request) bool
+	// Authorize authorizes the requests of the admin endpoints. The admin endpoints are disabled if nil
+	Authorize func(r *http.Request) bool
+	// Options configure the signer of the service
+	Options []cookiesignature.Option
+	// DrainQuietPeriod is how long a draining key must verify nothing before it is reported safe to retire.
+	// Defaults to 24 hours
+	DrainQuietPeriod time.Duration
+	// OnHealthError is called with the errors of the health checks, which /healthz doesn't return
+	OnHealthError func(err error)
+}
+
+// Service is the HTTP handler of the signing service. It serves
+// the sign endpoints, authorized by Config.AuthorizeSigning,
+//
+//	POST /sign                          {"value": "..."} → {"signed": "..."}
+//	POST /unsign                        {"signed": "..."} → {"value": "..."}
+//	GET  /healthz                       {"status": "ok"}, or 503 if the store of the rotator is unhealthy
+//	GET  /admin/keys                    the keys of the key ring, without their secrets
+//	POST /admin/rotate                  generates a new key and promotes it at once
+//	POST /admin/keys/{id}/verify-only   demotes the key to verify-only
+//	POST /admin/keys/{id}/drain         demotes the key to verify-only and reports its drain status
+//	GET  /admin/drain                   the drain status of every verify-only key
+//
+// Admin changes are saved to the store of the rotator before the key ring is updated
+type Service struct {
+	rotator   *cookiesignature.Rotator
+	signer    *cookiesignature.CookieSignature
+	authorize func(r *http.Request) bool
+	// authorizeSigning authorizes the requests of /sign and /unsign
+	authorizeSigning func(r *http.Request) bool
+	mux              *http.ServeMux
+	started          time.Time
+	quietPeriod      time.Duration
+	// onHealthError is called with the errors of the health checks
+	onHealthError func(err error)
+}
+
+// KeyInfo describes a key of the key ring, identified by the fingerprint of its secret
+type KeyInfo struct {
+	ID          string                   `json:"id"`
+	State       cookiesignature.KeyState `json:"state"`
+	Fingerprint string                   `json:"fingerprint"`
+	CreatedAt   time.Time                `json:"created_at"`
+	// PromotedAt and DemotedAt are nil until the key is promoted or demoted
+	PromotedAt *time.Time `json:"promoted_at,omitempty"`
+	DemotedAt  *time.Time `json:"demoted_at,omitempty"`
+}
+
+// DrainStatus reports the verification traffic remaining on a verify-only key, so operators know when retiring it is safe.
+// Verifications are counted since the service started
+type DrainStatus struct {
+	ID            string    `json:"id"`
+	DrainingSince time.Time `json:"draining_since"`
+	Verifications uint64    `json:"verifications"`
+	// LastVerifiedAt is nil if the key verified nothing since the service started
+	LastVerifiedAt *time.Time `json:"last_verified_at,omitempty"`
+	// SafeToRetire is true once the key verified nothing for the quiet period, whether the service ran all along or not
+	SafeToRetire bool `json:"safe_to_retire"`
+}
+
+type signRequest struct {
+	Value string `json:"value"`
+}
+
+type signResponse struct {
+	Signed string `json:"signed"`
+}
+
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New creates a new Service instance that signs with the key ring of the rotator
+func New(rotator *cookiesignature.Rotator, config Config) (*Service, error) {
+	if rotator == nil {
+		return nil, errors.New("rotator must be provided")
+	}
+	if config.AuthorizeSigning == nil {
+		return nil, errors.New("signing authorizer must be provided")
+	}
+	signer, err := cookiesignature.NewCookieSignatureFromKeyRing(rotator.KeyRing, config.Options...)
+	if err != nil {
+		return nil, err
+	}
+
+	quietPeriod := config.DrainQuietPeriod
+	if quietPeriod <= 0 {
+		quietPeriod = defaultDrainQuietPeriod
+	}
+	s := &Service{
+		rotator:          rotator,
+		signer:           signer,
+		authorize:        config.Authorize,
+		authorizeSigning: config.AuthorizeSigning,
+		mux:              http.NewServeMux(),
+		started:          timeNow(),
+		quietPeriod:      quietPeriod,
+		onHealthError:    config.OnHealthError,
+	}
+	s.mux.HandleFunc("/sign", s.authorized(s.authorizeSigning, http.MethodPost, s.sign))
+	s.mux.HandleFunc("/unsign", s.authorized(s.authorizeSigning, http.MethodPost, s.unsign))
+	s.mux.HandleFunc("/healthz", s.healthz)
+	if s.authorize != nil {
+		s.mux.HandleFunc("/admin/keys", s.admin(http.MethodGet, s.keys))
+		s.mux.HandleFunc("/admin/rotate", s.admin(http.MethodPost, s.rotate))
+		s.mux.HandleFunc("/admin/drain", s.admin(http.MethodGet, s.drainStatuses))
+		s.mux.HandleFunc(adminKeysPath, s.admin(http.MethodPost, s.keyAction))
+	}
+	return s, nil
+}
+
+// BearerToken authorizes the requests with the token in their Authorization header
+func BearerToken(token string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		header := r.Header.Get("Authorization")
+		return token != "" && strings.HasPrefix(header, "Bearer ") &&
+			cookiesignature.EqualString(strings.TrimPrefix(header, "Bearer "), token)
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Service) admin(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return s.authorized(s.authorize, method, handler)
+}
+
+func (s *Service) authorized(authorize func(r *http.Request) bool, method string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !authorize(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (s *Service) sign(w http.ResponseWriter, r *http.Request) {
+	var body signRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	signed, err := s.signer.SignContext(r.Context(), body.Value)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, signResponse{Signed: signed})
+}
+
+func (s *Service) unsign(w http.ResponseWriter, r *http.Request) {
+	var body signResponse
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	value, err := s.signer.UnsignContext(r.Context(), body.Signed)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, signRequest{Value: value})
+}
+
+// healthz checks the store of the rotator if it is a HealthChecker, the key ring always holds an active key.
+// The probe is unauthenticated, so the error is passed to OnHealthError instead of being returned
+func (s *Service) healthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	if checker, ok := s.rotator.Store.(cookiesignature.HealthChecker); ok {
+		if err := checker.Healthy(r.Context()); err != nil {
+			if s.onHealthError != nil {
+				s.onHealthError(err)
+			}
+			writeError(w, http.StatusServiceUnavailable, errors.New("unhealthy"))
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+func (s *Service) keys(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, keyInfos(s.rotator.KeyRing.Keys()))
+}
+
+func (s *Service) rotate(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.rotator.RotateNow(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, keyInfos(s.rotator.KeyRing.Keys()))
+}
+
+func (s *Service) keyAction(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, adminKeysPath)
+	index := strings.LastIndex(path, "/")
+	if index < 0 {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	id, action := path[:index], path[index+1:]
+	if action != "verify-only" && action != "drain" {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	if !hasKey(s.rotator.KeyRing.Keys(), id) {
+		writeError(w, http.StatusNotFound, errors.New("key not found"))
+		return
+	}
+
+	err := s.rotator.Update(r.Context(), func(draft *cookiesignature.KeyRing) error {
+		return draft.Demote(id)
+	})
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if action == "drain" {
+		for _, status := range s.drainStatus() {
+			if status.ID == id {
+				writeJSON(w, http.StatusOK, status)
+				return
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, keyInfos(s.rotator.KeyRing.Keys()))
+}
+
+func (s *Service) drainStatuses(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.drainStatus())
+}
+
+// drainStatus returns the drain status of the verify-only keys. A key is quiet since it last verified a value,
+// it was demoted or the service started, whichever is the latest
+func (s *Service) drainStatus() []DrainStatus {
+	usage := s.signer.KeyUsage()
+	lastUsed := s.signer.KeyLastUsed()
+	now := timeNow()
+
+	statuses := []DrainStatus{}
+	for _, key := range s.rotator.KeyRing.Keys() {
+		if key.State != cookiesignature.KeyVerifyOnly {
+			continue
+		}
+		quietSince := s.started
+		if key.DemotedAt.After(quietSince) {
+			quietSince = key.DemotedAt
+		}
+		if lastUsed[key.ID].After(quietSince) {
+			quietSince = lastUsed[key.ID]
+		}
+		statuses = append(statuses, DrainStatus{
+			ID:             key.ID,
+			DrainingSince:  key.DemotedAt,
+			Verifications:  usage[key.ID],
+			LastVerifiedAt: optionalTime(lastUsed[key.ID]),
+			SafeToRetire:   now.Sub(quietSince) >= s.quietPeriod,
+		})
+	}
+	return statuses
+}
+
+func hasKey(keys []cookiesignature.Key, id string) bool {
+	for _, key := range keys {
+		if key.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func keyInfos(keys []cookiesignature.Key) []KeyInfo {
+	infos := make([]KeyInfo, len(keys))
+	for i, key := range keys {
+		infos[i] = KeyInfo{
+			ID:          key.ID,
+			State:       key.State,
+			Fingerprint: key.Fingerprint(),
+			CreatedAt:   key.CreatedAt,
+			PromotedAt:  optionalTime(key.PromotedAt),
+			DemotedAt:   optionalTime(key.DemotedAt),
+		}
+	}
+	return infos
+}
+
+// optionalTime returns nil for the zero time, so unset times are omitted from the JSON responses
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
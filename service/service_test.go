@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cookiesignature "github.com/hgiasac/go-cookie-signature"
+)
+
+type testKeyStore struct {
+	keys []cookiesignature.Key
+}
+
+func (s *testKeyStore) Load(context.Context) ([]cookiesignature.Key, error) {
+	return s.keys, nil
+}
+
+func (s *testKeyStore) Save(_ context.Context, keys []cookiesignature.Key) error {
+	s.keys = keys
+	return nil
+}
+
+func newTestService(t *testing.T) (*Service, *cookiesignature.Rotator) {
+	keyRing, err := cookiesignature.NewKeyRing(cookiesignature.Key{ID: "k0", Secret: []byte("tobiiscool"), State: cookiesignature.KeyActive})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	rotator := &cookiesignature.Rotator{KeyRing: keyRing, Store: &testKeyStore{}}
+	s, err := New(rotator, Config{Authorize: BearerToken("admin-token"), AuthorizeSigning: BearerToken("sign-token")})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	return s, rotator
+}
+
+func serve(s *Service, method string, path string, body string, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func TestSignEndpoints(t *testing.T) {
+	s, rotator := newTestService(t)
+
+	w := serve(s, http.MethodPost, "/sign", `{"value":"hello"}`, "sign-token")
+	if w.Code != http.StatusOK || w.Body.String() != `{"signed":"hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"}`+"\n" {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+	w = serve(s, http.MethodPost, "/unsign", `{"signed":"hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"}`, "sign-token")
+	if w.Code != http.StatusOK || w.Body.String() != `{"value":"hello"}`+"\n" {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+	if w = serve(s, http.MethodPost, "/unsign", `{"signed":"hello.invalid"}`, "sign-token"); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status: %d, got: %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	if w = serve(s, http.MethodGet, "/sign", "", "sign-token"); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status: %d, got: %d", http.StatusMethodNotAllowed, w.Code)
+	}
+	for _, token := range []string{"", "admin-token"} {
+		if w = serve(s, http.MethodPost, "/sign", `{"value":"hello"}`, token); w.Code != http.StatusUnauthorized {
+			t.Fatalf("expected status: %d, got: %d", http.StatusUnauthorized, w.Code)
+		}
+	}
+	if _, err := New(&cookiesignature.Rotator{KeyRing: rotator.KeyRing}, Config{}); err == nil {
+		t.Fatalf("expected an error without a signing authorizer")
+	}
+}
+
+func TestAdminEndpoints(t *testing.T) {
+	s, rotator := newTestService(t)
+
+	if w := serve(s, http.MethodGet, "/admin/keys", "", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status: %d, got: %d", http.StatusUnauthorized, w.Code)
+	}
+	if w := serve(s, http.MethodGet, "/admin/keys", "", "other"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status: %d, got: %d", http.StatusUnauthorized, w.Code)
+	}
+
+	w := serve(s, http.MethodGet, "/admin/keys", "", "admin-token")
+	var keys []KeyInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &keys); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(keys) != 1 || keys[0].ID != "k0" || keys[0].Fingerprint != cookiesignature.Fingerprint([]byte("tobiiscool")) {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Fatalf("expected the secrets not to be listed, got: %s", w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "promoted_at") || strings.Contains(w.Body.String(), "demoted_at") {
+		t.Fatalf("expected the unset times to be omitted, got: %s", w.Body.String())
+	}
+
+	if w = serve(s, http.MethodPost, "/admin/rotate", "", "admin-token"); w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+	if rotator.KeyRing.Active().ID == "k0" || len(rotator.Store.(*testKeyStore).keys) != 2 {
+		t.Fatalf("expected a new active key to be saved, got: %+v", rotator.KeyRing.Keys())
+	}
+
+	w = serve(s, http.MethodPost, "/admin/keys/"+rotator.KeyRing.Active().ID+"/verify-only", "", "admin-token")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status: %d, got: %d", http.StatusConflict, w.Code)
+	}
+	if w = serve(s, http.MethodPost, "/admin/keys/unknown/verify-only", "", "admin-token"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected status: %d, got: %d", http.StatusNotFound, w.Code)
+	}
+
+	if err := rotator.KeyRing.Add(cookiesignature.Key{ID: "k2", Secret: []byte("luna"), State: cookiesignature.KeyPending}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if w = serve(s, http.MethodPost, "/admin/keys/k2/verify-only", "", "admin-token"); w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+	for _, key := range rotator.KeyRing.Keys() {
+		if key.ID == "k2" && key.State != cookiesignature.KeyVerifyOnly {
+			t.Fatalf("expected the key to be verify-only, got: %s", key.State)
+		}
+	}
+}
+
+func TestAdminEndpointsDisabled(t *testing.T) {
+	keyRing, _ := cookiesignature.NewKeyRing(cookiesignature.Key{ID: "k0", Secret: []byte("tobiiscool"), State: cookiesignature.KeyActive})
+	s, err := New(&cookiesignature.Rotator{KeyRing: keyRing}, Config{AuthorizeSigning: BearerToken("sign-token")})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if w := serve(s, http.MethodGet, "/admin/keys", "", "admin-token"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected status: %d, got: %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestDrainEndpoints(t *testing.T) {
+	s, rotator := newTestService(t)
+	if w := serve(s, http.MethodPost, "/admin/rotate", "", "admin-token"); w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+	if err := rotator.KeyRing.Add(cookiesignature.Key{ID: "k2", Secret: []byte("luna"), State: cookiesignature.KeyPending}); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	w := serve(s, http.MethodPost, "/admin/keys/k2/drain", "", "admin-token")
+	var status DrainStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if w.Code != http.StatusOK || status.ID != "k2" || status.DrainingSince.IsZero() || status.Verifications != 0 || status.SafeToRetire {
+		t.Fatalf("unexpected drain status: %d %+v", w.Code, status)
+	}
+	if w = serve(s, http.MethodPost, "/admin/keys/k2/retire", "", "admin-token"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected status: %d, got: %d", http.StatusNotFound, w.Code)
+	}
+
+	for i := 0; i < 3; i++ {
+		serve(s, http.MethodPost, "/unsign", `{"signed":"hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"}`, "sign-token")
+	}
+	var statuses []DrainStatus
+	w = serve(s, http.MethodGet, "/admin/drain", "", "admin-token")
+	if err := json.Unmarshal(w.Body.Bytes(), &statuses); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(statuses) != 2 || statuses[0].ID != "k0" || statuses[0].Verifications != 3 || statuses[0].LastVerifiedAt == nil || statuses[0].SafeToRetire || statuses[1].LastVerifiedAt != nil {
+		t.Fatalf("unexpected drain statuses: %+v", statuses)
+	}
+
+	timeNow = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	defer func() { timeNow = time.Now }()
+	statuses = nil
+	w = serve(s, http.MethodGet, "/admin/drain", "", "admin-token")
+	if err := json.Unmarshal(w.Body.Bytes(), &statuses); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(statuses) != 2 || !statuses[0].SafeToRetire || !statuses[1].SafeToRetire {
+		t.Fatalf("expected the keys to be safe to retire, got: %+v", statuses)
+	}
+}
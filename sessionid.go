@@ -0,0 +1,127 @@
+package cookiesignature
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSessionIDLength = 24
+	sessionIDPrefix        = "s:"
+)
+
+var errInvalidSessionID = errors.New("session id must be prefixed with s:")
+
+// SessionIDGenerator generates cryptographically secure session IDs.
+// The zero value generates IDs compatible with express-session, which uses uid-safe with 24 random bytes
+type SessionIDGenerator struct {
+	// Length is the number of random bytes if Alphabet is empty, or the number of characters otherwise. Defaults to 24
+	Length int
+	// Alphabet restricts the characters of the ID. The ID is the url-safe base64 encoding of the random bytes if empty
+	Alphabet string
+	// TimestampPrefix prefixes the ID with the base36 encoded creation time in milliseconds and a dash,
+	// so IDs are roughly sortable by creation time
+	TimestampPrefix bool
+}
+
+// GenerateSessionID generates a session ID compatible with express-session
+func GenerateSessionID() (string, error) {
+	return SessionIDGenerator{}.Generate()
+}
+
+// Generate generates a new session ID
+func (g SessionIDGenerator) Generate() (string, error) {
+	length := g.Length
+	if length <= 0 {
+		length = defaultSessionIDLength
+	}
+
+	var id string
+	var err error
+	if g.Alphabet == "" {
+		id, err = randomBase64(length)
+	} else {
+		id, err = randomString(length, g.Alphabet)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if g.TimestampPrefix {
+		id = strconv.FormatInt(unixMilli(timeNow()), 36) + "-" + id
+	}
+	return id, nil
+}
+
+// SignSessionID signs the session ID into the "s:" prefixed format of express-session cookies
+func (cs CookieSignature) SignSessionID(sid string) (string, error) {
+	signed, err := cs.Sign(sid)
+	if err != nil {
+		return "", err
+	}
+	return cs.encodeOutput(sessionIDPrefix) + signed, nil
+}
+
+// UnsignSessionID verifies an express-session cookie value and returns the session ID.
+// Percent-encoded values, e.g. s%3A..., are decoded transparently
+func (cs CookieSignature) UnsignSessionID(input string) (string, error) {
+	ctx := context.Background()
+	signed, ok := cs.trimSessionIDPrefix(input)
+	if !ok {
+		cs.runHooks(ctx, OperationUnsign, errInvalidSessionID)
+		return "", errInvalidSessionID
+	}
+	return cs.UnsignContext(ctx, signed)
+}
+
+// trimSessionIDPrefix removes the "s:" prefix of the input, percent-encoded or not,
+// so the signed value is verified by Unsign with the same decoding rules
+func (cs CookieSignature) trimSessionIDPrefix(input string) (string, bool) {
+	if strings.HasPrefix(input, sessionIDPrefix) {
+		return input[len(sessionIDPrefix):], true
+	}
+	encodedPrefix := encodeURIComponent(sessionIDPrefix)
+	decodable := cs.opts.urlEncoding || cs.opts.parseMode == ParseLenient
+	if decodable && len(input) >= len(encodedPrefix) && strings.EqualFold(input[:len(encodedPrefix)], encodedPrefix) {
+		return input[len(encodedPrefix):], true
+	}
+	return "", false
+}
+
+func randomBase64(length int) (string, error) {
+	bs := make([]byte, length)
+	if _, err := rand.Read(bs); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bs), nil
+}
+
+// randomString picks characters of the alphabet uniformly by rejecting bytes that would bias the result
+func randomString(length int, alphabet string) (string, error) {
+	if len(alphabet) < 2 || len(alphabet) > 256 {
+		return "", errors.New("alphabet must have between 2 and 256 characters")
+	}
+
+	limit := 256 - 256%len(alphabet)
+	result := make([]byte, 0, length)
+	buf := make([]byte, length)
+	for len(result) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+			result = append(result, alphabet[int(b)%len(alphabet)])
+			if len(result) == length {
+				break
+			}
+		}
+	}
+	return string(result), nil
+}
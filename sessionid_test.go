@@ -0,0 +1,71 @@
+package cookiesignature
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSessionIDGenerator(t *testing.T) {
+	sid, err := GenerateSessionID()
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if !regexp.MustCompile(`^[A-Za-z0-9_-]{32}$`).MatchString(sid) {
+		t.Fatalf("expected an express-session compatible id, got: %s", sid)
+	}
+
+	sid2, err := GenerateSessionID()
+	assertNotEqual(t, sid, sid2, err)
+
+	sid, err = SessionIDGenerator{Length: 16, Alphabet: "0123456789abcdef", TimestampPrefix: true}.Generate()
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if !regexp.MustCompile(`^[0-9a-z]+-[0-9a-f]{16}$`).MatchString(sid) {
+		t.Fatalf("unexpected session id: %s", sid)
+	}
+
+	if _, err := (SessionIDGenerator{Alphabet: "a"}).Generate(); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestSignSessionID(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	signed, err := cs.SignSessionID("hello")
+	assertEqual(t, "s:hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", signed, err)
+
+	sid, err := cs.UnsignSessionID(signed)
+	assertEqual(t, "hello", sid, err)
+
+	if _, err := cs.UnsignSessionID(strings.TrimPrefix(signed, "s:")); err != errInvalidSessionID {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSessionID, err)
+	}
+}
+
+func TestSessionIDOptions(t *testing.T) {
+	var events []Event
+	cs, _ := NewCookieSignature([]string{"tobiiscool"}, WithSafeValues(), WithHook(func(_ context.Context, event Event) {
+		events = append(events, event)
+	}))
+
+	if _, err := cs.SignSessionID("hello\n"); err != ErrUnsafeValue {
+		t.Fatalf("expected error: %s, got: %v", ErrUnsafeValue, err)
+	}
+	signed, _ := cs.SignSessionID("hello")
+	if _, err := cs.UnsignSessionID(signed); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if _, err := cs.UnsignSessionID("hello"); err != errInvalidSessionID {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSessionID, err)
+	}
+	if len(events) != 4 || events[0].Err != ErrUnsafeValue || events[2].Operation != OperationUnsign || events[3].Err != errInvalidSessionID {
+		t.Fatalf("expected the session id operations to run the hooks, got: %+v", events)
+	}
+}
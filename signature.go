@@ -4,18 +4,25 @@
 package cookiesignature
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
+	"context"
+	"crypto"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var (
 	errEmptySignedValue   = errors.New("signed value must be provided")
 	errEmptyUnsignedValue = errors.New("unsigned value must be provided")
 	errInvalidSignature   = errors.New("invalid signature")
+
+	signatureDecoder = strings.NewReplacer("-", "+", "_", "/")
+
+	// timeNow is replaced in tests to control the clock
+	timeNow = time.Now
 )
 
 // CookieSignature allows interoperability with [node-cookie-signature] to sign and unsign cookies.
@@ -27,30 +34,138 @@ var (
 // [node-cookie-signature]: https://github.com/tj/node-cookie-signature/blob/master/index.js
 type CookieSignature struct {
 	secrets [][]byte
+	keyRing *KeyRing
+	// macProviders compute the MACs instead of the secrets, e.g. on an HSM
+	macProviders []MACProvider
+	usage        *keyUsage
+	warnings     []SecretWarning
+	opts         options
 }
 
 // NewCookieSignature creates a new CookieSignature instance
-func NewCookieSignature(secrets []string) (*CookieSignature, error) {
+func NewCookieSignature(secrets []string, opts ...Option) (*CookieSignature, error) {
 	if len(secrets) == 0 {
 		return nil, errors.New("secret key must be provided")
 	}
 
-	result := CookieSignature{}
+	result := CookieSignature{usage: &keyUsage{}}
+	for _, opt := range opts {
+		opt(&result.opts)
+	}
+	if result.opts.err != nil {
+		return nil, result.opts.err
+	}
+	if err := result.opts.validateHash(); err != nil {
+		return nil, err
+	}
+	secrets, result.warnings = normalizeSecrets(secrets, result.opts.trimSecrets)
 	for i, secret := range secrets {
 		if secret == "" {
 			return nil, fmt.Errorf("secret key at index %d must not be empty", i)
 		}
-		result.secrets = append(result.secrets, []byte(secret))
+		decoded, err := decodeSecret(secret, result.opts.secretEncoding)
+		if err != nil {
+			return nil, fmt.Errorf("secret key at index %d: %w", i, err)
+		}
+		if len(decoded) == 0 {
+			return nil, fmt.Errorf("secret key at index %d must not be empty", i)
+		}
+		result.secrets = append(result.secrets, result.opts.deriveSecret(decoded))
 	}
 	return &result, nil
 }
 
+// signingSecret returns the secret that signs outgoing values, nil if the MAC is computed by a MACProvider
+func (cs CookieSignature) signingSecret() []byte {
+	_, secret := cs.signingKey()
+	return secret
+}
+
+// signingKey returns the ID and the secret of the key that signs outgoing values,
+// with a nil secret if the MAC is computed by a MACProvider
+func (cs CookieSignature) signingKey() (string, []byte) {
+	if len(cs.macProviders) > 0 {
+		return "0", nil
+	}
+	if cs.keyRing != nil {
+		active := cs.keyRing.Active()
+		return active.ID, cs.opts.deriveSecret(active.Secret)
+	}
+	return "0", cs.secrets[0]
+}
+
+// signingMAC computes the MAC of the input with the key that signs outgoing values
+func (cs CookieSignature) signingMAC(input string) ([]byte, error) {
+	return cs.computeSigningMAC(input, cs.signingSecret())
+}
+
+func (cs CookieSignature) computeSigningMAC(input string, secret []byte) ([]byte, error) {
+	if len(cs.macProviders) > 0 {
+		return cs.macProviders[0].MAC([]byte(input))
+	}
+	return cs.opts.macAlgorithm().compute(input, secret)
+}
+
+// verificationKeys returns the keys that verify incoming values, the signing key first
+func (cs CookieSignature) verificationKeys() []verificationKey {
+	if len(cs.macProviders) > 0 {
+		keys := make([]verificationKey, len(cs.macProviders))
+		for i, provider := range cs.macProviders {
+			keys[i] = verificationKey{id: strconv.Itoa(i), mac: provider}
+		}
+		return keys
+	}
+	if cs.keyRing != nil {
+		keys := cs.keyRing.verificationKeys()
+		for i := range keys {
+			keys[i].secret = cs.opts.deriveSecret(keys[i].secret)
+			keys[i].algorithm = cs.opts.macAlgorithm()
+		}
+		return keys
+	}
+	keys := make([]verificationKey, len(cs.secrets))
+	for i, secret := range cs.secrets {
+		keys[i] = verificationKey{id: strconv.Itoa(i), secret: secret, algorithm: cs.opts.macAlgorithm()}
+	}
+	return keys
+}
+
 // Sign computes a signature from the input string and returns a joined string of the input and the signed value
 func (cs CookieSignature) Sign(input string) (string, error) {
+	return cs.SignContext(context.Background(), input)
+}
+
+// SignContext is like Sign, the context is passed to the hooks
+func (cs CookieSignature) SignContext(ctx context.Context, input string) (string, error) {
+	result, err := cs.sign(input)
+	cs.runHooks(ctx, OperationSign, err)
+	return result, err
+}
+
+func (cs CookieSignature) sign(input string) (string, error) {
 	if input == "" {
 		return "", errEmptyUnsignedValue
 	}
-	return Sign(input, cs.secrets[0])
+	if err := cs.opts.checkValue(input); err != nil {
+		return "", err
+	}
+	keyID, secret := cs.signingKey()
+	cache := cs.opts.signCache
+	if cache != nil {
+		if signed, ok := cache.get(keyID, secret, input); ok {
+			return signed, nil
+		}
+	}
+
+	hashBytes, err := cs.computeSigningMAC(cs.opts.macInput(input), secret)
+	if err != nil {
+		return "", err
+	}
+	signed := cs.encodeOutput(cs.joinSignature(input, cs.opts.signatureTag(), hashBytes))
+	if cache != nil {
+		cache.add(keyID, secret, input, signed)
+	}
+	return signed, nil
 }
 
 // SignBase64 computes a signature from the input string with base64 encoding
@@ -58,17 +173,98 @@ func (cs CookieSignature) SignBase64(input string) (string, error) {
 	if input == "" {
 		return "", errEmptyUnsignedValue
 	}
-	return Sign(base64.StdEncoding.EncodeToString([]byte(input)), cs.secrets[0])
+	return cs.Sign(cs.encodeValue(input))
 }
 
-// Unsign compares and extracts the value (the part of the string before the '.') from the input value
+// Unsign compares and extracts the value (the part of the string before the '.') from the input value.
+// Percent-encoded inputs, e.g. cookies written by express, are decoded transparently
 func (cs CookieSignature) Unsign(input string) (string, error) {
+	return cs.UnsignContext(context.Background(), input)
+}
+
+// UnsignContext is like Unsign, the context is passed to the hooks
+func (cs CookieSignature) UnsignContext(ctx context.Context, input string) (string, error) {
+	result, err := cs.unsignInput(ctx, input)
+	cs.runHooks(ctx, OperationUnsign, err)
+	return result, err
+}
+
+func (cs CookieSignature) unsignInput(ctx context.Context, input string) (string, error) {
 	if input == "" {
 		return "", errEmptySignedValue
 	}
+	if err := cs.opts.negativeCache.get(input); err != nil {
+		return "", err
+	}
+	result, err := cs.unsignDecoded(input)
+	if err != nil {
+		cs.opts.negativeCache.add(input, err)
+		return "", err
+	}
+	if err := cs.checkRevokedSignature(ctx, input); err != nil {
+		return "", err
+	}
+	if err := cs.opts.checkValue(result); err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+func (cs CookieSignature) unsignDecoded(input string) (string, error) {
+
+	decoded, ok := cs.decodeInput(input)
+	if cs.opts.urlEncoding {
+		if !ok {
+			return "", errInvalidSignature
+		}
+		return cs.unsign(decoded)
+	}
+
+	result, err := cs.unsign(input)
+	if err != nil && ok {
+		if decodedResult, decodedErr := cs.unsign(decoded); decodedErr == nil {
+			return decodedResult, nil
+		}
+	}
+	return result, err
+}
+
+func (cs CookieSignature) unsign(input string) (string, error) {
+	value, untagged, algorithm, tagged, err := cs.opts.parseAlgorithmTag(input)
+	var mac macAlgorithm
+	if err == nil {
+		mac, err = cs.opts.verificationMAC(algorithm)
+	}
+	if err != nil {
+		cs.computeRejectedMACs(input)
+		return "", err
+	}
+	if !tagged {
+		return cs.unsignWithKeys(input, mac)
+	}
+	if _, err := cs.unsignWithKeys(untagged, mac); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// computeRejectedMACs computes the MACs of the input with every key, as a failed verification does,
+// so an input rejected for its algorithm fails in the same time as an invalid signature
+func (cs CookieSignature) computeRejectedMACs(input string) {
+	if index := strings.LastIndex(input, "."); index >= 0 {
+		input = input[:index]
+	}
+	for _, key := range cs.verificationKeys() {
+		_, _ = key.computeMAC(input)
+	}
+}
+
+func (cs CookieSignature) unsignWithKeys(input string, mac macAlgorithm) (string, error) {
 	var firstError error
-	for _, secret := range cs.secrets {
-		if result, err := Unsign(input, secret); err == nil {
+	for _, key := range cs.usage.mostRecentlyUsedFirst(cs.verificationKeys()) {
+		key.algorithm = mac
+		if result, err := unsign(input, key.computeMAC, cs.opts.parseMode, cs.opts.codec()); err == nil {
+			cs.usage.record(key.id)
 			return result, nil
 		} else if firstError == nil {
 			firstError = err
@@ -77,14 +273,57 @@ func (cs CookieSignature) Unsign(input string) (string, error) {
 	return "", firstError
 }
 
+// Verify checks the integrity of the signed input with any secret without extracting the value
+func (cs CookieSignature) Verify(input string) error {
+	_, err := cs.Unsign(input)
+	return err
+}
+
+// Valid reports whether the signed input is valid with any secret
+func (cs CookieSignature) Valid(input string) bool {
+	return cs.Verify(input) == nil
+}
+
 // UnsignBase64 compares and extracts the base64 value (the part of the string before the '.') from the input value
 func (cs CookieSignature) UnsignBase64(input string) ([]byte, error) {
-	rawResult, err := cs.Unsign(input)
+	return cs.unsignBase64(context.Background(), input)
+}
+
+func (cs CookieSignature) unsignBase64(ctx context.Context, input string) ([]byte, error) {
+	rawResult, err := cs.UnsignContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
 
-	return base64.StdEncoding.WithPadding(base64.NoPadding).DecodeString(strings.TrimRight(rawResult, "="))
+	return decodeBase64(cs.opts.codec(), strings.TrimRight(rawResult, "="))
+}
+
+// SignDetached computes the signature of the input string without joining it to the input,
+// so the signature can be stored separately, e.g. in a keygrip-style "name.sig" cookie or in a header
+func (cs CookieSignature) SignDetached(input string) (string, error) {
+	if input == "" {
+		return "", errEmptyUnsignedValue
+	}
+	if err := cs.opts.checkValue(input); err != nil {
+		return "", err
+	}
+	hashBytes, err := cs.signingMAC(cs.opts.macInput(input))
+	if err != nil {
+		return "", err
+	}
+	return cs.encodeOutput(cs.opts.signatureTag() + encodeBase64(cs.opts.codec(), hashBytes)), nil
+}
+
+// VerifyDetached checks that the signature created by SignDetached matches the input string with any secret
+func (cs CookieSignature) VerifyDetached(input string, signature string) error {
+	if input == "" {
+		return errEmptyUnsignedValue
+	}
+	if signature == "" {
+		return errInvalidSignature
+	}
+	_, err := cs.Unsign(fmt.Sprintf("%s.%s", input, signature))
+	return err
 }
 
 // Sign computes a signature from the input string and returns a joined string of the input and the signed value
@@ -100,24 +339,41 @@ func Sign(input string, secret []byte) (string, error) {
 
 // Unsign compares and extracts the value (the part of the string before the '.') from the input value
 func Unsign(input string, secret []byte) (string, error) {
-	parts := strings.Split(input, ".")
-	length := len(parts)
-	if length < 2 {
-		return "", errInvalidSignature
+	return unsign(input, func(value string) ([]byte, error) {
+		return computeHMAC256(value, secret)
+	}, ParseLenient, base64.RawStdEncoding)
+}
+
+func unsign(input string, computeMAC func(value string) ([]byte, error), mode ParseMode, codec Base64Codec) (string, error) {
+	rawResult, signature := input, ""
+	index := strings.LastIndex(input, ".")
+	if index >= 0 {
+		rawResult, signature = input[:index], input[index+1:]
 	}
 
-	rawResult := strings.Join(parts[:length-1], ".")
-	inputHash, err := base64.StdEncoding.WithPadding(base64.NoPadding).DecodeString(parts[length-1])
+	// the HMAC is computed before the input is validated,
+	// so the failure timing doesn't depend on where the input is malformed
+	expectedHash, err := computeMAC(rawResult)
 	if err != nil {
 		return "", err
 	}
 
-	expectedHash, err := computeHMAC256(rawResult, secret)
+	if index < 0 {
+		return "", errInvalidSignature
+	}
+	if mode == ParseStrict {
+		if !Equal(stringToBytes(signature), stringToBytes(encodeBase64(codec, expectedHash))) {
+			return "", errInvalidSignature
+		}
+		return rawResult, nil
+	}
+
+	inputHash, err := decodeSignature(codec, signature)
 	if err != nil {
 		return "", err
 	}
 
-	if len(inputHash) != len(expectedHash) || !hmac.Equal([]byte(inputHash), []byte(expectedHash)) {
+	if !Equal(inputHash, expectedHash) {
 		return "", errInvalidSignature
 	}
 	return rawResult, nil
@@ -125,14 +381,15 @@ func Unsign(input string, secret []byte) (string, error) {
 
 // Create an HMAC signature that is identical to one produced by node-cookie-signature
 func computeHMAC256(input string, secret []byte) ([]byte, error) {
-	mac := hmac.New(sha256.New, secret)
-	_, err := mac.Write([]byte(input))
-	if err != nil {
-		return nil, err
-	}
-	return mac.Sum(nil), nil
+	return computeHMAC(crypto.SHA256, input, secret)
+}
+
+// decodeSignature decodes signatures with or without padding, in either the standard or the url-safe base64 alphabet
+// and surrounded by whitespace, because some proxies and client libraries re-pad or re-encode cookies
+func decodeSignature(codec Base64Codec, signature string) ([]byte, error) {
+	return decodeBase64(codec, signatureDecoder.Replace(strings.TrimRight(strings.TrimSpace(signature), "=")))
 }
 
 func hashBase64(hashBytes []byte) string {
-	return strings.TrimRight(string(base64.StdEncoding.EncodeToString(hashBytes)), "=")
+	return encodeBase64(base64.RawStdEncoding, hashBytes)
 }
@@ -1,6 +1,8 @@
 package cookiesignature
 
 import (
+	"crypto"
+	"encoding/base64"
 	"testing"
 )
 
@@ -67,15 +69,127 @@ func TestUnsign(t *testing.T) {
 	if _, err = cs.Unsign("foo"); err == nil || err != errInvalidSignature {
 		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
 	}
-	if _, err = cs.Unsign("foo.bar=="); err == nil || err.Error() != "illegal base64 data at input byte 3" {
+	if _, err = cs.Unsign("foo.bar=="); err == nil || err != errInvalidSignature {
 		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
 	}
+	if _, err = cs.Unsign("foo.b@r"); err == nil || err.Error() != "illegal base64 data at input byte 1" {
+		t.Fatalf("expected error: illegal base64 data at input byte 1, got: %s", err)
+	}
 
 	if _, err = cs.UnsignBase64(val2); err == nil || err != errInvalidSignature {
 		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
 	}
 }
 
+func TestUnsignLenientBase64(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	for _, signed := range []string{
+		"hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI",
+		"hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI=",
+		"hello.DGDUkGlIkCzPz-C0B064FNgHdEjox7ch8tOBGslZ5QI",
+		"hello.DGDUkGlIkCzPz-C0B064FNgHdEjox7ch8tOBGslZ5QI=",
+		"hello. DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI\n",
+	} {
+		val, err := cs.Unsign(signed)
+		assertEqual(t, "hello", val, err)
+	}
+}
+
+func TestUnsignStrict(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"tobiiscool"}, WithParseMode(ParseStrict))
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	val, err := cs.Unsign("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI")
+	assertEqual(t, "hello", val, err)
+
+	for _, signed := range []string{
+		"hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI=",
+		"hello.DGDUkGlIkCzPz-C0B064FNgHdEjox7ch8tOBGslZ5QI",
+		"hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI\n",
+		"hello",
+	} {
+		if _, err := cs.Unsign(signed); err != errInvalidSignature {
+			t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	if err := cs.Verify("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if !cs.Valid("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI") {
+		t.Fatal("expected valid signature")
+	}
+	if err := cs.Verify("hello2.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+	if cs.Valid("") || cs.Valid("hello") {
+		t.Fatal("expected invalid signature")
+	}
+}
+
+func TestSignDetached(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"n3wsecr3t", "tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	if _, err := cs.SignDetached(""); err != errEmptyUnsignedValue {
+		t.Fatalf("expected error: %s, got: %s", errEmptyUnsignedValue, err)
+	}
+
+	sig, err := cs.SignDetached("hello")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	signed, err := cs.Sign("hello")
+	assertEqual(t, signed, "hello."+sig, err)
+
+	if err := cs.VerifyDetached("hello", sig); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	// signed with the old secret
+	if err := cs.VerifyDetached("hello", "DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if err := cs.VerifyDetached("hello2", sig); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+	if err := cs.VerifyDetached("hello", ""); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+
+	// detached signatures match the signatures of Sign under the signer options
+	codec := &countingCodec{Encoding: base64.RawStdEncoding}
+	for _, opts := range [][]Option{{WithAlgorithmTag()}, {WithBase64Codec(codec)}, {WithAlgorithmTag(), WithHash(crypto.SHA512)}} {
+		cs, _ := NewCookieSignature([]string{"tobiiscool"}, opts...)
+		sig, err := cs.SignDetached("hello")
+		if err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		signed, err := cs.Sign("hello")
+		assertEqual(t, signed, "hello."+sig, err)
+		if err := cs.VerifyDetached("hello", sig); err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+	}
+	if codec.encoded != 2 {
+		t.Fatalf("expected the codec to encode 2 times, got: %d", codec.encoded)
+	}
+}
+
 func assertEqual(t *testing.T, expected string, got string, err error) {
 	if err != nil {
 		t.Fatalf("expected no error, got: %s", err)
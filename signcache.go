@@ -0,0 +1,80 @@
+package cookiesignature
+
+import (
+	"container/list"
+	"crypto/subtle"
+	"sync"
+)
+
+// WithSignCache caches the results of Sign for up to size values, for workloads that sign the same few values
+// over and over, e.g. feature-flag cookies. Entries are keyed by the signing key and the value,
+// so they're invalidated automatically when the key rotates. Only enable it for small values,
+// the cache holds every value and its signed result
+func WithSignCache(size int) Option {
+	return func(o *options) {
+		if size <= 0 {
+			o.signCache = nil
+			return
+		}
+		o.signCache = &signCache{
+			size:    size,
+			entries: make(map[signCacheKey]*list.Element, size),
+			order:   list.New(),
+		}
+	}
+}
+
+// signCache is a bounded cache of signed values, evicting the oldest entry when full
+type signCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[signCacheKey]*list.Element
+	order   *list.List
+}
+
+type signCacheKey struct {
+	keyID string
+	input string
+}
+
+type signCacheEntry struct {
+	key signCacheKey
+	// secret detects a key replaced under the same ID, e.g. a secret file updated in place
+	secret []byte
+	signed string
+}
+
+func (c *signCache) get(keyID string, secret []byte, input string) (string, bool) {
+	key := signCacheKey{keyID: keyID, input: input}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	entry := element.Value.(*signCacheEntry)
+	if subtle.ConstantTimeCompare(entry.secret, secret) != 1 {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return "", false
+	}
+	return entry.signed, true
+}
+
+func (c *signCache) add(keyID string, secret []byte, input string, signed string) {
+	key := signCacheKey{keyID: keyID, input: input}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+	for c.order.Len() >= c.size {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*signCacheEntry).key)
+	}
+	c.entries[key] = c.order.PushBack(&signCacheEntry{key: key, secret: secret, signed: signed})
+}
@@ -0,0 +1,33 @@
+package cookiesignature
+
+import (
+	"testing"
+)
+
+func TestWithSignCache(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"}, WithSignCache(2))
+	for i := 0; i < 2; i++ {
+		signed, err := cs.Sign("hello")
+		assertEqual(t, "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", signed, err)
+	}
+	_, _ = cs.Sign("a")
+	_, _ = cs.Sign("b")
+	if len(cs.opts.signCache.entries) != 2 {
+		t.Fatalf("expected the cache to be bounded, got %d entries", len(cs.opts.signCache.entries))
+	}
+
+	kr, _ := NewKeyRing(Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive})
+	cs, _ = NewCookieSignatureFromKeyRing(kr, WithSignCache(10))
+	signed, err := cs.Sign("hello")
+	assertEqual(t, "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", signed, err)
+
+	// rotated keys and keys replaced under the same ID both invalidate the cached results
+	_ = kr.Add(Key{ID: "k1", Secret: []byte("n3wsecr3t"), State: KeyPending})
+	_ = kr.Promote("k1")
+	signed, err = cs.Sign("hello")
+	assertEqual(t, "hello.fJDsH8b7iNvcQdwtuhE29LZUFMorBk6MOzotVfMoiOc", signed, err)
+
+	_ = kr.Replace([]Key{{ID: "k1", Secret: []byte("tobiiscool"), State: KeyActive}})
+	signed, err = cs.Sign("hello")
+	assertEqual(t, "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", signed, err)
+}
@@ -0,0 +1,137 @@
+package cookiesignature
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// signedValueHashLength is the number of hex characters of the hash shown in logs
+const signedValueHashLength = 12
+
+// SignedValue wraps a signed value, e.g. a session cookie, so it can't leak into logs.
+// It formats, logs and serializes to JSON as a truncated hash of the value, which still correlates log lines.
+// Values parsed by ParseSignedValue also expose their parts for debugging and tooling
+type SignedValue struct {
+	raw string
+	// the percent-decoded value and its parts, set by ParseSignedValue
+	decoded   string
+	value     string
+	signature string
+	algorithm Algorithm
+	claims    *Claims
+}
+
+// ParseSignedValue splits the signed value into its parts without verifying it.
+// Percent-encoded values are decoded. Signed values don't carry the ID of their key, Verify returns it
+func ParseSignedValue(raw string) (SignedValue, error) {
+	decoded := raw
+	if strings.Contains(raw, "%") {
+		if unescaped, err := url.PathUnescape(raw); err == nil {
+			decoded = unescaped
+		}
+	}
+	index := strings.LastIndex(decoded, ".")
+	if index <= 0 || index == len(decoded)-1 {
+		return SignedValue{}, errInvalidSignature
+	}
+
+	v := SignedValue{raw: raw, decoded: decoded, value: decoded[:index], signature: decoded[index+1:]}
+	if end := strings.IndexByte(v.signature, algorithmTagEnd); end >= 0 {
+		v.algorithm, v.signature = Algorithm(v.signature[:end]), v.signature[end+1:]
+	} else {
+		v.algorithm = options{}.untaggedAlgorithm(v.signature)
+	}
+	if payload, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(v.value, "=")); err == nil {
+		var claims Claims
+		if json.Unmarshal(payload, &claims) == nil && (claims.IssuedAt != 0 || claims.ExpiresAt != 0) {
+			v.claims = &claims
+		}
+	}
+	return v, nil
+}
+
+// NewSignedValue wraps the signed value
+func NewSignedValue(raw string) SignedValue {
+	return SignedValue{raw: raw}
+}
+
+// Raw returns the signed value
+func (v SignedValue) Raw() string {
+	return v.raw
+}
+
+// Payload returns the value before the signature, as signed
+func (v SignedValue) Payload() string {
+	return v.value
+}
+
+// Signature returns the signature, without its algorithm tag
+func (v SignedValue) Signature() string {
+	return v.signature
+}
+
+// Algorithm returns the algorithm named by the tag of the signature, detected from the length of untagged signatures
+func (v SignedValue) Algorithm() Algorithm {
+	return v.algorithm
+}
+
+// Claims returns the unverified claims of a timed token, false if the value isn't one
+func (v SignedValue) Claims() (Claims, bool) {
+	if v.claims == nil {
+		return Claims{}, false
+	}
+	return *v.claims, true
+}
+
+// IssuedAt returns when the timed token was issued, the zero time if the value isn't one or has no issue time
+func (v SignedValue) IssuedAt() time.Time {
+	if v.claims == nil || v.claims.IssuedAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(v.claims.IssuedAt, 0)
+}
+
+// Verify checks the signature of a value parsed by ParseSignedValue with the keys and the options of the signer,
+// and returns the ID of the key that signed it: the key ID of a key ring, or the index of the secret or the MAC provider.
+// The time claims of timed tokens aren't validated, use UnsignClaims for that
+func (v SignedValue) Verify(cs *CookieSignature) (string, error) {
+	if cs == nil || v.decoded == "" {
+		return "", errInvalidSignature
+	}
+	for _, key := range cs.verificationKeys() {
+		single := CookieSignature{opts: cs.opts}
+		if key.mac != nil {
+			single.macProviders = []MACProvider{key.mac}
+		} else {
+			single.secrets = [][]byte{key.secret}
+		}
+		if _, err := single.unsign(v.decoded); err == nil {
+			return key.id, nil
+		}
+	}
+	return "", errInvalidSignature
+}
+
+// String returns the truncated hash of the value
+func (v SignedValue) String() string {
+	if v.raw == "" {
+		return "signed:empty"
+	}
+	hash := sha256.Sum256([]byte(v.raw))
+	return "signed:" + hex.EncodeToString(hash[:])[:signedValueHashLength]
+}
+
+// GoString returns the truncated hash of the value, so %#v doesn't print it either
+func (v SignedValue) GoString() string {
+	return v.String()
+}
+
+// MarshalJSON encodes the truncated hash of the value
+func (v SignedValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
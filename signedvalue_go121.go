@@ -0,0 +1,11 @@
+//go:build go1.21
+// +build go1.21
+
+package cookiesignature
+
+import "log/slog"
+
+// LogValue logs the truncated hash of the value
+func (v SignedValue) LogValue() slog.Value {
+	return slog.StringValue(v.String())
+}
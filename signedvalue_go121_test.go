@@ -0,0 +1,21 @@
+//go:build go1.21
+// +build go1.21
+
+package cookiesignature
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSignedValueLogValue(t *testing.T) {
+	value := NewSignedValue("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI")
+
+	var output bytes.Buffer
+	slog.New(slog.NewTextHandler(&output, nil)).Info("request", "session", value)
+	if strings.Contains(output.String(), "hello") || !strings.Contains(output.String(), "session="+value.String()) {
+		t.Fatalf("expected the value to be redacted, got: %s", output.String())
+	}
+}
@@ -0,0 +1,87 @@
+package cookiesignature
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignedValue(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	signed, _ := cs.Sign("hello")
+	value := NewSignedValue(signed)
+
+	assertEqual(t, signed, value.Raw(), nil)
+	if len(value.String()) != len("signed:")+signedValueHashLength {
+		t.Fatalf("unexpected string: %s", value)
+	}
+
+	state := struct {
+		Path    string
+		Session SignedValue
+	}{"/", value}
+	encoded, _ := json.Marshal(state)
+	for _, output := range []string{fmt.Sprint(value), fmt.Sprintf("%+v", state), fmt.Sprintf("%#v", state), string(encoded)} {
+		if strings.Contains(output, "hello") || !strings.Contains(output, value.String()) {
+			t.Fatalf("expected the value to be redacted, got: %s", output)
+		}
+	}
+
+	assertEqual(t, "signed:empty", SignedValue{}.String(), nil)
+}
+
+func TestParseSignedValue(t *testing.T) {
+	for _, raw := range []string{"", "hello", ".DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI", "hello."} {
+		if _, err := ParseSignedValue(raw); err != errInvalidSignature {
+			t.Fatalf("expected error: %s for %q, got: %v", errInvalidSignature, raw, err)
+		}
+	}
+
+	value, err := ParseSignedValue("hello.hs256%3AuZV1qDYJ1h8SMjxE%2BHtqXUSkGpnAqXxZwyPmmwohytU")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if value.Payload() != "hello" || value.Signature() != "uZV1qDYJ1h8SMjxE+HtqXUSkGpnAqXxZwyPmmwohytU" || value.Algorithm() != AlgorithmHS256 {
+		t.Fatalf("unexpected parts: %s %s %s", value.Payload(), value.Signature(), value.Algorithm())
+	}
+	if _, ok := value.Claims(); ok || !value.IssuedAt().IsZero() {
+		t.Fatalf("expected no claims")
+	}
+
+	kr, _ := NewKeyRing(
+		Key{ID: "k1", Secret: []byte("n3wsecr3t"), State: KeyActive},
+		Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyVerifyOnly},
+	)
+	cs, _ := NewCookieSignatureFromKeyRing(kr)
+	keyID, err := value.Verify(cs)
+	assertEqual(t, "k0", keyID, err)
+	token, _ := cs.SignTimed("hello", time.Hour)
+	value, _ = ParseSignedValue(token)
+	claims, ok := value.Claims()
+	if !ok || claims.Value != "hello" || value.IssuedAt().IsZero() || value.Algorithm() != AlgorithmHS256 {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+	keyID, err = value.Verify(cs)
+	assertEqual(t, "k1", keyID, err)
+
+	_ = kr.Retire("k0")
+	value, _ = ParseSignedValue("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI")
+	if _, err := value.Verify(cs); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	if _, err := NewSignedValue("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI").Verify(cs); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+
+	// the options of the signer apply
+	keyed, _ := NewCookieSignature([]string{"tobiiscool", "n3wsecr3t"}, WithKeyedBLAKE2b(), WithSubkey("cookies"))
+	signed, _ := keyed.Sign("hello")
+	value, _ = ParseSignedValue(signed)
+	keyID, err = value.Verify(keyed)
+	assertEqual(t, "0", keyID, err)
+	if _, err := value.Verify(cs); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+}
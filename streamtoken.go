@@ -0,0 +1,101 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Purposes of the path tokens, so stream and download tokens can't be used for one another
+const (
+	streamTokenPurpose   = "stream-token"
+	downloadTokenPurpose = "download-token"
+)
+
+// StreamTokenParam is the query parameter of the Server-Sent Events URL that carries the stream token
+const StreamTokenParam = "token"
+
+// ErrPathMismatch is returned when a stream token is used on another path than the one it was issued for
+var ErrPathMismatch = errors.New("token was issued for another path")
+
+type streamTokenKey struct{}
+
+type streamTokenClaims struct {
+	Value     string `json:"val"`
+	Path      string `json:"pth"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// SignStreamToken signs a short-lived token of the value for the Server-Sent Events endpoint at path, e.g. /events.
+// EventSource can't set headers, and SameSite rules and proxies often strip the session cookie of its requests,
+// so the page passes the token in the "token" query parameter instead. Query strings end up in access logs,
+// keep the ttl short: EventSource reconnections after it are rejected and the page must fetch a new token
+func (cs CookieSignature) SignStreamToken(value string, path string, ttl time.Duration) (string, error) {
+	return cs.signPathToken(streamTokenPurpose, value, path, ttl)
+}
+
+// signPathToken signs a short-lived token of the value for the path, with the purpose in the MAC
+func (cs CookieSignature) signPathToken(purpose string, value string, path string, ttl time.Duration) (string, error) {
+	if path == "" {
+		return "", errors.New("token path must not be empty")
+	}
+	if ttl <= 0 {
+		return "", errors.New("token ttl must be positive")
+	}
+	now := timeNow()
+	return cs.signPurposeJSON(purpose, streamTokenClaims{
+		Value:     value,
+		Path:      path,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+}
+
+// UnsignStreamToken verifies the token, checks that it didn't expire and that it was issued for the path, and returns its value
+func (cs CookieSignature) UnsignStreamToken(token string, path string) (string, error) {
+	return cs.unsignPathToken(streamTokenPurpose, token, path)
+}
+
+func (cs CookieSignature) unsignPathToken(purpose string, token string, path string) (string, error) {
+	var claims streamTokenClaims
+	if err := cs.unsignPurposeJSON(purpose, token, &claims); err != nil {
+		return "", err
+	}
+	if err := cs.validateClaims(Claims{IssuedAt: claims.IssuedAt, ExpiresAt: claims.ExpiresAt}, verifyOptions{}); err != nil {
+		return "", err
+	}
+	if claims.Path != path {
+		return "", ErrPathMismatch
+	}
+	return claims.Value, nil
+}
+
+// StreamTokenMiddleware rejects requests without a valid token for their path with 401,
+// and attaches the value of the token to the request context, read with StreamTokenFromContext
+func (cs CookieSignature) StreamTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value, err := cs.verifyPathToken(r, streamTokenPurpose)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), streamTokenKey{}, value)))
+	})
+}
+
+// verifyPathToken verifies the token of the purpose in the query parameter against the request path
+func (cs CookieSignature) verifyPathToken(r *http.Request, purpose string) (string, error) {
+	token := r.URL.Query().Get(StreamTokenParam)
+	if token == "" {
+		return "", errEmptySignedValue
+	}
+	return cs.unsignPathToken(purpose, token, r.URL.Path)
+}
+
+// StreamTokenFromContext returns the value of the token verified by StreamTokenMiddleware
+func StreamTokenFromContext(ctx context.Context) (string, bool) {
+	value, ok := ctx.Value(streamTokenKey{}).(string)
+	return value, ok
+}
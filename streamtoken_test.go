@@ -0,0 +1,68 @@
+package cookiesignature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestStreamToken(t *testing.T) {
+	timeNow = func() time.Time { return time.Unix(1600000000, 0) }
+	defer func() { timeNow = time.Now }()
+
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	if _, err := cs.SignStreamToken("user-42", "/events", 0); err == nil {
+		t.Fatalf("expected a ttl error")
+	}
+	token, err := cs.SignStreamToken("user-42", "/events", time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	value, err := cs.UnsignStreamToken(token, "/events")
+	assertEqual(t, "user-42", value, err)
+	if _, err := cs.UnsignStreamToken(token, "/admin/events"); err != ErrPathMismatch {
+		t.Fatalf("expected error: %s, got: %v", ErrPathMismatch, err)
+	}
+
+	var ctxValue string
+	handler := cs.StreamTokenMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxValue, _ = StreamTokenFromContext(r.Context())
+	}))
+	for path, status := range map[string]int{
+		"/events?" + StreamTokenParam + "=" + url.QueryEscape(token):       http.StatusOK,
+		"/admin/events?" + StreamTokenParam + "=" + url.QueryEscape(token): http.StatusUnauthorized,
+		"/events": http.StatusUnauthorized,
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != status {
+			t.Fatalf("%s: expected status: %d, got: %d", path, status, w.Code)
+		}
+	}
+	if ctxValue != "user-42" {
+		t.Fatalf("expected: user-42, got: %s", ctxValue)
+	}
+
+	// stream, download and ticket tokens can't be used for one another
+	download, _ := cs.SignDownloadURL("/events", time.Minute)
+	download, _ = url.QueryUnescape(download[len("/events?"+StreamTokenParam+"="):])
+	ticket, _ := cs.SignTicket("user-42", "https://app.example.com", time.Minute)
+	signedJSON, _ := cs.SignJSON(map[string]interface{}{"val": "user-42", "pth": "/events"})
+	for _, forged := range []string{download, ticket, signedJSON} {
+		if _, err := cs.UnsignStreamToken(forged, "/events"); err != errInvalidSignature {
+			t.Fatalf("expected error: %s for %s, got: %v", errInvalidSignature, forged, err)
+		}
+	}
+	w := httptest.NewRecorder()
+	cs.RequireSignedURL(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events?"+StreamTokenParam+"="+url.QueryEscape(token), nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status: %d, got: %d", http.StatusForbidden, w.Code)
+	}
+
+	timeNow = func() time.Time { return time.Unix(1600000060, 0) }
+	if _, err := cs.UnsignStreamToken(token, "/events"); err != ErrTokenExpired {
+		t.Fatalf("expected error: %s, got: %v", ErrTokenExpired, err)
+	}
+}
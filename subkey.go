@@ -0,0 +1,39 @@
+package cookiesignature
+
+import (
+	"crypto/sha256"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	subkeyInfo   = "cookiesignature subkey:"
+	subkeyLength = 32
+)
+
+// DeriveSubkey deterministically derives a subkey of the master key from the labels with HKDF-SHA256,
+// e.g. DeriveSubkey(master, "billing", "session"). Different labels give independent subkeys
+func DeriveSubkey(master []byte, labels ...string) []byte {
+	key := make([]byte, subkeyLength)
+	// the labels are length-prefixed, so ("ab", "c") and ("a", "bc") derive different subkeys.
+	// reading less than 255 hashes from HKDF never fails
+	_, _ = io.ReadFull(hkdf.New(sha256.New, master, nil, []byte(subkeyInfo+encodeValues(labels))), key)
+	return key
+}
+
+// WithSubkey treats the secrets as master keys and signs with their subkeys of the labels, e.g. the service and the cookie name.
+// Dozens of services can then rotate in lockstep by rotating a single root secret, without sharing a signing key
+func WithSubkey(labels ...string) Option {
+	return func(o *options) {
+		o.subkeyLabels = append([]string(nil), labels...)
+	}
+}
+
+// deriveSecret derives the key used to sign and verify from a configured secret
+func (o options) deriveSecret(secret []byte) []byte {
+	if len(o.subkeyLabels) > 0 {
+		secret = DeriveSubkey(secret, o.subkeyLabels...)
+	}
+	return environmentSecret(secret, o.environment)
+}
@@ -0,0 +1,28 @@
+package cookiesignature
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestSubkey(t *testing.T) {
+	master := []byte("tobiiscool")
+	assertNotEqual(t, hex.EncodeToString(DeriveSubkey(master, "ab", "c")), hex.EncodeToString(DeriveSubkey(master, "a", "bc")), nil)
+	assertEqual(t, hex.EncodeToString(DeriveSubkey(master, "billing")), hex.EncodeToString(DeriveSubkey(master, "billing")), nil)
+
+	billing, _ := NewCookieSignature([]string{"tobiiscool"}, WithSubkey("billing", "session"))
+	search, _ := NewCookieSignature([]string{"tobiiscool"}, WithSubkey("search", "session"))
+
+	signed, err := billing.Sign("hello")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if _, err := search.Unsign(signed); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+
+	// a service that only knows its subkey verifies the values
+	subkey, _ := NewCookieSignature([]string{hex.EncodeToString(DeriveSubkey(master, "billing", "session"))}, WithSecretEncoding(SecretEncodingHex))
+	result, err := subkey.Unsign(signed)
+	assertEqual(t, "hello", result, err)
+}
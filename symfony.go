@@ -0,0 +1,128 @@
+package cookiesignature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const symfonyCookieDelimiter = ":"
+
+var (
+	errSymfonyInvalidCookie = errors.New("invalid remember-me cookie")
+	errSymfonyExpired       = errors.New("remember-me cookie expired")
+
+	symfonyIdentifierDecoder = strings.NewReplacer("-", "+", "_", "/", "~", "=")
+)
+
+// SymfonyRememberMeDetails holds the content of a Symfony remember-me cookie
+type SymfonyRememberMeDetails struct {
+	// UserClass is the fully qualified class name of the user, e.g. App\Entity\User
+	UserClass      string
+	UserIdentifier string
+	Expires        time.Time
+	Hash           string
+}
+
+// SymfonyRememberMe creates and verifies remember-me cookies of the Symfony 5.3+ signature remember-me handler,
+// so persistent logins of a Symfony application can be honored during a migration
+type SymfonyRememberMe struct {
+	secret []byte
+}
+
+// NewSymfonyRememberMe creates a new SymfonyRememberMe instance from the kernel.secret of the Symfony application
+func NewSymfonyRememberMe(secret string) (*SymfonyRememberMe, error) {
+	if secret == "" {
+		return nil, errors.New("secret key must be provided")
+	}
+	return &SymfonyRememberMe{secret: []byte(secret)}, nil
+}
+
+// ComputeHash computes the signature hash of the user like SignatureHasher::computeSignatureHash,
+// over the base64 user identifier, the expiration and the base64 signature properties.
+// The signature properties are the values of the user properties configured in signature_properties, in the same order
+func (sr SymfonyRememberMe) ComputeHash(userIdentifier string, expires time.Time, signatureProperties ...string) string {
+	fields := []string{base64.StdEncoding.EncodeToString([]byte(userIdentifier)), strconv.FormatInt(expires.Unix(), 10)}
+	for _, property := range signatureProperties {
+		fields = append(fields, base64.StdEncoding.EncodeToString([]byte(property)))
+	}
+
+	mac := hmac.New(sha256.New, sr.secret)
+	_, _ = mac.Write([]byte(strings.Join(fields, symfonyCookieDelimiter)))
+	return base64.StdEncoding.EncodeToString([]byte(hex.EncodeToString(mac.Sum(nil))))
+}
+
+// Create returns the remember-me cookie value of the user
+func (sr SymfonyRememberMe) Create(userClass string, userIdentifier string, expires time.Time, signatureProperties ...string) string {
+	details := SymfonyRememberMeDetails{
+		UserClass:      userClass,
+		UserIdentifier: userIdentifier,
+		Expires:        expires,
+		Hash:           sr.ComputeHash(userIdentifier, expires, signatureProperties...),
+	}
+	return details.String()
+}
+
+// Verify checks that the cookie hash matches the current signature properties of the user and that the cookie is not expired
+func (sr SymfonyRememberMe) Verify(details SymfonyRememberMeDetails, signatureProperties ...string) error {
+	expected := sr.ComputeHash(details.UserIdentifier, details.Expires, signatureProperties...)
+	if !EqualString(expected, details.Hash) {
+		return errInvalidSignature
+	}
+	if details.Expires.Before(timeNow()) {
+		return errSymfonyExpired
+	}
+	return nil
+}
+
+// String encodes the details to a remember-me cookie value
+func (details SymfonyRememberMeDetails) String() string {
+	raw := strings.Join([]string{
+		strings.ReplaceAll(details.UserClass, `\`, "."),
+		base64.StdEncoding.EncodeToString([]byte(details.UserIdentifier)),
+		strconv.FormatInt(details.Expires.Unix(), 10),
+		details.Hash,
+	}, symfonyCookieDelimiter)
+	return base64.StdEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseSymfonyRememberMeCookie decodes a remember-me cookie value without verifying it.
+// Both the base64 wrapped format of Symfony 5 and the raw format of Symfony 6 are accepted
+func ParseSymfonyRememberMeCookie(value string) (SymfonyRememberMeDetails, error) {
+	if unescaped, err := url.PathUnescape(value); err == nil {
+		value = unescaped
+	}
+	if !strings.Contains(value, symfonyCookieDelimiter) {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return SymfonyRememberMeDetails{}, errSymfonyInvalidCookie
+		}
+		value = string(decoded)
+	}
+
+	parts := strings.SplitN(value, symfonyCookieDelimiter, 4)
+	if len(parts) != 4 {
+		return SymfonyRememberMeDetails{}, errSymfonyInvalidCookie
+	}
+	identifier, err := base64.StdEncoding.DecodeString(symfonyIdentifierDecoder.Replace(parts[1]))
+	if err != nil {
+		return SymfonyRememberMeDetails{}, errSymfonyInvalidCookie
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return SymfonyRememberMeDetails{}, errSymfonyInvalidCookie
+	}
+
+	return SymfonyRememberMeDetails{
+		UserClass:      strings.ReplaceAll(parts[0], ".", `\`),
+		UserIdentifier: string(identifier),
+		Expires:        time.Unix(expires, 0),
+		Hash:           parts[3],
+	}, nil
+}
@@ -0,0 +1,54 @@
+package cookiesignature
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSymfonyRememberMe(t *testing.T) {
+	if _, err := NewSymfonyRememberMe(""); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	sr, err := NewSymfonyRememberMe("ThisTokenIsNotSoSecretChangeIt")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	// base64_encode(hash_hmac('sha256', implode(':', [base64_encode('tobi@example.com'), 1893456000, base64_encode('$2y$13$hashedpassword')]), $secret))
+	// as computed by SignatureHasher::computeSignatureHash
+	assertEqual(t, "MmY5YTBhOTEzYzUzMzcwMWUxNDU1ZDMxMjFiNTY4OWVmY2Y1YWQ4ZmQxMzk1M2QxZWM4OGUxNjk3MmNjMTdlNQ==", sr.ComputeHash("tobi@example.com", time.Unix(1893456000, 0), "$2y$13$hashedpassword"), nil)
+
+	cookie := "QXBwLkVudGl0eS5Vc2VyOmRHOWlhVUJsZUdGdGNHeGxMbU52YlE9PToxODkzNDU2MDAwOk1tWTVZVEJoT1RFell6VXpNemN3TVdVeE5EVTFaRE14TWpGaU5UWTRPV1ZtWTJZMVlXUTRabVF4TXprMU0yUXhaV000T0dVeE5qazNNbU5qTVRkbE5RPT0="
+	details, err := ParseSymfonyRememberMeCookie(cookie)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	assertEqual(t, `App\Entity\User`, details.UserClass, nil)
+	assertEqual(t, "tobi@example.com", details.UserIdentifier, nil)
+	if details.Expires.Unix() != 1893456000 {
+		t.Fatalf("expected: 1893456000, got: %d", details.Expires.Unix())
+	}
+
+	if err := sr.Verify(details, "$2y$13$hashedpassword"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	// changing the password invalidates the cookie
+	if err := sr.Verify(details, "$2y$13$newpassword"); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+
+	assertEqual(t, cookie, sr.Create(`App\Entity\User`, "tobi@example.com", time.Unix(1893456000, 0), "$2y$13$hashedpassword"), nil)
+
+	expired, err := ParseSymfonyRememberMeCookie(sr.Create(`App\Entity\User`, "tobi@example.com", time.Now().Add(-time.Second)))
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if err := sr.Verify(expired); err != errSymfonyExpired {
+		t.Fatalf("expected error: %s, got: %s", errSymfonyExpired, err)
+	}
+
+	if _, err := ParseSymfonyRememberMeCookie("invalid"); err != errSymfonyInvalidCookie {
+		t.Fatalf("expected error: %s, got: %s", errSymfonyInvalidCookie, err)
+	}
+}
@@ -0,0 +1,115 @@
+package cookiesignature
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+const (
+	defaultThrottleResetAfter = 24 * time.Hour
+	throttlePurpose           = "login-throttle"
+)
+
+// ErrLoginThrottled is returned by LoginThrottle.Check while the delay of the last failed attempt runs
+var ErrLoginThrottled = errors.New("login attempt is throttled")
+
+// LoginThrottle enforces progressive delays between failed login attempts with signed tokens kept by the client,
+// e.g. in a cookie, so stateless services need no shared datastore. A client can drop its token to start over,
+// so it only slows down clients that play by the rules; pair it with a server-side limit against targeted attacks
+type LoginThrottle struct {
+	Signer *CookieSignature
+	// FreeAttempts is the number of failed attempts allowed without delay
+	FreeAttempts int
+	// BaseDelay is the delay after the first throttled failure, doubled after each next one
+	BaseDelay time.Duration
+	// MaxDelay caps the delay. It isn't capped if zero
+	MaxDelay time.Duration
+	// ResetAfter forgets the failures once the last one is that old. Defaults to 24 hours
+	ResetAfter time.Duration
+}
+
+type throttleClaims struct {
+	Subject  string `json:"sub"`
+	Attempts int    `json:"n"`
+	// LastFailure and NextAttempt are unix timestamps in seconds
+	LastFailure int64 `json:"lf"`
+	NextAttempt int64 `json:"nxt"`
+}
+
+// Check returns the time left before the subject may attempt to log in again with the token of its last failure,
+// and ErrLoginThrottled if it must wait. An empty token or a token of another subject has no delay
+func (t LoginThrottle) Check(token string, subject string) (time.Duration, error) {
+	claims, err := t.claims(token, subject)
+	if err != nil {
+		return 0, err
+	}
+	wait := time.Unix(claims.NextAttempt, 0).Sub(timeNow())
+	if wait > 0 {
+		return wait, ErrLoginThrottled
+	}
+	return 0, nil
+}
+
+// Fail records a failed attempt of the subject on top of the token of its previous failure,
+// and returns the new token and the delay before the next attempt
+func (t LoginThrottle) Fail(token string, subject string) (string, time.Duration, error) {
+	claims, err := t.claims(token, subject)
+	if err != nil {
+		return "", 0, err
+	}
+
+	now := timeNow()
+	claims.Subject = subject
+	claims.Attempts++
+	claims.LastFailure = now.Unix()
+	delay := t.delay(claims.Attempts)
+	claims.NextAttempt = now.Add(delay).Unix()
+
+	signed, err := t.Signer.signPurposeJSON(throttlePurpose, claims)
+	if err != nil {
+		return "", 0, err
+	}
+	return signed, delay, nil
+}
+
+// claims returns the claims of the token, zero claims if it's empty, of another subject or reset
+func (t LoginThrottle) claims(token string, subject string) (throttleClaims, error) {
+	if t.Signer == nil {
+		return throttleClaims{}, errors.New("signer must be provided")
+	}
+	if token == "" {
+		return throttleClaims{}, nil
+	}
+
+	var claims throttleClaims
+	if err := t.Signer.unsignPurposeJSON(throttlePurpose, token, &claims); err != nil {
+		return throttleClaims{}, err
+	}
+	resetAfter := t.ResetAfter
+	if resetAfter <= 0 {
+		resetAfter = defaultThrottleResetAfter
+	}
+	if claims.Subject != subject || !timeNow().Before(time.Unix(claims.LastFailure, 0).Add(resetAfter)) {
+		return throttleClaims{}, nil
+	}
+	return claims, nil
+}
+
+func (t LoginThrottle) delay(attempts int) time.Duration {
+	throttled := attempts - t.FreeAttempts
+	if throttled <= 0 || t.BaseDelay <= 0 {
+		return 0
+	}
+	delay := t.BaseDelay
+	for i := 1; i < throttled && delay <= math.MaxInt64/2; i++ {
+		if t.MaxDelay > 0 && delay >= t.MaxDelay {
+			break
+		}
+		delay *= 2
+	}
+	if t.MaxDelay > 0 && delay > t.MaxDelay {
+		delay = t.MaxDelay
+	}
+	return delay
+}
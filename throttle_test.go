@@ -0,0 +1,58 @@
+package cookiesignature
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	throttle := LoginThrottle{Signer: cs, FreeAttempts: 2, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	token := ""
+	for i, expected := range []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if wait, err := throttle.Check(token, "tobi"); err != nil || wait != 0 {
+			t.Fatalf("attempt %d: expected no wait, got: %s, %v", i, wait, err)
+		}
+		var delay time.Duration
+		var err error
+		token, delay, err = throttle.Fail(token, "tobi")
+		if err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		if delay != expected {
+			t.Fatalf("attempt %d: expected delay: %s, got: %s", i, expected, delay)
+		}
+		if delay > 0 {
+			if wait, err := throttle.Check(token, "tobi"); err != ErrLoginThrottled || wait != delay {
+				t.Fatalf("attempt %d: expected wait: %s, got: %s, %v", i, delay, wait, err)
+			}
+		}
+		now = now.Add(delay)
+	}
+
+	if wait, err := throttle.Check(token, "loki"); err != nil || wait != 0 {
+		t.Fatalf("expected the token of another subject to be ignored, got: %s, %v", wait, err)
+	}
+	forged, _ := cs.SignJSON(throttleClaims{Subject: "tobi", Attempts: 1, LastFailure: now.Unix()})
+	if _, err := throttle.Check(forged, "tobi"); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+	if _, err := throttle.Check(token+"x", "tobi"); err == nil {
+		t.Fatalf("expected an invalid signature error")
+	}
+
+	now = now.Add(25 * time.Hour)
+	if _, delay, _ := throttle.Fail(token, "tobi"); delay != 0 {
+		t.Fatalf("expected the failures to be reset, got delay: %s", delay)
+	}
+
+	unbounded := LoginThrottle{Signer: cs, BaseDelay: time.Hour}
+	if delay := unbounded.delay(100); delay <= 0 {
+		t.Fatalf("expected a positive delay, got: %s", delay)
+	}
+}
@@ -0,0 +1,101 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const ticketPurpose = "ticket"
+
+// TicketParam is the query parameter of the WebSocket handshake URL that carries the connect ticket
+const TicketParam = "ticket"
+
+// ErrOriginMismatch is returned when a connect ticket is used from another origin than the one it was issued for
+var ErrOriginMismatch = errors.New("ticket was issued for another origin")
+
+type ticketKey struct{}
+
+type ticketClaims struct {
+	Value     string `json:"val"`
+	Origin    string `json:"org"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// SignTicket signs a short-lived connect ticket of the value, bound to the origin of the page opening the WebSocket,
+// e.g. https://app.example.com. Browsers can't set headers on WebSocket handshakes, and cookies alone let any site
+// open a connection on behalf of the user, so the page fetches a ticket and passes it in the "ticket" query parameter
+func (cs CookieSignature) SignTicket(value string, origin string, ttl time.Duration) (string, error) {
+	if origin == "" {
+		return "", errors.New("ticket origin must not be empty")
+	}
+	if ttl <= 0 {
+		return "", errors.New("ticket ttl must be positive")
+	}
+	now := timeNow()
+	return cs.signPurposeJSON(ticketPurpose, ticketClaims{
+		Value:     value,
+		Origin:    origin,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+}
+
+// UnsignTicket verifies the ticket, checks that it didn't expire and that it was issued for the origin, and returns its value.
+// An empty origin never matches
+func (cs CookieSignature) UnsignTicket(ticket string, origin string) (string, error) {
+	return cs.unsignTicket(ticket, origin)
+}
+
+func (cs CookieSignature) unsignTicket(ticket string, origin string) (string, error) {
+	var claims ticketClaims
+	if err := cs.unsignPurposeJSON(ticketPurpose, ticket, &claims); err != nil {
+		return "", err
+	}
+	if err := cs.validateClaims(Claims{IssuedAt: claims.IssuedAt, ExpiresAt: claims.ExpiresAt}, verifyOptions{}); err != nil {
+		return "", err
+	}
+	if origin == "" || !strings.EqualFold(strings.TrimSuffix(claims.Origin, "/"), strings.TrimSuffix(origin, "/")) {
+		return "", ErrOriginMismatch
+	}
+	return claims.Value, nil
+}
+
+// VerifyTicket verifies the ticket of the handshake request against its Origin header and returns its value
+func (cs CookieSignature) VerifyTicket(r *http.Request) (string, error) {
+	ticket := r.URL.Query().Get(TicketParam)
+	if ticket == "" {
+		return "", errEmptySignedValue
+	}
+	return cs.unsignTicket(ticket, r.Header.Get("Origin"))
+}
+
+// CheckTicket reports whether the handshake request carries a valid ticket for its origin.
+// Its signature matches the CheckOrigin field of websocket.Upgrader of gorilla/websocket,
+// so the upgrader rejects handshakes without a valid ticket with 403
+func (cs CookieSignature) CheckTicket(r *http.Request) bool {
+	_, err := cs.VerifyTicket(r)
+	return err == nil
+}
+
+// TicketMiddleware rejects handshake requests without a valid ticket with 403 before they're upgraded,
+// and attaches the value of the ticket to the request context, read with TicketFromContext
+func (cs CookieSignature) TicketMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value, err := cs.VerifyTicket(r)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ticketKey{}, value)))
+	})
+}
+
+// TicketFromContext returns the value of the ticket verified by TicketMiddleware
+func TicketFromContext(ctx context.Context) (string, bool) {
+	value, ok := ctx.Value(ticketKey{}).(string)
+	return value, ok
+}
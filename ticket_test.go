@@ -0,0 +1,72 @@
+package cookiesignature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTicket(t *testing.T) {
+	timeNow = func() time.Time { return time.Unix(1600000000, 0) }
+	defer func() { timeNow = time.Now }()
+
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	if _, err := cs.SignTicket("user-42", "", time.Minute); err == nil {
+		t.Fatalf("expected an origin error")
+	}
+	ticket, err := cs.SignTicket("user-42", "https://app.example.com", 30*time.Second)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	value, err := cs.UnsignTicket(ticket, "https://App.example.com/")
+	assertEqual(t, "user-42", value, err)
+	if _, err := cs.UnsignTicket(ticket, "https://evil.example.com"); err != ErrOriginMismatch {
+		t.Fatalf("expected error: %s, got: %v", ErrOriginMismatch, err)
+	}
+
+	var ctxValue string
+	handler := cs.TicketMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxValue, _ = TicketFromContext(r.Context())
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/ws?"+TicketParam+"="+url.QueryEscape(ticket), nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || ctxValue != "user-42" {
+		t.Fatalf("unexpected response: %d, value: %s", w.Code, ctxValue)
+	}
+	if !cs.CheckTicket(r) {
+		t.Fatalf("expected the ticket to be valid")
+	}
+
+	r.Header.Set("Origin", "https://evil.example.com")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status: %d, got: %d", http.StatusForbidden, w.Code)
+	}
+	if cs.CheckTicket(httptest.NewRequest(http.MethodGet, "/ws", nil)) {
+		t.Fatalf("expected a missing ticket to be rejected")
+	}
+
+	// tickets are bound to their purpose, other signed JSON values and an empty origin don't pass
+	if _, err := cs.UnsignTicket(ticket, ""); err != ErrOriginMismatch {
+		t.Fatalf("expected error: %s, got: %v", ErrOriginMismatch, err)
+	}
+	signedJSON, _ := cs.SignJSON(map[string]interface{}{"val": "admin", "exp": 1600000060})
+	streamToken, _ := cs.SignStreamToken("admin", "/ws", time.Minute)
+	for _, forged := range []string{signedJSON, streamToken} {
+		r := httptest.NewRequest(http.MethodGet, "/ws?"+TicketParam+"="+url.QueryEscape(forged), nil)
+		if _, err := cs.VerifyTicket(r); err != errInvalidSignature {
+			t.Fatalf("expected error: %s for %s, got: %v", errInvalidSignature, forged, err)
+		}
+	}
+
+	timeNow = func() time.Time { return time.Unix(1600000030, 0) }
+	if _, err := cs.UnsignTicket(ticket, "https://app.example.com"); err != ErrTokenExpired {
+		t.Fatalf("expected error: %s, got: %v", ErrTokenExpired, err)
+	}
+}
@@ -0,0 +1,151 @@
+package cookiesignature
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrTokenExpired is returned when the expiration time of a timed token has passed
+	ErrTokenExpired = errors.New("token is expired")
+	// ErrTokenNotYetValid is returned when a timed token is used before it was issued or before its not-before time
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+	// ErrTokenNotFresh is returned when a timed token was issued longer ago than the maximum age
+	ErrTokenNotFresh = errors.New("token is not fresh")
+	// ErrTokenTooNew is returned when a timed token was issued more recently than the minimum age
+	ErrTokenTooNew = errors.New("token is too new")
+)
+
+// Claims is the payload of timed tokens. Times are unix timestamps in seconds
+type Claims struct {
+	// ID identifies the token, so it can be revoked
+	ID        string `json:"jti,omitempty"`
+	Value     string `json:"val"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	// Device identifies the device the token was issued to, checked by WithDevice
+	Device string `json:"dev,omitempty"`
+	// AuthLevel is the authentication level of the session, higher after a step-up, checked by WithMinAuthLevel
+	AuthLevel int `json:"acr,omitempty"`
+	// AuthMethods are the authentication methods used, e.g. pwd and otp, checked by WithAuthMethods
+	AuthMethods []string `json:"amr,omitempty"`
+	// Generation is the session generation of the account when the token was issued, checked by WithGeneration
+	Generation uint64 `json:"gen,omitempty"`
+}
+
+// SignTimed signs the value into a timed token that expires after ttl.
+// The token never expires if ttl isn't positive
+func (cs CookieSignature) SignTimed(value string, ttl time.Duration) (string, error) {
+	now := timeNow()
+	claims := Claims{
+		Value:    value,
+		IssuedAt: now.Unix(),
+	}
+	if ttl > 0 {
+		claims.ExpiresAt = now.Add(ttl).Unix()
+	}
+	return cs.SignClaims(claims)
+}
+
+// SignTimedFrom signs the value into a timed token that only becomes valid at notBefore and expires ttl later.
+// The token never expires if ttl isn't positive
+func (cs CookieSignature) SignTimedFrom(value string, notBefore time.Time, ttl time.Duration) (string, error) {
+	claims := Claims{
+		Value:     value,
+		IssuedAt:  timeNow().Unix(),
+		NotBefore: notBefore.Unix(),
+	}
+	if ttl > 0 {
+		claims.ExpiresAt = notBefore.Add(ttl).Unix()
+	}
+	return cs.SignClaims(claims)
+}
+
+// UnsignTimed verifies the timed token created by SignTimed and returns its value
+func (cs CookieSignature) UnsignTimed(input string, opts ...VerifyOption) (string, error) {
+	claims, err := cs.UnsignClaims(input, opts...)
+	if err != nil {
+		return "", err
+	}
+	return claims.Value, nil
+}
+
+// SignClaims serializes and signs the claims into a timed token
+func (cs CookieSignature) SignClaims(claims Claims) (string, error) {
+	return cs.SignJSON(claims)
+}
+
+// UnsignClaims verifies the timed token and validates its time claims.
+// The leeway configured by WithLeeway is tolerated on every time comparison
+func (cs CookieSignature) UnsignClaims(input string, opts ...VerifyOption) (Claims, error) {
+	return cs.UnsignClaimsContext(context.Background(), input, opts...)
+}
+
+// UnsignClaimsContext is like UnsignClaims, the context is passed to the hooks and the revocation checker
+func (cs CookieSignature) UnsignClaimsContext(ctx context.Context, input string, opts ...VerifyOption) (Claims, error) {
+	var claims Claims
+	if err := cs.unsignJSON(ctx, input, &claims); err != nil {
+		return Claims{}, err
+	}
+
+	var verifyOpts verifyOptions
+	for _, opt := range opts {
+		opt(&verifyOpts)
+	}
+	if err := cs.validateClaims(claims, verifyOpts); err != nil {
+		return Claims{}, err
+	}
+	if err := checkGeneration(ctx, claims, verifyOpts); err != nil {
+		return Claims{}, err
+	}
+
+	if claims.ID != "" && cs.opts.revocationChecker != nil {
+		revoked, err := cs.opts.revocationChecker.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return Claims{}, err
+		}
+		if revoked {
+			return Claims{}, ErrTokenRevoked
+		}
+	}
+	return claims, nil
+}
+
+func (cs CookieSignature) validateClaims(claims Claims, opts verifyOptions) error {
+	now := timeNow()
+	leeway := cs.opts.leeway
+
+	if claims.ExpiresAt != 0 && !now.Before(time.Unix(claims.ExpiresAt, 0).Add(leeway)) {
+		return ErrTokenExpired
+	}
+	if claims.IssuedAt != 0 && now.Add(leeway).Before(time.Unix(claims.IssuedAt, 0)) {
+		return ErrTokenNotYetValid
+	}
+	if claims.NotBefore != 0 && now.Add(leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return ErrTokenNotYetValid
+	}
+
+	if opts.device != nil && !EqualString(claims.Device, *opts.device) {
+		return ErrDeviceMismatch
+	}
+
+	if err := validateAuthLevel(claims, opts); err != nil {
+		return err
+	}
+
+	if opts.maxAge > 0 || opts.minAge > 0 {
+		if claims.IssuedAt == 0 {
+			return ErrTokenNotFresh
+		}
+		age := now.Sub(time.Unix(claims.IssuedAt, 0))
+		if opts.maxAge > 0 && age > opts.maxAge+leeway {
+			return ErrTokenNotFresh
+		}
+		if opts.minAge > 0 && age+leeway < opts.minAge {
+			return ErrTokenTooNew
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package cookiesignature
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignTimed(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+
+	cs, err := NewCookieSignature([]string{"tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	signed, err := cs.SignTimed("hello", time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	val, err := cs.UnsignTimed(signed)
+	assertEqual(t, "hello", val, err)
+
+	timeNow = func() time.Time { return time.Now().Add(time.Minute) }
+	if _, err := cs.UnsignTimed(signed); err != ErrTokenExpired {
+		t.Fatalf("expected error: %s, got: %s", ErrTokenExpired, err)
+	}
+
+	forever, err := cs.SignTimed("hello", 0)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	timeNow = func() time.Time { return time.Now().Add(24 * 365 * time.Hour) }
+	val, err = cs.UnsignTimed(forever)
+	assertEqual(t, "hello", val, err)
+
+	if _, err := cs.UnsignTimed("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestTimedLeeway(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+
+	now := time.Now()
+	strict, err := NewCookieSignature([]string{"tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	lenient, err := NewCookieSignature([]string{"tobiiscool"}, WithLeeway(time.Minute))
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	// minted by a peer whose clock is 30 seconds ahead
+	signed, err := strict.SignClaims(Claims{Value: "hello", IssuedAt: now.Add(30 * time.Second).Unix(), ExpiresAt: now.Add(40 * time.Second).Unix()})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	timeNow = func() time.Time { return now }
+	if _, err := strict.UnsignClaims(signed); err != ErrTokenNotYetValid {
+		t.Fatalf("expected error: %s, got: %s", ErrTokenNotYetValid, err)
+	}
+	claims, err := lenient.UnsignClaims(signed)
+	assertEqual(t, "hello", claims.Value, err)
+
+	timeNow = func() time.Time { return now.Add(90 * time.Second) }
+	if _, err := strict.UnsignClaims(signed); err != ErrTokenExpired {
+		t.Fatalf("expected error: %s, got: %s", ErrTokenExpired, err)
+	}
+	claims, err = lenient.UnsignClaims(signed)
+	assertEqual(t, "hello", claims.Value, err)
+
+	timeNow = func() time.Time { return now.Add(2 * time.Minute) }
+	if _, err := lenient.UnsignClaims(signed); err != ErrTokenExpired {
+		t.Fatalf("expected error: %s, got: %s", ErrTokenExpired, err)
+	}
+}
+
+func TestSignTimedFrom(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+
+	now := time.Now()
+	cs, err := NewCookieSignature([]string{"tobiiscool"}, WithLeeway(10*time.Second))
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	signed, err := cs.SignTimedFrom("hello", now.Add(time.Hour), time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	timeNow = func() time.Time { return now }
+	if _, err := cs.UnsignTimed(signed); err != ErrTokenNotYetValid {
+		t.Fatalf("expected error: %s, got: %s", ErrTokenNotYetValid, err)
+	}
+
+	timeNow = func() time.Time { return now.Add(time.Hour - 5*time.Second) }
+	val, err := cs.UnsignTimed(signed)
+	assertEqual(t, "hello", val, err)
+
+	timeNow = func() time.Time { return now.Add(2*time.Hour + 10*time.Second) }
+	if _, err := cs.UnsignTimed(signed); err != ErrTokenExpired {
+		t.Fatalf("expected error: %s, got: %s", ErrTokenExpired, err)
+	}
+}
+
+func TestTimedFreshness(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+
+	now := time.Now()
+	cs, err := NewCookieSignature([]string{"tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	timeNow = func() time.Time { return now }
+	signed, err := cs.SignTimed("hello", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	timeNow = func() time.Time { return now.Add(10 * time.Minute) }
+	val, err := cs.UnsignTimed(signed, WithMaxAge(15*time.Minute), WithMinAge(time.Minute))
+	assertEqual(t, "hello", val, err)
+
+	if _, err := cs.UnsignTimed(signed, WithMaxAge(5*time.Minute)); err != ErrTokenNotFresh {
+		t.Fatalf("expected error: %s, got: %s", ErrTokenNotFresh, err)
+	}
+	if _, err := cs.UnsignTimed(signed, WithMinAge(time.Hour)); err != ErrTokenTooNew {
+		t.Fatalf("expected error: %s, got: %s", ErrTokenTooNew, err)
+	}
+
+	withoutIssuedAt, err := cs.SignClaims(Claims{Value: "hello"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if _, err := cs.UnsignTimed(withoutIssuedAt, WithMaxAge(time.Hour)); err != ErrTokenNotFresh {
+		t.Fatalf("expected error: %s, got: %s", ErrTokenNotFresh, err)
+	}
+}
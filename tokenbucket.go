@@ -0,0 +1,126 @@
+package cookiesignature
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const tokenBucketPurpose = "token-bucket"
+
+// ErrRateLimited is returned by TokenBucket.Take when the bucket of the subject is empty
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// TokenBucket limits the rate of the requests of a client with the state of its token bucket kept by the client,
+// e.g. in a cookie, so stateless edge nodes enforce an approximate per-client limit without a shared datastore.
+// The state is verified and clamped on every request, but a client can drop it or replay an older state to refill its bucket,
+// so it only slows down clients that play by the rules; pair it with a server-side limit against targeted abuse
+type TokenBucket struct {
+	Signer *CookieSignature
+	// Capacity is the maximum number of tokens, the burst allowed to a new client
+	Capacity int
+	// RefillInterval is the time to refill one token
+	RefillInterval time.Duration
+}
+
+type tokenBucketClaims struct {
+	Subject string `json:"sub"`
+	Tokens  int    `json:"n"`
+	// RefilledAt is the unix time in milliseconds the tokens were counted at
+	RefilledAt int64 `json:"t"`
+}
+
+// Take spends a token of the bucket of the subject, with the state of the previous request.
+// It returns the new state, the remaining tokens, and ErrRateLimited with the time until the next token when the bucket is empty.
+// An empty state, or a state of another subject, is a full bucket
+func (b TokenBucket) Take(state string, subject string) (string, int, time.Duration, error) {
+	if b.Signer == nil || b.Capacity <= 0 || b.RefillInterval <= 0 {
+		return "", 0, 0, errors.New("token bucket signer, capacity and refill interval must be provided")
+	}
+	claims, err := b.claims(state, subject)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	var wait time.Duration
+	if claims.Tokens > 0 {
+		claims.Tokens--
+	} else {
+		wait = time.Unix(0, claims.RefilledAt*int64(time.Millisecond)).Add(b.RefillInterval).Sub(timeNow())
+	}
+	signed, err := b.Signer.signPurposeJSON(tokenBucketPurpose, claims)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	if wait > 0 {
+		return signed, 0, wait, ErrRateLimited
+	}
+	return signed, claims.Tokens, 0, nil
+}
+
+// Middleware limits the rate of the requests of each subject, e.g. a device ID, with the state kept in the cookie of the template.
+// Limited requests are rejected with 429 and a Retry-After header. Requests with an invalid state are treated like new clients
+func (b TokenBucket) Middleware(template http.Cookie, subject func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var state string
+			if cookie, err := r.Cookie(template.Name); err == nil {
+				state = cookie.Value
+			}
+			id := subject(r)
+			signed, _, wait, err := b.Take(state, id)
+			if err != nil && err != ErrRateLimited {
+				// a forged or expired state starts a new bucket
+				signed, _, wait, err = b.Take("", id)
+			}
+			if err != nil && err != ErrRateLimited {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			cookie := template
+			cookie.Value = signed
+			http.SetCookie(w, &cookie)
+			if err == ErrRateLimited {
+				w.Header().Set("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// claims returns the refilled claims of the state, clamped to the capacity and to the current time
+func (b TokenBucket) claims(state string, subject string) (tokenBucketClaims, error) {
+	now := timeNow()
+	full := tokenBucketClaims{Subject: subject, Tokens: b.Capacity, RefilledAt: now.UnixNano() / int64(time.Millisecond)}
+	if state == "" {
+		return full, nil
+	}
+
+	var claims tokenBucketClaims
+	if err := b.Signer.unsignPurposeJSON(tokenBucketPurpose, state, &claims); err != nil {
+		return tokenBucketClaims{}, err
+	}
+	if claims.Subject != subject {
+		return full, nil
+	}
+
+	// a state counted in the future, e.g. by a node whose clock is ahead, is counted now
+	refilledAt := time.Unix(0, claims.RefilledAt*int64(time.Millisecond))
+	if refilledAt.After(now) {
+		refilledAt = now
+	}
+	if claims.Tokens < 0 {
+		claims.Tokens = 0
+	}
+	refills := int64(now.Sub(refilledAt) / b.RefillInterval)
+	if refills >= int64(b.Capacity-claims.Tokens) {
+		return full, nil
+	}
+	claims.Tokens += int(refills)
+	claims.RefilledAt = refilledAt.Add(time.Duration(refills)*b.RefillInterval).UnixNano() / int64(time.Millisecond)
+	return claims, nil
+}
@@ -0,0 +1,89 @@
+package cookiesignature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return start }
+	defer func() { timeNow = time.Now }()
+
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	bucket := TokenBucket{Signer: cs, Capacity: 2, RefillInterval: 10 * time.Second}
+
+	state, remaining, _, err := bucket.Take("", "device-1")
+	if err != nil || remaining != 1 {
+		t.Fatalf("unexpected result: %d, %v", remaining, err)
+	}
+	state, remaining, _, err = bucket.Take(state, "device-1")
+	if err != nil || remaining != 0 {
+		t.Fatalf("unexpected result: %d, %v", remaining, err)
+	}
+	timeNow = func() time.Time { return start.Add(4 * time.Second) }
+	limited, _, wait, err := bucket.Take(state, "device-1")
+	if err != ErrRateLimited || wait != 6*time.Second {
+		t.Fatalf("expected error: %s after %s, got: %v after %s", ErrRateLimited, 6*time.Second, err, wait)
+	}
+
+	// a token is refilled every interval
+	timeNow = func() time.Time { return start.Add(15 * time.Second) }
+	state, remaining, _, err = bucket.Take(limited, "device-1")
+	if err != nil || remaining != 0 {
+		t.Fatalf("unexpected result: %d, %v", remaining, err)
+	}
+	if _, _, wait, err := bucket.Take(state, "device-1"); err != ErrRateLimited || wait != 5*time.Second {
+		t.Fatalf("expected error: %s after %s, got: %v after %s", ErrRateLimited, 5*time.Second, err, wait)
+	}
+
+	// the bucket never refills beyond its capacity
+	timeNow = func() time.Time { return start.Add(time.Hour) }
+	if _, remaining, _, err := bucket.Take(state, "device-1"); err != nil || remaining != 1 {
+		t.Fatalf("unexpected result: %d, %v", remaining, err)
+	}
+
+	// a state counted in the future is clamped to now
+	futureClaims := tokenBucketClaims{Subject: "device-1", Tokens: 0, RefilledAt: start.Add(2*time.Hour).UnixNano() / int64(time.Millisecond)}
+	future, _ := cs.signPurposeJSON(tokenBucketPurpose, futureClaims)
+	if _, _, wait, err := bucket.Take(future, "device-1"); err != ErrRateLimited || wait != 10*time.Second {
+		t.Fatalf("expected error: %s after %s, got: %v after %s", ErrRateLimited, 10*time.Second, err, wait)
+	}
+	if _, remaining, _, err := bucket.Take(state, "device-2"); err != nil || remaining != 1 {
+		t.Fatalf("unexpected result: %d, %v", remaining, err)
+	}
+	if _, _, _, err := bucket.Take("forged.state", "device-1"); err == nil {
+		t.Fatal("expected an error for a forged state")
+	}
+	// other JSON tokens with the fields of the state aren't states
+	other, _ := cs.SignJSON(futureClaims)
+	if _, _, _, err := bucket.Take(other, "device-1"); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+	}
+}
+
+func TestTokenBucketMiddleware(t *testing.T) {
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	bucket := TokenBucket{Signer: cs, Capacity: 1, RefillInterval: time.Hour}
+	handler := bucket.Middleware(http.Cookie{Name: "rl", Path: "/"}, func(r *http.Request) string { return "device-1" })(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(&http.Cookie{Name: "rl", Value: "forged.state"})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	cookies := recorder.Result().Cookies()
+	if recorder.Code != http.StatusOK || len(cookies) != 1 {
+		t.Fatalf("unexpected response: %d %v", recorder.Code, cookies)
+	}
+
+	request = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(cookies[0])
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusTooManyRequests || recorder.Header().Get("Retry-After") != "3600" {
+		t.Fatalf("unexpected response: %d %s", recorder.Code, recorder.Header().Get("Retry-After"))
+	}
+}
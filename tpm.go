@@ -0,0 +1,54 @@
+package cookiesignature
+
+import (
+	"context"
+	"fmt"
+)
+
+// TPMSealer seals data to the TPM of the machine, e.g. with tpm2.Seal and tpm2.Unseal of go-tpm under a storage root key
+// and an optional PCR policy. It keeps this package free of a go-tpm dependency
+type TPMSealer interface {
+	Seal(data []byte) ([]byte, error)
+	Unseal(sealed []byte) ([]byte, error)
+}
+
+// SealedKeyStore is a KeyStore that seals the secret of every key to a TPM before saving it to the underlying store,
+// so the stored keys are bound to the machine and useless if the file is exfiltrated.
+// Secrets are sealed one by one, as a TPM only seals small objects
+type SealedKeyStore struct {
+	Store  KeyStore
+	Sealer TPMSealer
+}
+
+// Load loads the keys from the underlying store and unseals their secrets.
+// The secrets of passphrase keys aren't sealed, the underlying store derives them from the passphrase
+func (s SealedKeyStore) Load(ctx context.Context) ([]Key, error) {
+	keys, err := s.Store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		if len(key.Secret) == 0 || key.Scrypt != nil {
+			continue
+		}
+		if keys[i].Secret, err = s.Sealer.Unseal(key.Secret); err != nil {
+			return nil, fmt.Errorf("key %s: %w", key.ID, err)
+		}
+	}
+	return keys, nil
+}
+
+// Save seals the secrets of the keys and saves them to the underlying store
+func (s SealedKeyStore) Save(ctx context.Context, keys []Key) error {
+	sealed := copyKeys(keys)
+	for i, key := range sealed {
+		if len(key.Secret) == 0 || key.Scrypt != nil {
+			continue
+		}
+		var err error
+		if sealed[i].Secret, err = s.Sealer.Seal(key.Secret); err != nil {
+			return fmt.Errorf("key %s: %w", key.ID, err)
+		}
+	}
+	return s.Store.Save(ctx, sealed)
+}
@@ -0,0 +1,98 @@
+package cookiesignature
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testTPMSealer binds the data to a machine secret, like a TPM binds it to its storage root key
+type testTPMSealer struct {
+	machine []byte
+}
+
+func (s testTPMSealer) Seal(data []byte) ([]byte, error) {
+	mac, _ := computeHMAC256(string(data), s.machine)
+	return append(mac, data...), nil
+}
+
+func (s testTPMSealer) Unseal(sealed []byte) ([]byte, error) {
+	if len(sealed) < 32 {
+		return nil, errors.New("TPM_RC_INTEGRITY")
+	}
+	mac, _ := computeHMAC256(string(sealed[32:]), s.machine)
+	if !Equal(mac, sealed[:32]) {
+		return nil, errors.New("TPM_RC_INTEGRITY")
+	}
+	return sealed[32:], nil
+}
+
+func TestSealedKeyStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cookiesignature")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileStore := FileKeyStore{Path: filepath.Join(dir, "keys.json")}
+	store := SealedKeyStore{Store: fileStore, Sealer: testTPMSealer{machine: []byte("machine-a")}}
+
+	keys := []Key{{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive}}
+	if err := store.Save(context.Background(), keys); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	stored, _ := fileStore.Load(context.Background())
+	if bytes.Equal(stored[0].Secret, keys[0].Secret) {
+		t.Fatalf("expected the secret to be sealed")
+	}
+	if string(keys[0].Secret) != "tobiiscool" {
+		t.Fatalf("expected the saved keys to be left untouched, got: %s", keys[0].Secret)
+	}
+
+	loaded, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if string(loaded[0].Secret) != "tobiiscool" {
+		t.Fatalf("unexpected secret: %s", loaded[0].Secret)
+	}
+
+	exfiltrated := SealedKeyStore{Store: fileStore, Sealer: testTPMSealer{machine: []byte("machine-b")}}
+	if _, err := exfiltrated.Load(context.Background()); err == nil || err.Error() != "key k0: TPM_RC_INTEGRITY" {
+		t.Fatalf("expected error: key k0: TPM_RC_INTEGRITY, got: %s", err)
+	}
+}
+
+type memoryKeyStore struct {
+	keys []Key
+}
+
+func (s *memoryKeyStore) Load(context.Context) ([]Key, error) {
+	return copyKeys(s.keys), nil
+}
+
+func (s *memoryKeyStore) Save(_ context.Context, keys []Key) error {
+	s.keys = copyKeys(keys)
+	return nil
+}
+
+func TestSealedKeyStorePassphraseKeys(t *testing.T) {
+	passphraseKey, _ := NewPassphraseKey("k1", "correct horse battery staple", ScryptParams{Salt: []byte("pepper"), N: 16, R: 8, P: 1, KeyLength: 32})
+	keys := []Key{{ID: "k0", Secret: []byte("tobiiscool"), State: KeyActive}, passphraseKey}
+
+	store := SealedKeyStore{Store: &memoryKeyStore{}, Sealer: testTPMSealer{machine: []byte("machine-a")}}
+	if err := store.Save(context.Background(), keys); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	loaded, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if string(loaded[0].Secret) != "tobiiscool" || !bytes.Equal(loaded[1].Secret, passphraseKey.Secret) {
+		t.Fatalf("unexpected secrets: %s %x", loaded[0].Secret, loaded[1].Secret)
+	}
+}
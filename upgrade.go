@@ -0,0 +1,39 @@
+package cookiesignature
+
+import "net/http"
+
+// UpgradeCookies returns a middleware that re-signs the named cookies with the newest secret when they verify
+// under an older secret or in a non-canonical format (padded, url-safe or percent-encoded signatures),
+// and sets the replacement cookies on the response, draining old secrets from circulation without application changes.
+// The attributes of the replacement cookies are copied from the template. Invalid cookies are left untouched.
+// Cookies signed by SignScoped aren't supported because their scope isn't known from the request
+func (cs CookieSignature) UpgradeCookies(template http.Cookie, names ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, name := range names {
+				cs.upgradeCookie(w, r, template, name)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (cs CookieSignature) upgradeCookie(w http.ResponseWriter, r *http.Request, template http.Cookie, name string) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return
+	}
+	value, err := cs.Unsign(cookie.Value)
+	if err != nil {
+		return
+	}
+	resigned, err := cs.Sign(value)
+	if err != nil || resigned == cookie.Value {
+		return
+	}
+
+	replacement := template
+	replacement.Name = name
+	replacement.Value = resigned
+	http.SetCookie(w, &replacement)
+}
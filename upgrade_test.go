@@ -0,0 +1,44 @@
+package cookiesignature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUpgradeCookies(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"n3wsecr3t", "tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	current := cs.MustSign("world")
+
+	var called bool
+	handler := cs.UpgradeCookies(http.Cookie{Path: "/", HttpOnly: true}, "old", "padded", "current", "invalid", "missing")(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(&http.Cookie{Name: "old", Value: "hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI"})
+	request.AddCookie(&http.Cookie{Name: "padded", Value: current + "="})
+	request.AddCookie(&http.Cookie{Name: "current", Value: current})
+	request.AddCookie(&http.Cookie{Name: "invalid", Value: "hello.invalid"})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if !called {
+		t.Fatal("expected the next handler to be called")
+	}
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 2 {
+		t.Fatalf("expected 2 cookies, got: %d", len(cookies))
+	}
+	assertEqual(t, "old", cookies[0].Name, nil)
+	assertEqual(t, cs.MustSign("hello"), cookies[0].Value, nil)
+	if !cookies[0].HttpOnly {
+		t.Fatal("expected the template attributes to be copied")
+	}
+	assertEqual(t, "padded", cookies[1].Name, nil)
+	assertEqual(t, current, cookies[1].Value, nil)
+}
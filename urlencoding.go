@@ -0,0 +1,41 @@
+package cookiesignature
+
+import (
+	"net/url"
+	"strings"
+)
+
+// uriComponentReplacer turns the output of url.QueryEscape into the output of encodeURIComponent
+var uriComponentReplacer = strings.NewReplacer("+", "%20", "%21", "!", "%27", "'", "%28", "(", "%29", ")", "%2A", "*")
+
+// WithURLEncoding percent-encodes signed outputs in the same way as encodeURIComponent,
+// which express uses to write signed cookies, e.g. s%3Ahello.DGDUkGlIkCzPz%2BC0B064FNgHdEjox7ch8tOBGslZ5QI.
+// Unsign then always decodes the input. Without this option, percent-encoded inputs are only decoded in the lenient parse mode
+func WithURLEncoding() Option {
+	return func(o *options) {
+		o.urlEncoding = true
+	}
+}
+
+func (cs CookieSignature) encodeOutput(output string) string {
+	if !cs.opts.urlEncoding {
+		return output
+	}
+	return encodeURIComponent(output)
+}
+
+func encodeURIComponent(input string) string {
+	return uriComponentReplacer.Replace(url.QueryEscape(input))
+}
+
+// decodeInput decodes percent-encoded inputs, it reports false if the input must not or can't be decoded
+func (cs CookieSignature) decodeInput(input string) (string, bool) {
+	if !cs.opts.urlEncoding && (cs.opts.parseMode != ParseLenient || !strings.Contains(input, "%")) {
+		return input, false
+	}
+	unescaped, err := url.PathUnescape(input)
+	if err != nil {
+		return input, false
+	}
+	return unescaped, true
+}
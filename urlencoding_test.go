@@ -0,0 +1,45 @@
+package cookiesignature
+
+import "testing"
+
+func TestURLEncoding(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	// written by express-session
+	sid, err := cs.UnsignSessionID("s%3Ahello.DGDUkGlIkCzPz%2BC0B064FNgHdEjox7ch8tOBGslZ5QI")
+	assertEqual(t, "hello", sid, err)
+	val, err := cs.Unsign("hello.DGDUkGlIkCzPz%2BC0B064FNgHdEjox7ch8tOBGslZ5QI")
+	assertEqual(t, "hello", val, err)
+
+	strict, err := NewCookieSignature([]string{"tobiiscool"}, WithParseMode(ParseStrict))
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if _, err := strict.Unsign("hello.DGDUkGlIkCzPz%2BC0B064FNgHdEjox7ch8tOBGslZ5QI"); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+
+	encoded, err := NewCookieSignature([]string{"tobiiscool"}, WithURLEncoding(), WithParseMode(ParseStrict))
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	signed, err := encoded.SignSessionID("hello")
+	assertEqual(t, "s%3Ahello.DGDUkGlIkCzPz%2BC0B064FNgHdEjox7ch8tOBGslZ5QI", signed, err)
+	sid, err = encoded.UnsignSessionID(signed)
+	assertEqual(t, "hello", sid, err)
+
+	signed, err = encoded.Sign("hello world")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	assertEqual(t, "hello%20world", signed[:13], nil)
+	val, err = encoded.Unsign(signed)
+	assertEqual(t, "hello world", val, err)
+
+	if _, err := encoded.Unsign("hello.%zz"); err != errInvalidSignature {
+		t.Fatalf("expected error: %s, got: %s", errInvalidSignature, err)
+	}
+}
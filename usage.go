@@ -0,0 +1,99 @@
+package cookiesignature
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// verificationKey is a secret or a MACProvider that verifies incoming values, with the ID its usage is counted under
+type verificationKey struct {
+	id     string
+	secret []byte
+	mac    MACProvider
+	// algorithm of the MAC of the secret, HMAC-SHA256 if zero
+	algorithm macAlgorithm
+}
+
+func (k verificationKey) computeMAC(value string) ([]byte, error) {
+	if k.mac != nil {
+		return k.mac.MAC([]byte(value))
+	}
+	return k.algorithm.compute(value, k.secret)
+}
+
+// keyUsage counts the successful verifications of each key ID and remembers the most recently used key
+type keyUsage struct {
+	// counters holds a *keyCounter by key ID
+	counters sync.Map
+	// lastID holds the ID of the key that most recently verified successfully
+	lastID atomic.Value
+}
+
+type keyCounter struct {
+	verifications uint64
+	// lastUsed is the unix time in nanoseconds of the last verification
+	lastUsed int64
+}
+
+func (u *keyUsage) record(id string) {
+	if u == nil {
+		return
+	}
+	if last, _ := u.lastID.Load().(string); last != id {
+		u.lastID.Store(id)
+	}
+	counter, ok := u.counters.Load(id)
+	if !ok {
+		counter, _ = u.counters.LoadOrStore(id, &keyCounter{})
+	}
+	atomic.AddUint64(&counter.(*keyCounter).verifications, 1)
+	atomic.StoreInt64(&counter.(*keyCounter).lastUsed, timeNow().UnixNano())
+}
+
+// KeyUsage returns the number of successful verifications served by each key since the CookieSignature was created,
+// so operators can see when the traffic of an old key reaches zero and it's safe to retire it.
+// Keys of a KeyRing are identified by their ID, plain secrets by their index in the secrets passed to NewCookieSignature
+func (cs CookieSignature) KeyUsage() map[string]uint64 {
+	result := make(map[string]uint64)
+	if cs.usage == nil {
+		return result
+	}
+	cs.usage.counters.Range(func(id, counter interface{}) bool {
+		result[id.(string)] = atomic.LoadUint64(&counter.(*keyCounter).verifications)
+		return true
+	})
+	return result
+}
+
+// KeyLastUsed returns when each key last verified successfully since the CookieSignature was created,
+// keys are identified as in KeyUsage
+func (cs CookieSignature) KeyLastUsed() map[string]time.Time {
+	result := make(map[string]time.Time)
+	if cs.usage == nil {
+		return result
+	}
+	cs.usage.counters.Range(func(id, counter interface{}) bool {
+		result[id.(string)] = time.Unix(0, atomic.LoadInt64(&counter.(*keyCounter).lastUsed))
+		return true
+	})
+	return result
+}
+
+// mostRecentlyUsedFirst moves the key that most recently verified successfully to the front,
+// so long rotation overlaps don't cost a failed MAC for every value signed with an older key
+func (u *keyUsage) mostRecentlyUsedFirst(keys []verificationKey) []verificationKey {
+	if u == nil {
+		return keys
+	}
+	last, _ := u.lastID.Load().(string)
+	for i := 1; i < len(keys); i++ {
+		if keys[i].id == last {
+			ordered := make([]verificationKey, 0, len(keys))
+			ordered = append(ordered, keys[i])
+			ordered = append(ordered, keys[:i]...)
+			return append(ordered, keys[i+1:]...)
+		}
+	}
+	return keys
+}
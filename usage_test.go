@@ -0,0 +1,93 @@
+package cookiesignature
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyUsage(t *testing.T) {
+	old, _ := NewCookieSignature([]string{"tobiiscool"})
+	cs, _ := NewCookieSignature([]string{"n3wsecr3t", "tobiiscool"})
+
+	oldSigned, _ := old.Sign("hello")
+	newSigned, _ := cs.Sign("hello")
+	encrypted, _ := old.Encrypt([]byte("hello"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = cs.Unsign(newSigned)
+		}()
+	}
+	wg.Wait()
+	_, _ = cs.Unsign(oldSigned)
+	_, _ = cs.Unsign("hello.invalid")
+	if _, err := cs.Decrypt(encrypted); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	usage := cs.KeyUsage()
+	if len(usage) != 2 || usage["0"] != 10 || usage["1"] != 2 {
+		t.Fatalf("unexpected usage: %v", usage)
+	}
+
+	kr, _ := NewKeyRing(Key{ID: "k1", Secret: []byte("n3wsecr3t"), State: KeyActive}, Key{ID: "k0", Secret: []byte("tobiiscool"), State: KeyVerifyOnly})
+	cs, _ = NewCookieSignatureFromKeyRing(kr)
+	_, _ = cs.Unsign(oldSigned)
+	if usage := cs.KeyUsage(); len(usage) != 1 || usage["k0"] != 1 {
+		t.Fatalf("unexpected usage: %v", usage)
+	}
+
+	if usage := (CookieSignature{}).KeyUsage(); len(usage) != 0 {
+		t.Fatalf("unexpected usage: %v", usage)
+	}
+}
+
+func TestKeyLastUsed(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	cs, _ := NewCookieSignature([]string{"n3wsecr3t", "tobiiscool"})
+	_, _ = cs.Unsign("hello.DGDUkGlIkCzPz+C0B064FNgHdEjox7ch8tOBGslZ5QI")
+	now = now.Add(time.Hour)
+	_, _ = cs.Unsign("hello.fJDsH8b7iNvcQdwtuhE29LZUFMorBk6MOzotVfMoiOc")
+
+	lastUsed := cs.KeyLastUsed()
+	if len(lastUsed) != 2 || !lastUsed["0"].Equal(now) || !lastUsed["1"].Equal(now.Add(-time.Hour)) {
+		t.Fatalf("unexpected last used times: %v", lastUsed)
+	}
+	if lastUsed := (CookieSignature{}).KeyLastUsed(); len(lastUsed) != 0 {
+		t.Fatalf("unexpected last used times: %v", lastUsed)
+	}
+}
+
+func TestMostRecentlyUsedKey(t *testing.T) {
+	var computed []string
+	provider := func(id string) MACProvider {
+		return macProviderFunc(func(input []byte) ([]byte, error) {
+			computed = append(computed, id)
+			return computeHMAC256(string(input), []byte(id))
+		})
+	}
+	cs, _ := NewCookieSignatureFromMAC([]MACProvider{provider("new"), provider("old")})
+	old, _ := NewCookieSignature([]string{"old"})
+	oldSigned, _ := old.Sign("hello")
+
+	for i := 0; i < 2; i++ {
+		result, err := cs.Unsign(oldSigned)
+		assertEqual(t, "hello", result, err)
+	}
+	// the second verification starts with the old key that verified the first one
+	assertEqual(t, "new,old,old", strings.Join(computed, ","), nil)
+
+	computed = nil
+	signed, _ := cs.Sign("hello")
+	result, err := cs.Unsign(signed)
+	assertEqual(t, "hello", result, err)
+	assertEqual(t, "new,old,new", strings.Join(computed, ","), nil)
+}
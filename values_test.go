@@ -0,0 +1,44 @@
+package cookiesignature
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSignValues(t *testing.T) {
+	cs, err := NewCookieSignature([]string{"tobiiscool"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+
+	if _, err := cs.SignValues(); err != errEmptyUnsignedValue {
+		t.Fatalf("expected error: %s, got: %s", errEmptyUnsignedValue, err)
+	}
+
+	values := []string{"42", "admin:owner", "", "acme.corp"}
+	signed, err := cs.SignValues(values...)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	result, err := cs.UnsignValues(signed)
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if !reflect.DeepEqual(values, result) {
+		t.Fatalf("expected: %v, got: %v", values, result)
+	}
+
+	// values that would be ambiguous when joined by a delimiter are signed differently
+	ambiguous1, err := cs.SignValues("a:b", "c")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	ambiguous2, err := cs.SignValues("a", "b:c")
+	assertNotEqual(t, ambiguous1, ambiguous2, err)
+
+	for _, payload := range []string{"3:ab", "x:abc", "-1:", "01:a", ":a"} {
+		if _, err := decodeValues(payload); err != errInvalidValues {
+			t.Fatalf("expected error: %s for %s, got: %s", errInvalidValues, payload, err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package cookiesignature
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	wizardPurpose = "wizard"
+	// defaultWizardMaxSize leaves room for the name and attributes in the 4096 bytes browsers keep per cookie
+	defaultWizardMaxSize = 3800
+	defaultWizardTTL     = time.Hour
+	// maxWizardStateSize bounds the decompressed state, so a small cookie can't inflate into a large allocation
+	maxWizardStateSize = 64 << 10
+)
+
+// ErrWizardStateTooLarge is returned when the encoded wizard state doesn't fit in the maximum cookie size
+var ErrWizardStateTooLarge = errors.New("wizard state exceeds the maximum cookie size")
+
+// Wizard keeps the state of a short multi-step flow, e.g. a signup or a checkout, in a signed cookie
+// instead of a server-side temporary storage. The state can be encrypted and compressed
+type Wizard struct {
+	Signer *CookieSignature
+	// Cookie is the template of the state cookie. Its Name is required
+	Cookie http.Cookie
+	// TTL of the state, renewed on every save. Defaults to one hour
+	TTL time.Duration
+	// MaxSize of the cookie value in bytes, larger states are rejected with ErrWizardStateTooLarge. Defaults to 3800
+	MaxSize int
+	// Encrypt hides the state from the client
+	Encrypt bool
+	// Compress deflates the state before it is encrypted and encoded
+	Compress bool
+}
+
+// WizardStep is the data of a step of a WizardState, with the version of its data format
+type WizardStep struct {
+	Version int             `json:"v"`
+	Data    json.RawMessage `json:"d"`
+}
+
+// WizardState is the data accumulated by the steps of a Wizard
+type WizardState struct {
+	Steps     map[string]WizardStep `json:"s,omitempty"`
+	ExpiresAt int64                 `json:"exp"`
+}
+
+// Set stores the data of the step with the version of its format
+func (state *WizardState) Set(step string, version int, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if state.Steps == nil {
+		state.Steps = make(map[string]WizardStep)
+	}
+	state.Steps[step] = WizardStep{Version: version, Data: data}
+	return nil
+}
+
+// Get reads the data of the step into value. It returns false if the step wasn't completed,
+// or was stored with another version, e.g. before a deployment changed the format of the step
+func (state WizardState) Get(step string, version int, value interface{}) (bool, error) {
+	stored, ok := state.Steps[step]
+	if !ok || stored.Version != version {
+		return false, nil
+	}
+	if err := json.Unmarshal(stored.Data, value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete removes the data of the step
+func (state *WizardState) Delete(step string) {
+	delete(state.Steps, step)
+}
+
+// Load reads the state from the cookie of the request. A request without the cookie starts with an empty state,
+// an invalid or expired cookie returns an error, and the caller decides whether the flow restarts
+func (wz Wizard) Load(r *http.Request) (*WizardState, error) {
+	cookie, err := r.Cookie(wz.Cookie.Name)
+	if err == http.ErrNoCookie {
+		return &WizardState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return wz.Decode(cookie.Value)
+}
+
+// Save sets the cookie with the state, renewing its expiration
+func (wz Wizard) Save(w http.ResponseWriter, state *WizardState) error {
+	value, err := wz.Encode(state)
+	if err != nil {
+		return err
+	}
+	cookie := wz.Cookie
+	cookie.Value = value
+	http.SetCookie(w, &cookie)
+	return nil
+}
+
+// Clear deletes the cookie, once the flow is completed or abandoned
+func (wz Wizard) Clear(w http.ResponseWriter) {
+	cookie := wz.Cookie
+	cookie.Value = ""
+	cookie.MaxAge = -1
+	http.SetCookie(w, &cookie)
+}
+
+// Encode renews the expiration of the state and encodes it into a signed cookie value
+func (wz Wizard) Encode(state *WizardState) (string, error) {
+	if wz.Signer == nil || wz.Cookie.Name == "" {
+		return "", errors.New("wizard signer and cookie name must be provided")
+	}
+	ttl := wz.TTL
+	if ttl <= 0 {
+		ttl = defaultWizardTTL
+	}
+	state.ExpiresAt = timeNow().Add(ttl).Unix()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	if wz.Compress {
+		var compressed bytes.Buffer
+		writer, _ := flate.NewWriter(&compressed, flate.BestCompression)
+		if _, err := writer.Write(data); err != nil {
+			return "", err
+		}
+		if err := writer.Close(); err != nil {
+			return "", err
+		}
+		data = compressed.Bytes()
+	}
+
+	var payload string
+	if wz.Encrypt {
+		if payload, err = wz.Signer.Encrypt(data); err != nil {
+			return "", err
+		}
+	} else {
+		payload = base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	hashBytes, err := wz.Signer.signingMAC(wz.macInput(payload))
+	if err != nil {
+		return "", err
+	}
+	value := payload + "." + hashBase64(hashBytes)
+	maxSize := wz.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultWizardMaxSize
+	}
+	if len(value) > maxSize {
+		return "", ErrWizardStateTooLarge
+	}
+	return value, nil
+}
+
+// Decode verifies the cookie value encoded by Encode, checks that it didn't expire and returns the state
+func (wz Wizard) Decode(value string) (*WizardState, error) {
+	if wz.Signer == nil {
+		return nil, errors.New("wizard signer must be provided")
+	}
+	index := strings.LastIndex(value, ".")
+	if index < 0 {
+		return nil, errInvalidSignature
+	}
+	payload := value[:index]
+	if _, err := wz.Signer.unsign(wz.macInput(payload) + value[index:]); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	var err error
+	if wz.Encrypt {
+		data, err = wz.Signer.Decrypt(payload)
+	} else {
+		data, err = base64.RawURLEncoding.DecodeString(payload)
+	}
+	if err != nil {
+		return nil, errInvalidSignature
+	}
+	if wz.Compress {
+		if data, err = ioutil.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(data)), maxWizardStateSize+1)); err != nil {
+			return nil, err
+		}
+		if len(data) > maxWizardStateSize {
+			return nil, ErrWizardStateTooLarge
+		}
+	}
+
+	var state WizardState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	if err := wz.Signer.validateClaims(Claims{ExpiresAt: state.ExpiresAt}, verifyOptions{}); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// macInput binds the cookie name, so the state of a wizard can't be replayed into another one
+func (wz Wizard) macInput(payload string) string {
+	return encodeValues([]string{wizardPurpose, wz.Cookie.Name, payload})
+}
@@ -0,0 +1,91 @@
+package cookiesignature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testShipping struct {
+	Address string `json:"address"`
+}
+
+func TestWizard(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	timeNow = func() time.Time { return start }
+	defer func() { timeNow = time.Now }()
+
+	cs, _ := NewCookieSignature([]string{"tobiiscool"})
+	for _, wz := range []Wizard{
+		{Signer: cs, Cookie: http.Cookie{Name: "checkout", Path: "/checkout"}},
+		{Signer: cs, Cookie: http.Cookie{Name: "checkout"}, Encrypt: true, Compress: true},
+	} {
+		state, err := wz.Load(httptest.NewRequest(http.MethodGet, "/checkout", nil))
+		if err != nil || len(state.Steps) != 0 {
+			t.Fatalf("expected an empty state, got: %+v, %v", state, err)
+		}
+		if err := state.Set("shipping", 1, testShipping{Address: "1 Infinite Loop"}); err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+
+		recorder := httptest.NewRecorder()
+		if err := wz.Save(recorder, state); err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		cookies := recorder.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Path != wz.Cookie.Path {
+			t.Fatalf("unexpected cookies: %v", cookies)
+		}
+		if wz.Encrypt == strings.Contains(cookies[0].Value, "eyJ") {
+			t.Fatalf("unexpected cookie value: %s", cookies[0].Value)
+		}
+
+		request := httptest.NewRequest(http.MethodGet, "/checkout", nil)
+		request.AddCookie(cookies[0])
+		loaded, err := wz.Load(request)
+		if err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		var shipping testShipping
+		if ok, err := loaded.Get("shipping", 1, &shipping); !ok || err != nil || shipping.Address != "1 Infinite Loop" {
+			t.Fatalf("unexpected step: %+v, %v, %v", shipping, ok, err)
+		}
+		// a step stored with another version of its format is ignored
+		if ok, err := loaded.Get("shipping", 2, &shipping); ok || err != nil {
+			t.Fatalf("expected the step to be ignored, got: %v, %v", ok, err)
+		}
+
+		// the state can't be replayed into another wizard
+		other := wz
+		other.Cookie.Name = "signup"
+		if _, err := other.Decode(cookies[0].Value); err != errInvalidSignature {
+			t.Fatalf("expected error: %s, got: %v", errInvalidSignature, err)
+		}
+
+		timeNow = func() time.Time { return start.Add(2 * time.Hour) }
+		if _, err := wz.Load(request); err != ErrTokenExpired {
+			t.Fatalf("expected error: %s, got: %v", ErrTokenExpired, err)
+		}
+		timeNow = func() time.Time { return start }
+
+		recorder = httptest.NewRecorder()
+		wz.Clear(recorder)
+		if cookies := recorder.Result().Cookies(); len(cookies) != 1 || cookies[0].MaxAge != -1 {
+			t.Fatalf("expected the cookie to be deleted, got: %v", cookies)
+		}
+	}
+
+	wz := Wizard{Signer: cs, Cookie: http.Cookie{Name: "checkout"}, MaxSize: 150}
+	state := &WizardState{}
+	_ = state.Set("notes", 1, strings.Repeat("a", 200))
+	if _, err := wz.Encode(state); err != ErrWizardStateTooLarge {
+		t.Fatalf("expected error: %s, got: %v", ErrWizardStateTooLarge, err)
+	}
+	// compression fits repetitive states
+	wz.Compress = true
+	if _, err := wz.Encode(state); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+}